@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	audit2 "github.com/softplan/tenkai-api/pkg/audit"
@@ -12,11 +14,13 @@ import (
 	"github.com/softplan/tenkai-api/pkg/dbms/repository"
 	"github.com/softplan/tenkai-api/pkg/global"
 	"github.com/softplan/tenkai-api/pkg/handlers"
+	"github.com/softplan/tenkai-api/pkg/mail"
 	"github.com/softplan/tenkai-api/pkg/rabbitmq"
 	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
 	"github.com/softplan/tenkai-api/pkg/service/core"
 	dockerapi "github.com/softplan/tenkai-api/pkg/service/docker"
 	"github.com/softplan/tenkai-api/pkg/tenkaihelm"
+	"github.com/softplan/tenkai-api/pkg/webhook"
 	"github.com/streadway/amqp"
 )
 
@@ -26,7 +30,6 @@ const (
 
 func main() {
 	logFields := global.AppFields{global.Function: "main"}
-	_ = os.Mkdir(global.KubeConfigBasePath, 0777)
 
 	global.Logger.Info(logFields, "loading config properties")
 
@@ -48,9 +51,15 @@ func main() {
 	initializeHelm(appContext)
 
 	appContext.Repositories = initRepository(&appContext.Database)
+	appContext.WebHookDispatcher = webhook.NewDispatcher(appContext.Repositories.WebHookDeliveryDAO,
+		appContext.Repositories.NotificationTemplateDAO, config.App.Passkey)
+	if config.App.SMTP.Host != "" {
+		appContext.Mailer = mail.NewMailer(config.App.SMTP)
+	}
 
 	//Elk setup
 	appContext.Elk, _ = appContext.Auditing.ElkClient(config.App.Elastic.URL, config.App.Elastic.Username, config.App.Elastic.Password)
+	appContext.Auditing = audit2.AuditingBuilder(getAuditSinks(appContext)...)
 
 	//RabbitMQ Connection
 	appContext.RabbitImpl = rabbitmq.RabbitImpl{}
@@ -64,25 +73,15 @@ func main() {
 
 	appContext.HelmService = tenkaihelm.HelmAPIImpl{}
 
-	createEnvironmentFiles(appContext)
+	handlers.EncryptLegacyEnvironmentCredentials(appContext)
+	handlers.CreateMissingGroups(appContext)
+	go handlers.StartEnvironmentMonitor(appContext, handlers.EnvironmentMonitorInterval)
+	go handlers.StartEphemeralEnvironmentReaper(appContext, handlers.EphemeralEnvironmentReaperInterval)
 
 	global.Logger.Info(logFields, "http server started")
 	handlers.StartHTTPServer(appContext)
 }
 
-func createEnvironmentFiles(appContext *handlers.AppContext) {
-	envs, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments("")
-	if err != nil {
-		logFields := global.AppFields{global.Function: "createEnvironmentFiles"}
-		global.Logger.Error(logFields, "Fail on create environment files")
-		return
-	}
-	for _, env := range envs {
-		handlers.CreateEnvironmentFile(env.Name, env.Token, appContext.K8sConfigPath+env.Group+"_"+env.Name,
-			env.CACertificate, env.ClusterURI, env.Namespace)
-	}
-}
-
 func createQueues(appContext *handlers.AppContext) {
 	createQueue(rabbitmq.InstallQueue, appContext)
 	createQueue(rabbitmq.ResultInstallQueue, appContext)
@@ -139,7 +138,34 @@ func initAPIs(appContext *handlers.AppContext) {
 	appContext.HelmServiceAPI = helmapi.HelmServiceBuilder()
 
 	appContext.Auditing = audit2.AuditingBuilder()
-	appContext.ConventionInterface = &core.ConventionImpl{}
+	appContext.KubeConfigProvider = core.NewKubeConfigProvider(filepath.Join(os.TempDir(), "tenkai-kubeconfig"))
+}
+
+func getAuditSinks(appContext *handlers.AppContext) []audit2.AuditSink {
+	logFields := global.AppFields{global.Function: "getAuditSinks"}
+
+	names := appContext.Configuration.App.Audit.Sinks
+	if len(strings.TrimSpace(names)) == 0 {
+		names = audit2.DatabaseSinkName
+	}
+
+	var sinks []audit2.AuditSink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case audit2.DatabaseSinkName:
+			sinks = append(sinks, audit2.DatabaseSink{AuditDAO: appContext.Repositories.AuditDAO})
+		case audit2.FileSinkName:
+			sink, err := audit2.NewFileSink(appContext.Configuration.App.Audit.File)
+			if err != nil {
+				global.Logger.Error(logFields, "Error opening audit file: "+err.Error())
+				continue
+			}
+			sinks = append(sinks, sink)
+		default:
+			global.Logger.Error(logFields, "Unknown audit sink "+name)
+		}
+	}
+	return sinks
 }
 
 func initRepository(database *dbms.Database) handlers.Repositories {
@@ -159,8 +185,19 @@ func initRepository(database *dbms.Database) handlers.Repositories {
 	repositories.UserEnvironmentRoleDAO = &repository.UserEnvironmentRoleDAOImpl{Db: database.Db}
 	repositories.NotesDAO = &repository.NotesDAOImpl{Db: database.Db}
 	repositories.WebHookDAO = &repository.WebHookDAOImpl{Db: database.Db}
+	repositories.WebHookDeliveryDAO = &repository.WebHookDeliveryDAOImpl{Db: database.Db}
+	repositories.NotificationTemplateDAO = &repository.NotificationTemplateDAOImpl{Db: database.Db}
+	repositories.EmailSubscriptionDAO = &repository.EmailSubscriptionDAOImpl{Db: database.Db}
+	repositories.PipelineTokenDAO = &repository.PipelineTokenDAOImpl{Db: database.Db}
+	repositories.ServiceAccountDAO = &repository.ServiceAccountDAOImpl{Db: database.Db}
+	repositories.APITokenDAO = &repository.APITokenDAOImpl{Db: database.Db}
 	repositories.DeploymentDAO = &repository.DeploymentDAOImpl{Db: database.Db}
 	repositories.RequestDeploymentDAO = &repository.RequestDeploymentDAOImpl{Db: database.Db}
+	repositories.EnvironmentStatusDAO = &repository.EnvironmentStatusDAOImpl{Db: database.Db}
+	repositories.EnvironmentTemplateDAO = &repository.EnvironmentTemplateDAOImpl{Db: database.Db}
+	repositories.EphemeralEnvironmentDAO = &repository.EphemeralEnvironmentDAOImpl{Db: database.Db}
+	repositories.GroupDAO = &repository.GroupDAOImpl{Db: database.Db}
+	repositories.AuditDAO = &repository.AuditDAOImpl{Db: database.Db}
 
 	return repositories
 }
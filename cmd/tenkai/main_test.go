@@ -2,8 +2,11 @@ package main
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/softplan/tenkai-api/pkg/configs"
 	dbms2 "github.com/softplan/tenkai-api/pkg/dbms"
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
@@ -115,24 +118,37 @@ func TestPublishRepoToQueueWithoutRepos(test *testing.T) {
 	assert.Panics(test, func() { publishRepoToQueue(appContext) }, appContext)
 }
 
-func TestCreateEnvironmentFiles(test *testing.T) {
-	appContext := handlers.AppContext{}
-	mockGetAllEnvironments(&appContext)
-	createEnvironmentFiles(&appContext)
+func TestEncryptLegacyEnvironmentCredentials(test *testing.T) {
+	appContext := handlers.AppContext{Configuration: &configs.Configuration{App: configs.App{Passkey: "qwert"}}}
+	mockEnvDao := mockGetAllEnvironments(&appContext)
+	mockEnvDao.On("EditEnvironment", mock.Anything).Return(nil)
+	handlers.EncryptLegacyEnvironmentCredentials(&appContext)
+	mockEnvDao.AssertNumberOfCalls(test, "EditEnvironment", 1)
 }
 
-func TestFailCreateEnvironmentFiles(test *testing.T) {
+func TestFailEncryptLegacyEnvironmentCredentials(test *testing.T) {
 	appContext := handlers.AppContext{}
 	mockFailGetAllEnvironments(&appContext)
-	createEnvironmentFiles(&appContext)
+	handlers.EncryptLegacyEnvironmentCredentials(&appContext)
+}
+
+func TestGetAuditSinks(test *testing.T) {
+	appContext := handlers.AppContext{Configuration: &configs.Configuration{}}
+	assert.Len(test, getAuditSinks(&appContext), 1)
+
+	appContext.Configuration.App.Audit.Sinks = "database, file, unknown"
+	appContext.Configuration.App.Audit.File = filepath.Join(os.TempDir(), "tenkai-audit-test.log")
+	defer os.Remove(appContext.Configuration.App.Audit.File)
+	assert.Len(test, getAuditSinks(&appContext), 2)
 }
 
-func mockGetAllEnvironments(appContext *handlers.AppContext) {
+func mockGetAllEnvironments(appContext *handlers.AppContext) *mockRepo.EnvironmentDAOInterface {
 	var envs []model.Environment
 	envs = append(envs, mockGetEnv())
 	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
 	mockEnvDao.On("GetAllEnvironments", "").Return(envs, nil)
 	appContext.Repositories.EnvironmentDAO = mockEnvDao
+	return mockEnvDao
 }
 
 func mockFailGetAllEnvironments(appContext *handlers.AppContext) {
@@ -4,13 +4,16 @@ import (
 	"context"
 	"github.com/olivere/elastic"
 	"github.com/olivere/elastic/config"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"sync"
 	"time"
 )
 
 //AuditingInterface AuditingInterface
 type AuditingInterface interface {
 	ElkClient(url string, username string, password string) (*elastic.Client, error)
-	DoAudit(ctx context.Context, client *elastic.Client, username string, operation string, values map[string]string)
+	DoAudit(ctx context.Context, client *elastic.Client, event model.AuditEvent)
 }
 
 //ElkInterface ElkInterface
@@ -24,20 +27,17 @@ type ElkImpl struct {
 
 //AuditingImpl AuditingImpl
 type AuditingImpl struct {
-	Elk ElkInterface
-}
-
-//Document structure
-type Document struct {
-	username  string
-	operation string
-	CreatedAt time.Time
+	Elk    ElkInterface
+	Sinks  []AuditSink
+	writes *sync.WaitGroup
 }
 
 //AuditingBuilder AuditingBuilder
-func AuditingBuilder() AuditingInterface {
+func AuditingBuilder(sinks ...AuditSink) AuditingInterface {
 	result := &AuditingImpl{}
 	result.Elk = &ElkImpl{}
+	result.Sinks = sinks
+	result.writes = &sync.WaitGroup{}
 	return result
 }
 
@@ -56,24 +56,55 @@ func (a AuditingImpl) ElkClient(url string, username string, password string) (*
 	return a.Elk.NewClient(config)
 }
 
-//DoAudit create new audit log into elk
-func (a AuditingImpl) DoAudit(ctx context.Context, client *elastic.Client, username string, operation string, values map[string]string) {
+//DoAudit completes an audit event with the request information of the context and writes it
+//to every sink, and into elk when a client is given. The sinks are written in the background,
+//so a slow or failing sink does not delay the request, its errors are only logged.
+func (a AuditingImpl) DoAudit(ctx context.Context, client *elastic.Client, event model.AuditEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	if len(event.Outcome) == 0 {
+		event.Outcome = model.AuditOutcomeSuccess
+	}
+	info := GetRequestInfo(ctx)
+	if len(event.RequestID) == 0 {
+		event.RequestID = info.RequestID
+	}
+	if len(event.ClientIP) == 0 {
+		event.ClientIP = info.ClientIP
+	}
+	if event.Action != model.AuditActionRequest && event.Action != model.AuditActionDenied {
+		MarkAudited(ctx)
+	}
+
+	sinks := a.Sinks
 	if client != nil {
-		bulk := client.Bulk().Index("tenkai1audit").Type("audit")
-		doc := buildDoc(username, operation, values)
-		bulk.Add(elastic.NewBulkIndexRequest().Doc(doc)).Do(ctx)
+		sinks = append(sinks[:len(sinks):len(sinks)], ElkSink{Client: client})
+	}
+	if a.writes != nil {
+		a.writes.Add(1)
+	}
+	go a.write(sinks, event)
+}
+
+//write writes the audit event to the sinks, out of the context of the request which may be over by then
+func (a AuditingImpl) write(sinks []AuditSink, event model.AuditEvent) {
+	if a.writes != nil {
+		defer a.writes.Done()
+	}
+	for _, sink := range sinks {
+		if err := sink.Write(context.Background(), event); err != nil {
+			global.Logger.Error(global.AppFields{global.Function: "DoAudit", "action": event.Action},
+				"Error writing audit event: "+err.Error())
+		}
 	}
 }
 
-func buildDoc(username string, operation string, values map[string]string) map[string]string {
-	doc := make(map[string]string)
-	doc["username"] = username
-	doc["operation"] = operation
-	doc["createat"] = time.Now().UTC().Format("2006-01-02T15:04:05-0700")
-	for k, v := range values {
-		doc[k] = v
+//wait waits for the audit events still being written
+func (a AuditingImpl) wait() {
+	if a.writes != nil {
+		a.writes.Wait()
 	}
-	return doc
 }
 
 func buildConfig(url string, username string, password string) *config.Config {
@@ -23,14 +23,6 @@ func TestGetAuditBuilder(t *testing.T) {
 	assert.NotNil(t, a)
 }
 
-func TestBuildDock(t *testing.T) {
-	myMap := make(map[string]string)
-	myMap["a"] = "a_value"
-	myMap["b"] = "b_value"
-	doc := buildDoc("alfa", "beta", myMap)
-	assert.NotNil(t, doc)
-}
-
 func TestBuildConfig(t *testing.T) {
 	config := buildConfig("http://audit", "alfa", "beta")
 	assert.NotNil(t, config)
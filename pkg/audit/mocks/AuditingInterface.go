@@ -6,15 +6,16 @@ import config "github.com/olivere/elastic/config"
 import context "context"
 import elastic "github.com/olivere/elastic"
 import mock "github.com/stretchr/testify/mock"
+import model "github.com/softplan/tenkai-api/pkg/dbms/model"
 
 // AuditingInterface is an autogenerated mock type for the AuditingInterface type
 type AuditingInterface struct {
 	mock.Mock
 }
 
-// DoAudit provides a mock function with given fields: ctx, client, username, operation, values
-func (_m *AuditingInterface) DoAudit(ctx context.Context, client *elastic.Client, username string, operation string, values map[string]string) {
-	_m.Called(ctx, client, username, operation, values)
+// DoAudit provides a mock function with given fields: ctx, client, event
+func (_m *AuditingInterface) DoAudit(ctx context.Context, client *elastic.Client, event model.AuditEvent) {
+	_m.Called(ctx, client, event)
 }
 
 // ElkClient provides a mock function with given fields: url, username, password
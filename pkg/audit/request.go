@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type requestInfoKey struct{}
+
+//RequestInfo identifies the request an audit event comes from
+type RequestInfo struct {
+	RequestID string
+	ClientIP  string
+	audited   *int32
+}
+
+//WithRequestInfo returns a copy of the context holding the request information
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	if info.audited == nil {
+		info.audited = new(int32)
+	}
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+//GetRequestInfo returns the request information of the context, if any
+func GetRequestInfo(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+//MarkAudited records that the request of the context already has an audit event of its own
+func MarkAudited(ctx context.Context) {
+	if info := GetRequestInfo(ctx); info.audited != nil {
+		atomic.StoreInt32(info.audited, 1)
+	}
+}
+
+//IsAudited tells whether the request of the context already has an audit event of its own
+func IsAudited(ctx context.Context) bool {
+	info := GetRequestInfo(ctx)
+	return info.audited != nil && atomic.LoadInt32(info.audited) == 1
+}
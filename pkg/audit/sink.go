@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/olivere/elastic"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/dbms/repository"
+)
+
+//Names of the audit sinks accepted by the configuration
+const (
+	DatabaseSinkName = "database"
+	FileSinkName     = "file"
+)
+
+//AuditSink receives the audit events
+type AuditSink interface {
+	Write(ctx context.Context, event model.AuditEvent) error
+}
+
+//DatabaseSink appends the audit events to the hash chain of the audit_events table
+type DatabaseSink struct {
+	AuditDAO repository.AuditDAOInterface
+}
+
+//Write stores the audit event
+func (s DatabaseSink) Write(ctx context.Context, event model.AuditEvent) error {
+	_, err := s.AuditDAO.CreateAuditEvent(event)
+	return err
+}
+
+//ElkSink indexes the audit events in Elasticsearch
+type ElkSink struct {
+	Client *elastic.Client
+}
+
+//Write indexes the audit event
+func (s ElkSink) Write(ctx context.Context, event model.AuditEvent) error {
+	bulk := s.Client.Bulk().Index("tenkai1audit").Type("audit")
+	_, err := bulk.Add(elastic.NewBulkIndexRequest().Doc(buildDoc(event))).Do(ctx)
+	return err
+}
+
+//buildDoc builds the document of an audit event, keeping the username, operation and createat fields
+//of the documents indexed before the audit events so the existing dashboards keep working.
+//The values once flattened in the document are now in resourceType, resourceId, before and after.
+func buildDoc(event model.AuditEvent) map[string]string {
+	return map[string]string{
+		"username":      event.Actor,
+		"operation":     event.Action,
+		"createat":      event.CreatedAt.UTC().Format("2006-01-02T15:04:05-0700"),
+		"environmentId": strconv.FormatUint(uint64(event.EnvironmentID), 10),
+		"resourceType":  event.ResourceType,
+		"resourceId":    event.ResourceID,
+		"before":        event.Before,
+		"after":         event.After,
+		"requestId":     event.RequestID,
+		"clientIp":      event.ClientIP,
+		"outcome":       event.Outcome,
+	}
+}
+
+//FileSink appends the audit events to a file, one JSON document per line
+type FileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+//NewFileSink opens the file of a FileSink, creating it when missing
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+//Write appends the audit event
+func (s *FileSink) Write(ctx context.Context, event model.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type memorySink struct {
+	events []model.AuditEvent
+	err    error
+}
+
+func (s *memorySink) Write(ctx context.Context, event model.AuditEvent) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestDoAudit_FanOut(t *testing.T) {
+	failing := &memorySink{err: errors.New("unavailable")}
+	working := &memorySink{}
+	a := AuditingBuilder(failing, working)
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{RequestID: "abc", ClientIP: "10.0.0.1"})
+	a.DoAudit(ctx, nil, model.AuditEvent{Actor: "alfa", Action: "deploy", EnvironmentID: 999})
+	a.(*AuditingImpl).wait()
+
+	assert.Len(t, failing.events, 1)
+	assert.Len(t, working.events, 1)
+	event := working.events[0]
+	assert.Equal(t, "alfa", event.Actor)
+	assert.Equal(t, "deploy", event.Action)
+	assert.Equal(t, uint(999), event.EnvironmentID)
+	assert.Equal(t, "abc", event.RequestID)
+	assert.Equal(t, "10.0.0.1", event.ClientIP)
+	assert.Equal(t, model.AuditOutcomeSuccess, event.Outcome)
+	assert.False(t, event.CreatedAt.IsZero())
+	assert.True(t, IsAudited(ctx))
+}
+
+func TestDoAudit_RequestEventDoesNotMark(t *testing.T) {
+	a := AuditingBuilder(&memorySink{})
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{RequestID: "abc"})
+	a.DoAudit(ctx, nil, model.AuditEvent{Actor: "alfa", Action: model.AuditActionRequest})
+	a.(*AuditingImpl).wait()
+
+	assert.False(t, IsAudited(ctx))
+	assert.False(t, IsAudited(context.Background()))
+}
+
+type blockingSink struct {
+	release chan struct{}
+	written chan model.AuditEvent
+}
+
+func (s *blockingSink) Write(ctx context.Context, event model.AuditEvent) error {
+	<-s.release
+	s.written <- event
+	return nil
+}
+
+func TestDoAudit_DoesNotWaitForSinks(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{}), written: make(chan model.AuditEvent, 1)}
+	a := AuditingBuilder(sink)
+
+	a.DoAudit(context.Background(), nil, model.AuditEvent{Actor: "alfa", Action: "deploy"})
+	close(sink.release)
+
+	event := <-sink.written
+	assert.Equal(t, "deploy", event.Action)
+	a.(*AuditingImpl).wait()
+}
+
+func TestDatabaseSink(t *testing.T) {
+	mockAuditDAO := &mockRepo.AuditDAOInterface{}
+	mockAuditDAO.On("CreateAuditEvent", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Actor == "alfa" && event.EnvironmentID == 999
+	})).Return(1, nil)
+
+	sink := DatabaseSink{AuditDAO: mockAuditDAO}
+	err := sink.Write(context.Background(), model.AuditEvent{Actor: "alfa", Action: "deploy", EnvironmentID: 999})
+
+	assert.NoError(t, err)
+	mockAuditDAO.AssertNumberOfCalls(t, "CreateAuditEvent", 1)
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Write(context.Background(), model.AuditEvent{Actor: "alfa", Action: "deploy"}))
+	assert.NoError(t, sink.Write(context.Background(), model.AuditEvent{Actor: "beta", Action: "promote"}))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var events []model.AuditEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event model.AuditEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	assert.Len(t, events, 2)
+	assert.Equal(t, "promote", events[1].Action)
+}
+
+func TestBuildDoc(t *testing.T) {
+	event := model.AuditEvent{Actor: "alfa", Action: "deploy", EnvironmentID: 999, ResourceID: "foo",
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	doc := buildDoc(event)
+	assert.Equal(t, "alfa", doc["username"])
+	assert.Equal(t, "deploy", doc["operation"])
+	assert.Equal(t, "2020-01-02T03:04:05+0000", doc["createat"])
+	assert.Equal(t, "999", doc["environmentId"])
+	assert.Equal(t, "foo", doc["resourceId"])
+}
+
+func TestGetRequestInfo_Missing(t *testing.T) {
+	assert.Equal(t, RequestInfo{}, GetRequestInfo(context.Background()))
+}
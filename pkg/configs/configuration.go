@@ -7,9 +7,11 @@ import (
 	"github.com/spf13/viper"
 )
 
-//Server - Dados do servidor
+//Server - Dados do servidor, TrustedProxies is a comma separated list of the addresses or CIDRs
+//of the proxies whose X-Forwarded-For header is trusted
 type Server struct {
-	Port string
+	Port           string
+	TrustedProxies string
 }
 
 //Configuration - Configuração geral da aplicação
@@ -29,12 +31,15 @@ type App struct {
 	Dbms       Dbms
 	Elastic    Elastic
 	Rabbit     Rabbit
+	Audit      Audit
+	SMTP       SMTP
 	HelmAPIUrl string
 }
 
 //Rabbit struct
 type Rabbit struct {
-	URI string
+	URI     string
+	Passkey string
 }
 
 //Elastic Config Structure
@@ -44,6 +49,21 @@ type Elastic struct {
 	Password string
 }
 
+//Audit Config Structure, Sinks is a comma separated list of database and file
+type Audit struct {
+	Sinks string
+	File  string
+}
+
+//SMTP Config Structure, email notifications are disabled without a host
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
 //ReadConfig inicia as configurações
 func ReadConfig(configFile string) (*Configuration, error) {
 
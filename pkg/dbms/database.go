@@ -49,8 +49,23 @@ func (database *Database) Connect(dbmsURI string, local bool) {
 	database.Db.AutoMigrate(&model2.UserEnvironmentRole{})
 	database.Db.AutoMigrate(&model2.Notes{})
 	database.Db.AutoMigrate(&model2.WebHook{})
+	database.Db.AutoMigrate(&model2.WebHookDelivery{})
+	database.Db.AutoMigrate(&model2.NotificationTemplate{})
+	database.Db.AutoMigrate(&model2.EmailSubscription{})
+	database.Db.AutoMigrate(&model2.PipelineToken{})
+	database.Db.AutoMigrate(&model2.ServiceAccount{})
+	database.Db.AutoMigrate(&model2.APIToken{})
 	database.Db.AutoMigrate(&model2.Deployment{})
 	database.Db.AutoMigrate(&model2.RequestDeployment{})
+	database.Db.AutoMigrate(&model2.EnvironmentStatus{})
+	database.Db.AutoMigrate(&model2.EnvironmentTemplate{})
+	database.Db.AutoMigrate(&model2.EnvironmentTemplateVariable{})
+	database.Db.AutoMigrate(&model2.EnvironmentTemplateChart{})
+	database.Db.AutoMigrate(&model2.EphemeralEnvironment{})
+	database.Db.AutoMigrate(&model2.Group{})
+	database.Db.AutoMigrate(&model2.GroupVariable{})
+	database.Db.AutoMigrate(&model2.GroupRole{})
+	database.Db.AutoMigrate(&model2.AuditEvent{})
 	database.Db.Model(&model.ValueRule{}).
 		AddForeignKey("variable_rule_id", "variable_rules(id)", "CASCADE", "CASCADE")
 	database.Db.Model(&model.Deployment{}).
@@ -59,4 +74,16 @@ func (database *Database) Connect(dbmsURI string, local bool) {
 		AddForeignKey("request_deployment_id", "request_deployments(id)", "CASCADE", "CASCADE")
 	database.Db.Model(&model.RequestDeployment{}).
 		AddForeignKey("user_id", "users(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.EnvironmentTemplateVariable{}).
+		AddForeignKey("environment_template_id", "environment_templates(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.EnvironmentTemplateChart{}).
+		AddForeignKey("environment_template_id", "environment_templates(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.EphemeralEnvironment{}).
+		AddForeignKey("environment_id", "environments(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.GroupVariable{}).
+		AddForeignKey("group_id", "groups(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.GroupRole{}).
+		AddForeignKey("group_id", "groups(id)", "CASCADE", "CASCADE")
+	database.Db.Model(&model.GroupRole{}).
+		AddForeignKey("user_id", "users(id)", "CASCADE", "CASCADE")
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+//ServiceAccount - Identity of an automation. It is backed by a user, so environments and roles are
+//granted to it like to any other user, and it authenticates with its API tokens.
+type ServiceAccount struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"unique_index"`
+	Description string `json:"description"`
+	UserID      uint   `json:"userId"`
+	CreatedBy   string `json:"createdBy"`
+}
+
+//ServiceAccountResponse - ServiceAccountResponse
+type ServiceAccountResponse struct {
+	List []ServiceAccount `json:"list"`
+}
+
+//APIToken - Token authenticating a service account or a user without a JWT. It is restricted to a set of
+//environments and SecurityOperation policies, on top of the grants of its owner, until it expires or is
+//revoked. Only the SHA-256 hash of the token is stored.
+type APIToken struct {
+	gorm.Model
+	Name             string         `json:"name"`
+	TokenHash        string         `json:"-" gorm:"unique_index"`
+	UserID           uint           `json:"userId" gorm:"index"`
+	ServiceAccountID uint           `json:"serviceAccountId"`
+	Environments     pq.Int64Array  `json:"environments" gorm:"type:integer[]"`
+	Policies         pq.StringArray `json:"policies" gorm:"type:text[]"`
+	ExpiresAt        *time.Time     `json:"expiresAt"`
+	RevokedAt        *time.Time     `json:"revokedAt"`
+	LastUsedAt       *time.Time     `json:"lastUsedAt"`
+	CreatedBy        string         `json:"createdBy"`
+}
+
+//APITokenResponse - APITokenResponse
+type APITokenResponse struct {
+	List []APIToken `json:"list"`
+}
+
+//NewAPITokenResponse - The token of a new API token, never shown again
+type NewAPITokenResponse struct {
+	ID    int    `json:"id"`
+	Token string `json:"token"`
+}
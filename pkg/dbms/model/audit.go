@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+//Outcomes of an audited action
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied"
+)
+
+//Actions of the audit events recorded for every request by the audit middleware
+const (
+	AuditActionRequest = "request"
+	AuditActionDenied  = "accessDenied"
+)
+
+//AuditEvent - Audit record of an action, chained to the previous event by its hash
+type AuditEvent struct {
+	ID            uint      `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"index"`
+	Actor         string    `json:"actor" gorm:"index"`
+	Action        string    `json:"action" gorm:"index"`
+	EnvironmentID uint      `json:"environmentId" gorm:"index"`
+	ResourceType  string    `json:"resourceType"`
+	ResourceID    string    `json:"resourceId"`
+	Before        string    `json:"before" gorm:"type:text"`
+	After         string    `json:"after" gorm:"type:text"`
+	RequestID     string    `json:"requestId"`
+	ClientIP      string    `json:"clientIp"`
+	Outcome       string    `json:"outcome"`
+	PreviousHash  string    `json:"previousHash"`
+	Hash          string    `json:"hash" gorm:"unique_index"`
+}
+
+//AuditEventFilter - Filters of the audit query, zero values are ignored
+type AuditEventFilter struct {
+	Actor         string
+	Action        string
+	EnvironmentID uint
+	From          time.Time
+	To            time.Time
+	Page          int
+	PageSize      int
+}
+
+//AuditEventResult Model
+type AuditEventResult struct {
+	List     []AuditEvent `json:"list"`
+	Total    int          `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"pageSize"`
+}
+
+//AuditChainVerification - Result of the verification of the audit hash chain
+type AuditChainVerification struct {
+	Valid          bool   `json:"valid"`
+	Checked        int    `json:"checked"`
+	LastHash       string `json:"lastHash"`
+	InvalidEventID uint   `json:"invalidEventId,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
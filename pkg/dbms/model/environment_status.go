@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//EnvironmentStatus last connectivity check recorded for an environment
+type EnvironmentStatus struct {
+	gorm.Model
+	EnvironmentID        uint       `json:"environmentId" gorm:"unique_index"`
+	Healthy              bool       `json:"healthy"`
+	Message              string     `json:"message"`
+	TokenExpiresAt       *time.Time `json:"tokenExpiresAt"`
+	CertificateExpiresAt *time.Time `json:"certificateExpiresAt"`
+	CheckedAt            time.Time  `json:"checkedAt"`
+}
+
+//ConnectivityCheck result of a single connectivity check
+type ConnectivityCheck struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+//EnvironmentConnectivity struct response /environments/{id}/connectivity GET
+type EnvironmentConnectivity struct {
+	EnvironmentID        uint                `json:"environmentId"`
+	Healthy              bool                `json:"healthy"`
+	Checks               []ConnectivityCheck `json:"checks"`
+	TokenExpiresAt       *time.Time          `json:"tokenExpiresAt"`
+	CertificateExpiresAt *time.Time          `json:"certificateExpiresAt"`
+	CheckedAt            time.Time           `json:"checkedAt"`
+}
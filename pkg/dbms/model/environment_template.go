@@ -0,0 +1,48 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+//EnvironmentTemplate reusable set of scopes, variables and charts used to bootstrap environments
+type EnvironmentTemplate struct {
+	gorm.Model
+	Name             string                        `json:"name" gorm:"unique_index"`
+	Description      string                        `json:"description"`
+	ProductVersionID int                           `json:"productVersionId"`
+	Variables        []EnvironmentTemplateVariable `json:"variables" gorm:"foreignkey:EnvironmentTemplateID"`
+	Charts           []EnvironmentTemplateChart    `json:"charts" gorm:"foreignkey:EnvironmentTemplateID"`
+}
+
+//EnvironmentTemplateVariable variable created by a template. The value may reference
+//environment placeholders such as ${NAMESPACE} and ${ENV.name}
+type EnvironmentTemplateVariable struct {
+	gorm.Model
+	EnvironmentTemplateID uint   `json:"environmentTemplateId"`
+	Scope                 string `json:"scope"`
+	Name                  string `json:"name"`
+	Value                 string `json:"value"`
+	Type                  string `json:"type"`
+	Secret                bool   `json:"secret"`
+	Description           string `json:"description"`
+}
+
+//EnvironmentTemplateChart chart installed by a template
+type EnvironmentTemplateChart struct {
+	gorm.Model
+	EnvironmentTemplateID uint   `json:"environmentTemplateId"`
+	Chart                 string `json:"chart"`
+	ChartVersion          string `json:"chartVersion"`
+	Name                  string `json:"name"`
+}
+
+//EnvironmentTemplateResult struct response /environment-templates GET
+type EnvironmentTemplateResult struct {
+	List []EnvironmentTemplate `json:"list"`
+}
+
+//EnvironmentFromTemplatePayload struct request /environments/from-template POST
+type EnvironmentFromTemplatePayload struct {
+	TemplateID       uint        `json:"templateId"`
+	Environment      Environment `json:"environment"`
+	ProductVersionID int         `json:"productVersionId"`
+	Install          bool        `json:"install"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//EphemeralEnvironment expiry registered for an environment created for a feature branch
+type EphemeralEnvironment struct {
+	gorm.Model
+	EnvironmentID     uint      `json:"environmentId" gorm:"unique_index"`
+	BaseEnvironmentID uint      `json:"baseEnvironmentId"`
+	ExpiresAt         time.Time `json:"expiresAt" gorm:"index"`
+	CreatedBy         string    `json:"createdBy"`
+}
+
+//EphemeralEnvironmentResult Model
+type EphemeralEnvironmentResult struct {
+	List []EphemeralEnvironment `json:"list"`
+}
+
+//EphemeralEnvironmentChart chart deployed in an ephemeral environment, optionally with another image tag
+type EphemeralEnvironmentChart struct {
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chartVersion"`
+	Name         string `json:"name"`
+	ImageTag     string `json:"imageTag"`
+}
+
+//EphemeralEnvironmentPayload struct request /environments/ephemeral POST
+type EphemeralEnvironmentPayload struct {
+	BaseEnvironmentID int                         `json:"baseEnvironmentId"`
+	NamespaceSuffix   string                      `json:"namespaceSuffix"`
+	TTL               string                      `json:"ttl"`
+	Charts            []EphemeralEnvironmentChart `json:"charts"`
+}
+
+//ExtendEphemeralEnvironmentPayload struct request /environments/ephemeral/{id}/extend POST
+type ExtendEphemeralEnvironmentPayload struct {
+	TTL string `json:"ttl"`
+}
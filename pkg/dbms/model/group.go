@@ -0,0 +1,44 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+//Group - Environment group, its members are the environments whose Group is the group name
+type Group struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"unique_index"`
+	Description string `json:"description"`
+}
+
+//GroupResult Model
+type GroupResult struct {
+	List []Group `json:"list"`
+}
+
+//GroupVariable - Global variable inherited by every environment of a group
+type GroupVariable struct {
+	gorm.Model
+	GroupID     uint   `json:"groupId" gorm:"index"`
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Secret      bool   `json:"secret"`
+	Description string `json:"description"`
+}
+
+//GroupVariableResult Model
+type GroupVariableResult struct {
+	List []GroupVariable `json:"list"`
+}
+
+//GroupRole - Security operation granted to a user on every environment of a group
+type GroupRole struct {
+	gorm.Model
+	GroupID             uint `json:"groupId"`
+	UserID              uint `json:"userId"`
+	SecurityOperationID uint `json:"securityOperationId"`
+}
+
+//GroupRoleResult Model
+type GroupRoleResult struct {
+	List []GroupRole `json:"list"`
+}
@@ -8,15 +8,18 @@ import (
 //Environment - Environment Model
 type Environment struct {
 	gorm.Model
-	Group          string `json:"group"`
-	Name           string `json:"name"`
-	ClusterURI     string `json:"cluster_uri"`
-	CACertificate  string `json:"ca_certificate"`
-	Token          string `json:"token"`
-	Namespace      string `json:"namespace"`
-	Gateway        string `json:"gateway"`
-	ProductVersion string `json:"productVersion"`
-	CurrentRelease string `json:"currentRelease"`
+	Group                   string             `json:"group"`
+	Name                    string             `json:"name"`
+	ClusterURI              string             `json:"cluster_uri"`
+	CACertificate           string             `json:"ca_certificate"`
+	Token                   string             `json:"token"`
+	Namespace               string             `json:"namespace"`
+	Gateway                 string             `json:"gateway"`
+	ProductVersion          string             `json:"productVersion"`
+	CurrentRelease          string             `json:"currentRelease"`
+	BlockOnSchemaErrors     bool               `json:"blockOnSchemaErrors"`
+	BlockOnInvalidVariables bool               `json:"blockOnInvalidVariables"`
+	Status                  *EnvironmentStatus `json:"status,omitempty" gorm:"-"`
 }
 
 //EnvResult Model
@@ -47,7 +50,15 @@ type UserResult struct {
 
 //DataElement dataElement
 type DataElement struct {
-	Data Environment `json:"data"`
+	Data      Environment       `json:"data"`
+	Namespace *NamespaceOptions `json:"namespace,omitempty"`
+}
+
+//NamespaceOptions options to create the namespace of a new environment
+type NamespaceOptions struct {
+	Create        bool              `json:"create"`
+	Labels        map[string]string `json:"labels"`
+	ResourceQuota map[string]string `json:"resourceQuota"`
 }
 
 //DataVariableElement dataElement
@@ -88,11 +99,78 @@ type Variable struct {
 	ChartVersion  string `gorm:"-" json:"chartVersion"`
 	Name          string `json:"name" gorm:"index:var_name"`
 	Value         string `json:"value"`
+	Type          string `json:"type"`
 	Secret        bool   `json:"secret"`
 	Description   string `json:"description"`
 	EnvironmentID int    `json:"environmentId"`
 }
 
+//Variable types, an empty type lets helm guess the type of the value
+const (
+	VariableTypeString = "string"
+	VariableTypeInt    = "int"
+	VariableTypeBool   = "bool"
+	VariableTypeList   = "list"
+	VariableTypeJSON   = "json"
+)
+
+//EnvironmentExport Model
+type EnvironmentExport struct {
+	Group     string           `json:"group"`
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Gateway   string           `json:"gateway"`
+	Variables []VariableExport `json:"variables"`
+}
+
+//VariableExport Model
+type VariableExport struct {
+	Scope       string `json:"scope"`
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Secret      bool   `json:"secret"`
+	Description string `json:"description"`
+}
+
+//ResolveVariablesRequest Struct
+type ResolveVariablesRequest struct {
+	EnvironmentID int    `json:"environmentId"`
+	Scope         string `json:"scope"`
+	Value         string `json:"value"`
+}
+
+//ResolvedVariable Struct
+type ResolvedVariable struct {
+	Scope    string `json:"scope"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Resolved string `json:"resolved"`
+	Error    string `json:"error"`
+}
+
+//ResolveVariablesResult Struct
+type ResolveVariablesResult struct {
+	Variables []ResolvedVariable `json:"variables"`
+}
+
+//EffectiveVariable Struct - Final value of a helm key and the layer it came from
+type EffectiveVariable struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Resolved string `json:"resolved"`
+	Type     string `json:"type"`
+	Secret   bool   `json:"secret"`
+	Source   string `json:"source"`
+	Error    string `json:"error"`
+}
+
+//EffectiveVariablesResult Struct
+type EffectiveVariablesResult struct {
+	Variables []EffectiveVariable `json:"variables"`
+}
+
 //VariableData Struct
 type VariableData struct {
 	Data []Variable `json:"data"`
@@ -106,10 +184,11 @@ type InstallArguments struct {
 
 //InstallPayload Struct
 type InstallPayload struct {
-	EnvironmentID int    `json:"environmentId"`
-	Chart         string `json:"chart"`
-	ChartVersion  string `json:"chartVersion"`
-	Name          string `json:"name"`
+	EnvironmentID int               `json:"environmentId"`
+	Chart         string            `json:"chart"`
+	ChartVersion  string            `json:"chartVersion"`
+	Name          string            `json:"name"`
+	Overrides     map[string]string `json:"overrides,omitempty"`
 }
 
 //MultipleInstallPayload struct
@@ -135,6 +214,15 @@ type GetChartRequest struct {
 //InvalidVariablesResult Model
 type InvalidVariablesResult struct {
 	InvalidVariables []InvalidVariable
+	SchemaViolations []SchemaViolation `json:",omitempty"`
+}
+
+//SchemaViolation Model
+type SchemaViolation struct {
+	Chart      string `json:"chart"`
+	Path       string `json:"path"`
+	Message    string `json:"message"`
+	SchemaPath string `json:"schemaPath"`
 }
 
 //InvalidVariable Model
@@ -145,6 +233,7 @@ type InvalidVariable struct {
 	VariableRule string `json:"variableRule"`
 	RuleType     string `json:"ruleType"`
 	ValueRule    string `json:"valueRule"`
+	Severity     string `json:"severity"`
 }
 
 type selectItem struct {
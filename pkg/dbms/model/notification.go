@@ -0,0 +1,38 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+//Channels of a webhook. The generic webhook posts the JSON payload of the event, the chat
+//channels post a message rendered from the template of the event.
+const (
+	ChannelWebHook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelTeams   = "teams"
+)
+
+//NotificationTemplate - Template of the message posted to a chat channel for an event, replacing the default one.
+//Templates use the text/template syntax over the JSON payload of the event, e.g. {{.environment}}.
+type NotificationTemplate struct {
+	gorm.Model
+	Channel  string `json:"channel" gorm:"unique_index:idx_notification_template"`
+	Event    string `json:"event" gorm:"unique_index:idx_notification_template"`
+	Template string `json:"template" gorm:"type:text"`
+	Custom   bool   `json:"custom" gorm:"-"`
+}
+
+//EmailSubscription - A user opted in to receive by email the results of the deploy requests made in an environment
+type EmailSubscription struct {
+	gorm.Model
+	UserID        uint `json:"userId" gorm:"unique_index:idx_email_subscription"`
+	EnvironmentID uint `json:"environmentId" gorm:"unique_index:idx_email_subscription"`
+}
+
+//EmailSubscriptionResponse struct
+type EmailSubscriptionResponse struct {
+	List []EmailSubscription `json:"list"`
+}
+
+//NotificationTemplateResponse struct
+type NotificationTemplateResponse struct {
+	List []NotificationTemplate `json:"list"`
+}
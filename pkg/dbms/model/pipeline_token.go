@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+//PipelineToken - API token of a CI pipeline, allowed to deploy new image tags to a set of environments
+//or, with the registry scope, to notify the tags pushed to a registry.
+//Only the SHA-256 hash of the token is stored, the token itself is returned once when it is created.
+type PipelineToken struct {
+	gorm.Model
+	Name         string         `json:"name" gorm:"unique_index"`
+	TokenHash    string         `json:"-" gorm:"unique_index"`
+	Environments pq.Int64Array  `json:"environments" gorm:"type:integer[]"`
+	Scopes       pq.StringArray `json:"scopes" gorm:"type:varchar(32)[]"`
+	CreatedBy    string         `json:"createdBy"`
+}
+
+//Scopes of a pipeline token. Tokens without scopes, created before scopes existed, may only deploy.
+const (
+	PipelineScopeDeploy   = "deploy"
+	PipelineScopeRegistry = "registry"
+)
+
+//PipelineTokenResponse - PipelineTokenResponse
+type PipelineTokenResponse struct {
+	List []PipelineToken `json:"list"`
+}
+
+//NewPipelineTokenResponse - The token of a new pipeline, never shown again
+type NewPipelineTokenResponse struct {
+	ID    int    `json:"id"`
+	Token string `json:"token"`
+}
+
+//CIDeployPayload - Image tag deployed by a CI pipeline. The release name defaults to the name of the chart
+//and the chart version to the latest one.
+type CIDeployPayload struct {
+	EnvironmentID int    `json:"environmentId"`
+	Chart         string `json:"chart"`
+	ChartVersion  string `json:"chartVersion"`
+	Name          string `json:"name"`
+	Tag           string `json:"tag"`
+}
+
+//CIDeployResponse - The deploy request queued for a CI pipeline, polled at /requestDeployments/{id}
+type CIDeployResponse struct {
+	RequestDeploymentID int `json:"requestDeploymentId"`
+}
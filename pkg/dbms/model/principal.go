@@ -5,4 +5,12 @@ type Principal struct {
 	Name  string
 	Email string
 	Roles []string
+	Token *TokenScope `json:",omitempty"`
+}
+
+//TokenScope - Restrictions of a principal authenticated by an API token instead of a JWT
+type TokenScope struct {
+	ID           uint
+	Environments []int64
+	Policies     []string
 }
@@ -11,6 +11,7 @@ type Product struct {
 	gorm.Model
 	Name             string `json:"name"`
 	ValidateReleases bool   `json:"validateReleases"`
+	AutoUpdateTags   bool   `json:"autoUpdateTags"`
 }
 
 //ProductVersion struct
@@ -30,6 +31,7 @@ type ProductVersionService struct {
 	ProductVersionID   int    `json:"productVersionId"`
 	ServiceName        string `json:"serviceName"`
 	DockerImageTag     string `json:"dockerImageTag"`
+	AvailableTag       string `json:"availableTag"`
 	LatestVersion      string `gorm:"-" json:"latestVersion"`
 	ChartLatestVersion string `gorm:"-" json:"chartLatestVersion"`
 	Notes              string `json:"notes"`
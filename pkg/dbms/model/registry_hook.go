@@ -0,0 +1,47 @@
+package model
+
+//RegistryHookPayload - A push notification of a Docker Registry v2 (events) or of Harbor (type and event_data)
+type RegistryHookPayload struct {
+	Events    []RegistryEvent  `json:"events"`
+	Type      string           `json:"type"`
+	EventData *HarborEventData `json:"event_data"`
+}
+
+//RegistryEvent - Docker Registry v2 notification event
+type RegistryEvent struct {
+	Action string              `json:"action"`
+	Target RegistryEventTarget `json:"target"`
+}
+
+//RegistryEventTarget - The manifest or blob of a Docker Registry v2 notification event
+type RegistryEventTarget struct {
+	MediaType  string `json:"mediaType"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+//HarborEventData - The artifacts of a Harbor webhook
+type HarborEventData struct {
+	Resources  []HarborResource `json:"resources"`
+	Repository HarborRepository `json:"repository"`
+}
+
+//HarborResource - HarborResource
+type HarborResource struct {
+	Digest      string `json:"digest"`
+	Tag         string `json:"tag"`
+	ResourceURL string `json:"resource_url"`
+}
+
+//HarborRepository - HarborRepository
+type HarborRepository struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	RepoFullName string `json:"repo_full_name"`
+}
+
+//PushedTag - An image tag pushed to a registry
+type PushedTag struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
@@ -7,9 +7,16 @@ type ValueRule struct {
 	gorm.Model
 	Type           string `json:"type"`
 	Value          string `json:"value"`
+	Severity       string `json:"severity"`
 	VariableRuleID uint
 }
 
+//Value rule severities, rules without severity are errors
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
 //ValueRuleReponse struct
 type ValueRuleReponse struct {
 	List []ValueRule `json:"list"`
@@ -2,11 +2,14 @@ package model
 
 import "github.com/jinzhu/gorm"
 
-//VariableRule Structure
+//VariableRule Structure - EnvironmentID, Group and Scope are optional selectors restricting where the rule applies
 type VariableRule struct {
 	gorm.Model
-	Name       string       `json:"name"`
-	ValueRules []*ValueRule `gorm:"foreignkey:VariableRuleID"`
+	Name          string       `json:"name"`
+	EnvironmentID uint         `json:"environmentId"`
+	Group         string       `json:"group"`
+	Scope         string       `json:"scope"`
+	ValueRules    []*ValueRule `gorm:"foreignkey:VariableRuleID"`
 }
 
 //VariableRuleReponse struct
@@ -2,6 +2,22 @@ package model
 
 import "github.com/jinzhu/gorm"
 
+//Types of webhook, each one posting its own payload. Hooks are subscribed to an environment,
+//except HOOK_NEW_RELEASE and HOOK_PRODUCT_VERSION_LOCKED which are not bound to one.
+const (
+	HookDeployProduct        = "HOOK_DEPLOY_PRODUCT"
+	HookNewRelease           = "HOOK_NEW_RELEASE"
+	HookDeployStarted        = "HOOK_DEPLOY_STARTED"
+	HookDeploySucceeded      = "HOOK_DEPLOY_SUCCEEDED"
+	HookDeployFailed         = "HOOK_DEPLOY_FAILED"
+	HookDeployFinished       = "HOOK_DEPLOY_FINISHED"
+	HookRollback             = "HOOK_ROLLBACK"
+	HookReleaseDeleted       = "HOOK_RELEASE_DELETED"
+	HookPromotionCompleted   = "HOOK_PROMOTION_COMPLETED"
+	HookProductVersionLocked = "HOOK_PRODUCT_VERSION_LOCKED"
+	HookVariableChanged      = "HOOK_VARIABLE_CHANGED"
+)
+
 // WebHook Structure
 type WebHook struct {
 	gorm.Model
@@ -10,6 +26,9 @@ type WebHook struct {
 	URL            string `json:"url"`
 	EnvironmentID  int    `json:"environmentId"`
 	AdditionalData string `json:"additionalData"`
+	Secret         string `json:"secret"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+	Channel        string `json:"channel"`
 }
 
 //WebHookReponse struct
@@ -17,14 +36,16 @@ type WebHookReponse struct {
 	List []WebHook `json:"list"`
 }
 
-//WebHookPostPayload struct
+//WebHookPostPayload - Payload of HOOK_DEPLOY_PRODUCT
+//	{"environment": string, "productName": string, "release": string}
 type WebHookPostPayload struct {
 	Environment string `json:"environment"`
 	ProductName string `json:"productName"`
 	Release     string `json:"release"`
 }
 
-//WebHookNewReleasePostPayload struct
+//WebHookNewReleasePostPayload - Payload of HOOK_NEW_RELEASE
+//	{"environment": "", "productName": string, "release": string, "additionalData": string, "services": [string]}
 type WebHookNewReleasePostPayload struct {
 	Environment    string   `json:"environment"`
 	ProductName    string   `json:"productName"`
@@ -32,3 +53,106 @@ type WebHookNewReleasePostPayload struct {
 	AdditionalData string   `json:"additionalData"`
 	Services       []string `json:"services"`
 }
+
+//WebHookDeploymentPayload - Payload of HOOK_DEPLOY_STARTED, HOOK_DEPLOY_SUCCEEDED and HOOK_DEPLOY_FAILED,
+//message holds the error of a failed deploy
+//	{"event": string, "environment": string, "environmentId": number, "chart": string,
+//	 "deploymentId": number, "requestDeploymentId": number, "message": string}
+type WebHookDeploymentPayload struct {
+	Event               string `json:"event"`
+	Environment         string `json:"environment"`
+	EnvironmentID       uint   `json:"environmentId"`
+	Chart               string `json:"chart"`
+	DeploymentID        uint   `json:"deploymentId"`
+	RequestDeploymentID uint   `json:"requestDeploymentId"`
+	Message             string `json:"message"`
+}
+
+//WebHookDeployFinishedPayload - Payload of HOOK_DEPLOY_FINISHED, sent when every chart of a deploy request was processed,
+//failures holds the charts that failed with their error
+//	{"event": string, "environment": string, "environmentId": number, "requestDeploymentId": number,
+//	 "success": boolean, "user": string, "charts": [string], "failures": [string]}
+type WebHookDeployFinishedPayload struct {
+	Event               string   `json:"event"`
+	Environment         string   `json:"environment"`
+	EnvironmentID       uint     `json:"environmentId"`
+	RequestDeploymentID uint     `json:"requestDeploymentId"`
+	Success             bool     `json:"success"`
+	User                string   `json:"user"`
+	Charts              []string `json:"charts"`
+	Failures            []string `json:"failures"`
+}
+
+//WebHookReleasePayload - Payload of HOOK_ROLLBACK and HOOK_RELEASE_DELETED, revision is set by rollbacks
+//and purge by deletes
+//	{"event": string, "environment": string, "environmentId": number, "release": string,
+//	 "revision": number, "purge": boolean, "user": string}
+type WebHookReleasePayload struct {
+	Event         string `json:"event"`
+	Environment   string `json:"environment"`
+	EnvironmentID uint   `json:"environmentId"`
+	Release       string `json:"release"`
+	Revision      int    `json:"revision,omitempty"`
+	Purge         bool   `json:"purge,omitempty"`
+	User          string `json:"user"`
+}
+
+//WebHookPromotionPayload - Payload of HOOK_PROMOTION_COMPLETED, sent to the hooks of the target environment
+//	{"event": string, "sourceEnvironment": string, "environment": string, "environmentId": number,
+//	 "mode": string, "user": string}
+type WebHookPromotionPayload struct {
+	Event             string `json:"event"`
+	SourceEnvironment string `json:"sourceEnvironment"`
+	Environment       string `json:"environment"`
+	EnvironmentID     uint   `json:"environmentId"`
+	Mode              string `json:"mode"`
+	User              string `json:"user"`
+}
+
+//WebHookProductVersionPayload - Payload of HOOK_PRODUCT_VERSION_LOCKED
+//	{"event": string, "productName": string, "release": string, "productVersionId": number, "user": string}
+type WebHookProductVersionPayload struct {
+	Event            string `json:"event"`
+	ProductName      string `json:"productName"`
+	Release          string `json:"release"`
+	ProductVersionID uint   `json:"productVersionId"`
+	User             string `json:"user"`
+}
+
+//WebHookVariablePayload - Payload of HOOK_VARIABLE_CHANGED, values are never sent as variables may be secret
+//	{"event": string, "environment": string, "environmentId": number, "scope": string, "name": string, "user": string}
+type WebHookVariablePayload struct {
+	Event         string `json:"event"`
+	Environment   string `json:"environment"`
+	EnvironmentID uint   `json:"environmentId"`
+	Scope         string `json:"scope"`
+	Name          string `json:"name"`
+	User          string `json:"user"`
+}
+
+//Status of a webhook delivery
+const (
+	WebHookDeliveryPending   = "pending"
+	WebHookDeliveryDelivered = "delivered"
+	WebHookDeliveryFailed    = "failed"
+)
+
+//WebHookDelivery - Record of the delivery of an event to a webhook, with the response of its last attempt
+type WebHookDelivery struct {
+	gorm.Model
+	WebHookID    uint   `json:"webHookId" gorm:"index"`
+	Event        string `json:"event"`
+	URL          string `json:"url"`
+	Payload      string `json:"payload" gorm:"type:text"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	StatusCode   int    `json:"statusCode"`
+	ResponseBody string `json:"responseBody" gorm:"type:text"`
+	Error        string `json:"error"`
+	RedeliveryOf uint   `json:"redeliveryOf"`
+}
+
+//WebHookDeliveryResponse struct
+type WebHookDeliveryResponse struct {
+	List []WebHookDelivery `json:"list"`
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//APITokenDAOInterface APITokenDAOInterface
+type APITokenDAOInterface interface {
+	CreateAPIToken(t model.APIToken) (int, error)
+	GetAPIToken(id int) (model.APIToken, error)
+	FindAPITokenByHash(hash string) (*model.APIToken, error)
+	ListAPITokens(userID int) ([]model.APIToken, error)
+	RevokeAPIToken(id int) error
+	RevokeUserAPITokens(userID int) error
+	TouchAPIToken(id int, usedAt time.Time) error
+}
+
+//APITokenDAOImpl APITokenDAOImpl
+type APITokenDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateAPIToken - Create a new API token
+func (dao APITokenDAOImpl) CreateAPIToken(t model.APIToken) (int, error) {
+	if err := dao.Db.Create(&t).Error; err != nil {
+		return -1, err
+	}
+	return int(t.ID), nil
+}
+
+//GetAPIToken - Get an API token
+func (dao APITokenDAOImpl) GetAPIToken(id int) (model.APIToken, error) {
+	var result model.APIToken
+	if err := dao.Db.First(&result, id).Error; err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+//FindAPITokenByHash - Find the API token with the given hash, nil when there is none
+func (dao APITokenDAOImpl) FindAPITokenByHash(hash string) (*model.APIToken, error) {
+	var result model.APIToken
+	if err := dao.Db.Where(&model.APIToken{TokenHash: hash}).First(&result).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+//ListAPITokens - List the API tokens of a user, the revoked ones included
+func (dao APITokenDAOImpl) ListAPITokens(userID int) ([]model.APIToken, error) {
+	list := make([]model.APIToken, 0)
+	if err := dao.Db.Where("user_id = ?", userID).Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//RevokeAPIToken - Revokes an API token, keeping it to be listed
+func (dao APITokenDAOImpl) RevokeAPIToken(id int) error {
+	return dao.Db.Model(&model.APIToken{}).Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+//RevokeUserAPITokens - Revokes every API token of a user
+func (dao APITokenDAOImpl) RevokeUserAPITokens(userID int) error {
+	return dao.Db.Model(&model.APIToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+//TouchAPIToken - Records the last use of an API token
+func (dao APITokenDAOImpl) TouchAPIToken(id int, usedAt time.Time) error {
+	return dao.Db.Model(&model.APIToken{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
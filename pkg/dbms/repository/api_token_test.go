@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func beforeAPITokenTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, APITokenDAOImpl) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := APITokenDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	expiresAt := time.Now().Add(time.Hour)
+	item := model.APIToken{
+		Name:         "my-token",
+		TokenHash:    "hash",
+		UserID:       5,
+		Environments: []int64{999},
+		Policies:     []string{"ACTION_DEPLOY"},
+		ExpiresAt:    &expiresAt,
+		CreatedBy:    "beta@alfa.com",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_tokens"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.TokenHash, item.UserID, 0, "{999}", "{\"ACTION_DEPLOY\"}",
+			AnyTime{}, nil, nil, item.CreatedBy).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	result, e := dao.CreateAPIToken(item)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetAPIToken(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "user_id"}).AddRow(1, "my-token", 5)
+	mock.ExpectQuery(`SELECT (.+) FROM "api_tokens" WHERE (.+)`).
+		WillReturnRows(rows)
+
+	result, err := dao.GetAPIToken(1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(5), result.UserID)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestFindAPITokenByHash(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "environments", "policies"}).
+		AddRow(1, "my-token", "{999}", "{ACTION_DEPLOY}")
+	mock.ExpectQuery(`SELECT (.+) FROM "api_tokens" WHERE (.+)`).
+		WithArgs("hash").
+		WillReturnRows(rows)
+
+	result, err := dao.FindAPITokenByHash("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{999}, []int64(result.Environments))
+	assert.Equal(t, []string{"ACTION_DEPLOY"}, []string(result.Policies))
+
+	mock.ExpectationsWereMet()
+}
+
+func TestFindAPITokenByHash_NotFound(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM "api_tokens" WHERE (.+)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := dao.FindAPITokenByHash("unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListAPITokens(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "other").AddRow(1, "my-token")
+	mock.ExpectQuery(`SELECT (.+) FROM "api_tokens" WHERE (.+) ORDER BY id desc`).
+		WithArgs(5).
+		WillReturnRows(rows)
+
+	result, err := dao.ListAPITokens(5)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_tokens" SET "revoked_at" = \$1, "updated_at" = \$2 WHERE (.+)`).
+		WithArgs(AnyTime{}, AnyTime{}, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.RevokeAPIToken(1)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestRevokeUserAPITokens(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_tokens" SET (.+) WHERE (.+)`).
+		WithArgs(AnyTime{}, AnyTime{}, 8).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	e := dao.RevokeUserAPITokens(8)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestTouchAPIToken(t *testing.T) {
+	gormDB, mock, dao := beforeAPITokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_tokens" SET "last_used_at" = \$1 WHERE (.+)`).
+		WithArgs(AnyTime{}, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.TouchAPIToken(1, time.Now())
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+const auditChainBatchSize = 500
+
+//auditChainLockKey is the key of the postgres advisory lock held while appending to the chain
+const auditChainLockKey = 7261746
+
+//auditChainMutex serializes the events appended to the chain on the databases without advisory locks
+var auditChainMutex sync.Mutex
+
+//AuditDAOInterface AuditDAOInterface
+type AuditDAOInterface interface {
+	CreateAuditEvent(event model.AuditEvent) (int, error)
+	ListAuditEvents(filter model.AuditEventFilter) ([]model.AuditEvent, int, error)
+	VerifyAuditChain(expectedHash string) (*model.AuditChainVerification, error)
+}
+
+//AuditDAOImpl AuditDAOImpl
+type AuditDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateAuditEvent - Appends an audit event to the hash chain. Reading the head of the chain and
+//appending the event must not interleave, so on postgres a transaction advisory lock serializes the
+//appends of every replica, leaving the table free for readers and the other writes. The appends still
+//run one at a time, which bounds the audit throughput to roughly one commit round trip per event.
+func (dao AuditDAOImpl) CreateAuditEvent(event model.AuditEvent) (int, error) {
+	postgres := dao.Db.Dialect().GetName() == "postgres"
+	if !postgres {
+		auditChainMutex.Lock()
+		defer auditChainMutex.Unlock()
+	}
+
+	tx := dao.Db.Begin()
+	if postgres {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+	}
+
+	var last model.AuditEvent
+	if err := tx.Order("id desc").First(&last).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	//The database keeps microseconds, the hash must be computed over the stored value
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Microsecond)
+	event.PreviousHash = last.Hash
+	event.Hash = hashAuditEvent(event)
+
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return -1, err
+	}
+	return int(event.ID), nil
+}
+
+//ListAuditEvents - List a page of audit events, newest first, with the total of events matching the filter
+func (dao AuditDAOImpl) ListAuditEvents(filter model.AuditEventFilter) ([]model.AuditEvent, int, error) {
+	query := dao.Db.Model(&model.AuditEvent{})
+	if len(filter.Actor) > 0 {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if len(filter.Action) > 0 {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EnvironmentID > 0 {
+		query = query.Where("environment_id = ?", filter.EnvironmentID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+
+	total := 0
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]model.AuditEvent, 0)
+	if err := query.Order("id desc").Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+//VerifyAuditChain - Walks the audit events checking the hash of each event and its link to the previous one.
+//Removing the latest events leaves a valid chain, so the hash of a head recorded earlier may be given
+//as expectedHash, the chain is invalid when that event is missing.
+func (dao AuditDAOImpl) VerifyAuditChain(expectedHash string) (*model.AuditChainVerification, error) {
+	result := &model.AuditChainVerification{Valid: true}
+	found := len(expectedHash) == 0
+
+	var lastID uint
+	for {
+		batch := make([]model.AuditEvent, 0, auditChainBatchSize)
+		if err := dao.Db.Where("id > ?", lastID).Order("id").Limit(auditChainBatchSize).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+
+		for _, e := range batch {
+			if e.PreviousHash != result.LastHash {
+				result.Valid = false
+				result.InvalidEventID = e.ID
+				result.Reason = "previous hash does not match, an earlier event was removed or changed"
+				return result, nil
+			}
+			if hashAuditEvent(e) != e.Hash {
+				result.Valid = false
+				result.InvalidEventID = e.ID
+				result.Reason = "hash does not match the event content"
+				return result, nil
+			}
+			result.LastHash = e.Hash
+			result.Checked++
+			lastID = e.ID
+			found = found || e.Hash == expectedHash
+		}
+
+		if len(batch) < auditChainBatchSize {
+			if !found {
+				result.Valid = false
+				result.Reason = "expected hash not found, the latest events were removed"
+			}
+			return result, nil
+		}
+	}
+}
+
+//hashAuditEvent computes the hash of an audit event over its content and the hash of the previous event
+func hashAuditEvent(event model.AuditEvent) string {
+	fields := []string{
+		event.PreviousHash,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		event.Actor,
+		event.Action,
+		strconv.FormatUint(uint64(event.EnvironmentID), 10),
+		event.ResourceType,
+		event.ResourceID,
+		event.Before,
+		event.After,
+		event.RequestID,
+		event.ClientIP,
+		event.Outcome,
+	}
+
+	hash := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(hash, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
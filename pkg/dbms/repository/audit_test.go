@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditEventColumns = []string{"id", "created_at", "actor", "action", "environment_id", "resource_type",
+	"resource_id", "before", "after", "request_id", "client_ip", "outcome", "previous_hash", "hash"}
+
+func addAuditEventRow(rows *sqlmock.Rows, e model.AuditEvent) *sqlmock.Rows {
+	return rows.AddRow(e.ID, e.CreatedAt, e.Actor, e.Action, e.EnvironmentID, e.ResourceType,
+		e.ResourceID, e.Before, e.After, e.RequestID, e.ClientIP, e.Outcome, e.PreviousHash, e.Hash)
+}
+
+func mockAuditChain() []model.AuditEvent {
+	createdAt := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := model.AuditEvent{ID: 1, CreatedAt: createdAt, Actor: "alfa", Action: "deploy", EnvironmentID: 999, Outcome: model.AuditOutcomeSuccess}
+	first.Hash = hashAuditEvent(first)
+	second := model.AuditEvent{ID: 2, CreatedAt: createdAt, Actor: "beta", Action: "deleteHelmRelease", EnvironmentID: 999,
+		Outcome: model.AuditOutcomeSuccess, PreviousHash: first.Hash}
+	second.Hash = hashAuditEvent(second)
+	return []model.AuditEvent{first, second}
+}
+
+func TestCreateAuditEvent(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := AuditDAOImpl{}
+	dao.Db = gormDB
+
+	chain := mockAuditChain()
+	event := model.AuditEvent{CreatedAt: time.Now(), Actor: "alfa", Action: "deploy", EnvironmentID: 999, Outcome: model.AuditOutcomeSuccess}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(auditChainLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT (.+) FROM "audit_events" ORDER BY id desc`).
+		WillReturnRows(addAuditEventRow(sqlmock.NewRows(auditEventColumns), chain[1]))
+	mock.ExpectQuery(`INSERT INTO "audit_events"`).
+		WithArgs(AnyTime{}, "alfa", "deploy", 999, "", "", "", "", "", "", model.AuditOutcomeSuccess, chain[1].Hash, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	result, err := dao.CreateAuditEvent(event)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListAuditEvents(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := AuditDAOImpl{}
+	dao.Db = gormDB
+
+	from := time.Now().Add(-time.Hour)
+	filter := model.AuditEventFilter{Actor: "alfa", EnvironmentID: 999, From: from, Page: 2, PageSize: 10}
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "audit_events" WHERE (.+)`).
+		WithArgs("alfa", 999, from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+	mock.ExpectQuery(`SELECT (.+) FROM "audit_events" WHERE (.+) ORDER BY id desc LIMIT 10 OFFSET 10`).
+		WithArgs("alfa", 999, from).
+		WillReturnRows(addAuditEventRow(sqlmock.NewRows(auditEventColumns), mockAuditChain()[0]))
+
+	result, total, err := dao.ListAuditEvents(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 11, total)
+	assert.Len(t, result, 1)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := AuditDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows(auditEventColumns)
+	for _, e := range mockAuditChain() {
+		addAuditEventRow(rows, e)
+	}
+	mock.ExpectQuery(`SELECT (.+) FROM "audit_events" WHERE \(id > \$1\) ORDER BY "id" LIMIT 500`).
+		WithArgs(0).
+		WillReturnRows(rows)
+
+	result, err := dao.VerifyAuditChain(mockAuditChain()[0].Hash)
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, mockAuditChain()[1].Hash, result.LastHash)
+}
+
+func TestVerifyAuditChain_Truncated(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := AuditDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows(auditEventColumns)
+	addAuditEventRow(rows, mockAuditChain()[0])
+	mock.ExpectQuery(`SELECT (.+) FROM "audit_events"`).WillReturnRows(rows)
+
+	result, err := dao.VerifyAuditChain(mockAuditChain()[1].Hash)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, 1, result.Checked)
+}
+
+func TestVerifyAuditChain_Tampered(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := AuditDAOImpl{}
+	dao.Db = gormDB
+
+	chain := mockAuditChain()
+	chain[1].Actor = "gama"
+	rows := sqlmock.NewRows(auditEventColumns)
+	for _, e := range chain {
+		addAuditEventRow(rows, e)
+	}
+	mock.ExpectQuery(`SELECT (.+) FROM "audit_events"`).WillReturnRows(rows)
+
+	result, err := dao.VerifyAuditChain("")
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, 1, result.Checked)
+	assert.Equal(t, uint(2), result.InvalidEventID)
+}
+
+func TestHashAuditEvent(t *testing.T) {
+	event := mockAuditChain()[0]
+	assert.Equal(t, event.Hash, hashAuditEvent(event))
+
+	//Moving a character between fields must change the hash
+	moved := event
+	moved.Actor = "alf"
+	moved.Action = "adeploy"
+	assert.NotEqual(t, event.Hash, hashAuditEvent(moved))
+}
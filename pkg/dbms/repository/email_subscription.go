@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//EmailSubscriptionDAOInterface EmailSubscriptionDAOInterface
+type EmailSubscriptionDAOInterface interface {
+	CreateEmailSubscription(s model.EmailSubscription) error
+	DeleteEmailSubscription(userID int, environmentID int) error
+	IsSubscribed(userID int, environmentID int) (bool, error)
+	ListEmailSubscriptions(userID int) ([]model.EmailSubscription, error)
+}
+
+//EmailSubscriptionDAOImpl EmailSubscriptionDAOImpl
+type EmailSubscriptionDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateEmailSubscription - Subscribes a user to the emails of an environment, keeping an existing subscription
+func (dao EmailSubscriptionDAOImpl) CreateEmailSubscription(s model.EmailSubscription) error {
+	return dao.Db.Where(&model.EmailSubscription{UserID: s.UserID, EnvironmentID: s.EnvironmentID}).FirstOrCreate(&s).Error
+}
+
+//DeleteEmailSubscription - Unsubscribes a user from the emails of an environment
+func (dao EmailSubscriptionDAOImpl) DeleteEmailSubscription(userID int, environmentID int) error {
+	return dao.Db.Unscoped().Where("user_id = ? AND environment_id = ?", userID, environmentID).
+		Delete(model.EmailSubscription{}).Error
+}
+
+//IsSubscribed - Tells whether a user receives the emails of an environment
+func (dao EmailSubscriptionDAOImpl) IsSubscribed(userID int, environmentID int) (bool, error) {
+	var count int
+	if err := dao.Db.Model(&model.EmailSubscription{}).
+		Where("user_id = ? AND environment_id = ?", userID, environmentID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+//ListEmailSubscriptions - List the subscriptions of a user
+func (dao EmailSubscriptionDAOImpl) ListEmailSubscriptions(userID int) ([]model.EmailSubscription, error) {
+	list := make([]model.EmailSubscription, 0)
+	if err := dao.Db.Where("user_id = ?", userID).Order("environment_id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
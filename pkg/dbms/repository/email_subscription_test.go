@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func beforeEmailSubscriptionTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, EmailSubscriptionDAOImpl) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := EmailSubscriptionDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao
+}
+
+func TestCreateEmailSubscription(t *testing.T) {
+	gormDB, mock, dao := beforeEmailSubscriptionTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM "email_subscriptions" WHERE (.+)`).
+		WithArgs(5, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "email_subscriptions"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, 5, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	e := dao.CreateEmailSubscription(model.EmailSubscription{UserID: 5, EnvironmentID: 999})
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeleteEmailSubscription(t *testing.T) {
+	gormDB, mock, dao := beforeEmailSubscriptionTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "email_subscriptions" WHERE (.*)`).
+		WithArgs(5, 999).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.DeleteEmailSubscription(5, 999)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestIsSubscribed(t *testing.T) {
+	gormDB, mock, dao := beforeEmailSubscriptionTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "email_subscriptions" WHERE (.+)`).
+		WithArgs(5, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := dao.IsSubscribed(5, 999)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListEmailSubscriptions(t *testing.T) {
+	gormDB, mock, dao := beforeEmailSubscriptionTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "environment_id"}).AddRow(1, 5, 999)
+	mock.ExpectQuery(`SELECT (.+) FROM "email_subscriptions" WHERE (.+) ORDER BY environment_id`).
+		WithArgs(5).
+		WillReturnRows(rows)
+
+	result, err := dao.ListEmailSubscriptions(5)
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, uint(999), result[0].EnvironmentID)
+
+	mock.ExpectationsWereMet()
+}
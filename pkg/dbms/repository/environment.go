@@ -8,6 +8,7 @@ import (
 //EnvironmentDAOInterface EnvironmentDAOInterface
 type EnvironmentDAOInterface interface {
 	CreateEnvironment(env model2.Environment) (int, error)
+	CreateEnvironmentWithVariables(env model2.Environment, variables []model2.Variable) (int, error)
 	EditEnvironment(env model2.Environment) error
 	DeleteEnvironment(env model2.Environment) error
 	GetAllEnvironments(principal string) ([]model2.Environment, error)
@@ -27,6 +28,26 @@ func (dao EnvironmentDAOImpl) CreateEnvironment(env model2.Environment) (int, er
 	return int(env.ID), nil
 }
 
+//CreateEnvironmentWithVariables - Creates an environment and its variables in a single transaction
+func (dao EnvironmentDAOImpl) CreateEnvironmentWithVariables(env model2.Environment, variables []model2.Variable) (int, error) {
+	tx := dao.Db.Begin()
+	if err := tx.Create(&env).Error; err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+	for _, variable := range variables {
+		variable.EnvironmentID = int(env.ID)
+		if err := tx.Create(&variable).Error; err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return -1, err
+	}
+	return int(env.ID), nil
+}
+
 // EditEnvironment - Updates an existing environment
 func (dao EnvironmentDAOImpl) EditEnvironment(env model2.Environment) error {
 	return dao.Db.Save(&env).Error
@@ -49,6 +70,15 @@ func (dao EnvironmentDAOImpl) GetAllEnvironments(principal string) ([]model2.Env
 		if err := dao.Db.Model(&user).Related(&envs, "Environments").Error; err != nil {
 			return checkNotFound(err)
 		}
+
+		//Environments of the groups where the user holds a role are granted too
+		groupEnvs := make([]model2.Environment, 0)
+		if err := dao.Db.Where(`"group" IN (SELECT groups.name FROM groups
+			INNER JOIN group_roles ON group_roles.group_id = groups.id AND group_roles.deleted_at IS NULL
+			WHERE groups.deleted_at IS NULL AND group_roles.user_id = ?)`, user.ID).Find(&groupEnvs).Error; err != nil {
+			return checkNotFound(err)
+		}
+		envs = appendMissingEnvironments(envs, groupEnvs)
 	} else {
 		if err := dao.Db.Find(&envs).Error; err != nil {
 			return checkNotFound(err)
@@ -66,6 +96,20 @@ func (dao EnvironmentDAOImpl) GetByID(envID int) (*model2.Environment, error) {
 	return &result, nil
 }
 
+func appendMissingEnvironments(envs []model2.Environment, others []model2.Environment) []model2.Environment {
+	ids := make(map[uint]bool)
+	for _, e := range envs {
+		ids[e.ID] = true
+	}
+	for _, e := range others {
+		if !ids[e.ID] {
+			envs = append(envs, e)
+			ids[e.ID] = true
+		}
+	}
+	return envs
+}
+
 func checkNotFound(err error) ([]model2.Environment, error) {
 	if err == gorm.ErrRecordNotFound {
 		return make([]model2.Environment, 0), nil
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//EnvironmentStatusDAOInterface EnvironmentStatusDAOInterface
+type EnvironmentStatusDAOInterface interface {
+	SaveEnvironmentStatus(status model.EnvironmentStatus) error
+	ListEnvironmentStatuses() ([]model.EnvironmentStatus, error)
+}
+
+//EnvironmentStatusDAOImpl EnvironmentStatusDAOImpl
+type EnvironmentStatusDAOImpl struct {
+	Db *gorm.DB
+}
+
+//SaveEnvironmentStatus - Create or replace the status of an environment
+func (dao EnvironmentStatusDAOImpl) SaveEnvironmentStatus(status model.EnvironmentStatus) error {
+	var result model.EnvironmentStatus
+	if err := dao.Db.Where(&model.EnvironmentStatus{EnvironmentID: status.EnvironmentID}).Find(&result).Error; err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+		return dao.Db.Create(&status).Error
+	}
+
+	status.ID = result.ID
+	status.CreatedAt = result.CreatedAt
+	return dao.Db.Save(&status).Error
+}
+
+//ListEnvironmentStatuses - List the last status recorded for each environment
+func (dao EnvironmentStatusDAOImpl) ListEnvironmentStatuses() ([]model.EnvironmentStatus, error) {
+	statuses := make([]model.EnvironmentStatus, 0)
+	if err := dao.Db.Find(&statuses).Error; err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveEnvironmentStatus_Create(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentStatusDAOImpl{}
+	dao.Db = gormDB
+
+	item := model.EnvironmentStatus{EnvironmentID: 999, Healthy: true}
+
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_statuses"`).
+		WithArgs(item.EnvironmentID).WillReturnError(gorm.ErrRecordNotFound)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.ExpectQuery(`INSERT INTO "environment_statuses"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.EnvironmentID, item.Healthy, item.Message, nil, nil, AnyTime{}).
+		WillReturnRows(rows)
+
+	err = dao.SaveEnvironmentStatus(item)
+	assert.Nil(t, err)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestSaveEnvironmentStatus_Update(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentStatusDAOImpl{}
+	dao.Db = gormDB
+
+	item := model.EnvironmentStatus{EnvironmentID: 999, Healthy: false, Message: "tiller: connection refused"}
+
+	rows := sqlmock.NewRows([]string{"id", "environment_id", "healthy"}).AddRow(1, 999, true)
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_statuses"`).
+		WithArgs(item.EnvironmentID).WillReturnRows(rows)
+
+	mock.ExpectExec(`UPDATE "environment_statuses"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = dao.SaveEnvironmentStatus(item)
+	assert.Nil(t, err)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListEnvironmentStatuses(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentStatusDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows([]string{"id", "environment_id", "healthy", "message"}).
+		AddRow(1, 999, false, "tiller: connection refused")
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_statuses"`).WillReturnRows(rows)
+
+	result, err := dao.ListEnvironmentStatuses()
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, uint(999), result[0].EnvironmentID)
+
+	mock.ExpectationsWereMet()
+}
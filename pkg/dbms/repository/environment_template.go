@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//EnvironmentTemplateDAOInterface EnvironmentTemplateDAOInterface
+type EnvironmentTemplateDAOInterface interface {
+	CreateEnvironmentTemplate(template model.EnvironmentTemplate) (int, error)
+	EditEnvironmentTemplate(template model.EnvironmentTemplate) error
+	DeleteEnvironmentTemplate(id int) error
+	GetEnvironmentTemplate(id int) (*model.EnvironmentTemplate, error)
+	ListEnvironmentTemplates() ([]model.EnvironmentTemplate, error)
+}
+
+//EnvironmentTemplateDAOImpl EnvironmentTemplateDAOImpl
+type EnvironmentTemplateDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateEnvironmentTemplate - Create a new environment template with its variables and charts
+func (dao EnvironmentTemplateDAOImpl) CreateEnvironmentTemplate(template model.EnvironmentTemplate) (int, error) {
+	if err := dao.Db.Create(&template).Error; err != nil {
+		return -1, err
+	}
+	return int(template.ID), nil
+}
+
+//EditEnvironmentTemplate - Updates an environment template, replacing its variables and charts
+func (dao EnvironmentTemplateDAOImpl) EditEnvironmentTemplate(template model.EnvironmentTemplate) error {
+	tx := dao.Db.Begin()
+	if err := tx.Unscoped().Where("environment_template_id = ?", template.ID).
+		Delete(model.EnvironmentTemplateVariable{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Unscoped().Where("environment_template_id = ?", template.ID).
+		Delete(model.EnvironmentTemplateChart{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Save(&template).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+//DeleteEnvironmentTemplate - Deletes an environment template
+func (dao EnvironmentTemplateDAOImpl) DeleteEnvironmentTemplate(id int) error {
+	return dao.Db.Unscoped().Delete(model.EnvironmentTemplate{}, id).Error
+}
+
+//GetEnvironmentTemplate - Get an environment template with its variables and charts
+func (dao EnvironmentTemplateDAOImpl) GetEnvironmentTemplate(id int) (*model.EnvironmentTemplate, error) {
+	var template model.EnvironmentTemplate
+	if err := dao.Db.Preload("Variables").Preload("Charts").First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+//ListEnvironmentTemplates - List environment templates
+func (dao EnvironmentTemplateDAOImpl) ListEnvironmentTemplates() ([]model.EnvironmentTemplate, error) {
+	list := make([]model.EnvironmentTemplate, 0)
+	if err := dao.Db.Preload("Variables").Preload("Charts").Find(&list).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return make([]model.EnvironmentTemplate, 0), nil
+		}
+		return nil, err
+	}
+	return list, nil
+}
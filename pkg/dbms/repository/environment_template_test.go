@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateEnvironmentTemplate(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentTemplateDAOImpl{}
+	dao.Db = gormDB
+
+	item := model.EnvironmentTemplate{Name: "default", Description: "Default template", ProductVersionID: 777}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "environment_templates"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.Description, item.ProductVersionID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	result, err := dao.CreateEnvironmentTemplate(item)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeleteEnvironmentTemplate(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentTemplateDAOImpl{}
+	dao.Db = gormDB
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "environment_templates"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = dao.DeleteEnvironmentTemplate(1)
+	assert.Nil(t, err)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListEnvironmentTemplates(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EnvironmentTemplateDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows([]string{"id", "name", "product_version_id"}).AddRow(1, "default", 777)
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_templates"`).WillReturnRows(rows)
+
+	variables := sqlmock.NewRows([]string{"id", "environment_template_id", "scope", "name", "value"}).
+		AddRow(1, 1, "global", "domain", "${NAMESPACE}.my-domain.com")
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_template_variables"`).WillReturnRows(variables)
+
+	charts := sqlmock.NewRows([]string{"id", "environment_template_id", "chart", "name"}).
+		AddRow(1, 1, "repo/my-chart", "my-chart")
+	mock.ExpectQuery(`SELECT (.+) FROM "environment_template_charts"`).WillReturnRows(charts)
+
+	result, err := dao.ListEnvironmentTemplates()
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Len(t, result[0].Variables, 1)
+	assert.Len(t, result[0].Charts, 1)
+
+	mock.ExpectationsWereMet()
+}
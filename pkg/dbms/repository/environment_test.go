@@ -64,7 +64,8 @@ func TestCreateEnvironment(t *testing.T) {
 	mock.ExpectQuery(`INSERT INTO "environments"`).
 		WithArgs(item.CreatedAt, item.UpdatedAt, item.DeletedAt, item.Group,
 			item.Name, item.ClusterURI, item.CACertificate, item.Token,
-			item.Namespace, item.Gateway, item.ProductVersion, item.CurrentRelease).
+			item.Namespace, item.Gateway, item.ProductVersion, item.CurrentRelease,
+			item.BlockOnSchemaErrors, item.BlockOnInvalidVariables).
 		WillReturnRows(rows)
 
 	result, e := envDAO.CreateEnvironment(item)
@@ -123,7 +124,8 @@ func TestEditEnvironment(t *testing.T) {
 	mock.ExpectExec(`UPDATE "environments" SET (.*) WHERE (.*)`).
 		WithArgs(item.CreatedAt, sqlmock.AnyArg(), item.DeletedAt, item.Group,
 			item.Name, item.ClusterURI, item.CACertificate, item.Token,
-			item.Namespace, item.Gateway, item.ProductVersion, item.CurrentRelease, item.ID).
+			item.Namespace, item.Gateway, item.ProductVersion, item.CurrentRelease,
+			item.BlockOnSchemaErrors, item.BlockOnInvalidVariables, item.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	result := envDAO.EditEnvironment(item)
@@ -191,10 +193,20 @@ func TestGetAllEnvironments(t *testing.T) {
 		WHERE "environments"."deleted_at" IS NULL AND \(\("user_environment"."user_id" IN (.*)\)\)`).
 		WillReturnRows(row2)
 
+	row3 := sqlmock.NewRows([]string{"id", "group", "name"}).
+		AddRow(e.ID, e.Group, e.Name).
+		AddRow(1000, e.Group, "other")
+
+	mock.ExpectQuery(`SELECT (.*) FROM "environments" WHERE (.*)"group" IN \(SELECT groups.name FROM groups`).
+		WithArgs(user.ID).
+		WillReturnRows(row3)
+
 	result, err := envDAO.GetAllEnvironments(user.Email)
 	assert.Nil(t, err)
 	assert.NotNil(t, result)
+	assert.Len(t, result, 2)
 	assert.Equal(t, e.ID, result[0].ID)
+	assert.Equal(t, uint(1000), result[1].ID)
 
 	mock.ExpectationsWereMet()
 }
@@ -366,3 +378,49 @@ func TestGetByID_Error(t *testing.T) {
 
 	mock.ExpectationsWereMet()
 }
+
+func TestCreateEnvironmentWithVariables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	envDAO := EnvironmentDAOImpl{}
+	envDAO.Db = gormDB
+
+	item := getEnvironmentTestData()
+	variables := []model.Variable{{Scope: "global", Name: "username", Value: "user"}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "environments"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "variables"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, "global", "username", "user", "", false, "", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectCommit()
+
+	result, e := envDAO.CreateEnvironmentWithVariables(item, variables)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, result)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateEnvironmentWithVariables_Rollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	envDAO := EnvironmentDAOImpl{}
+	envDAO.Db = gormDB
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "environments"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "variables"`).WillReturnError(errors.New("duplicate key"))
+	mock.ExpectRollback()
+
+	_, e := envDAO.CreateEnvironmentWithVariables(getEnvironmentTestData(), []model.Variable{{Name: "username"}})
+	assert.Error(t, e)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
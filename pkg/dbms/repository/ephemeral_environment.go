@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//EphemeralEnvironmentDAOInterface EphemeralEnvironmentDAOInterface
+type EphemeralEnvironmentDAOInterface interface {
+	CreateEphemeralEnvironment(item model.EphemeralEnvironment) (int, error)
+	EditEphemeralEnvironment(item model.EphemeralEnvironment) error
+	DeleteEphemeralEnvironment(id int) error
+	GetByEnvironmentID(envID int) (*model.EphemeralEnvironment, error)
+	ListEphemeralEnvironments() ([]model.EphemeralEnvironment, error)
+	ListExpiredEphemeralEnvironments(now time.Time) ([]model.EphemeralEnvironment, error)
+}
+
+//EphemeralEnvironmentDAOImpl EphemeralEnvironmentDAOImpl
+type EphemeralEnvironmentDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateEphemeralEnvironment - Register the expiry of an ephemeral environment
+func (dao EphemeralEnvironmentDAOImpl) CreateEphemeralEnvironment(item model.EphemeralEnvironment) (int, error) {
+	if err := dao.Db.Create(&item).Error; err != nil {
+		return -1, err
+	}
+	return int(item.ID), nil
+}
+
+//EditEphemeralEnvironment - Updates an ephemeral environment
+func (dao EphemeralEnvironmentDAOImpl) EditEphemeralEnvironment(item model.EphemeralEnvironment) error {
+	return dao.Db.Save(&item).Error
+}
+
+//DeleteEphemeralEnvironment - Deletes an ephemeral environment registry
+func (dao EphemeralEnvironmentDAOImpl) DeleteEphemeralEnvironment(id int) error {
+	return dao.Db.Unscoped().Delete(model.EphemeralEnvironment{}, id).Error
+}
+
+//GetByEnvironmentID - Get the ephemeral registry of an environment
+func (dao EphemeralEnvironmentDAOImpl) GetByEnvironmentID(envID int) (*model.EphemeralEnvironment, error) {
+	var result model.EphemeralEnvironment
+	if err := dao.Db.Where(&model.EphemeralEnvironment{EnvironmentID: uint(envID)}).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+//ListEphemeralEnvironments - List ephemeral environments
+func (dao EphemeralEnvironmentDAOImpl) ListEphemeralEnvironments() ([]model.EphemeralEnvironment, error) {
+	list := make([]model.EphemeralEnvironment, 0)
+	if err := dao.Db.Order("expires_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//ListExpiredEphemeralEnvironments - List ephemeral environments whose TTL expired before now
+func (dao EphemeralEnvironmentDAOImpl) ListExpiredEphemeralEnvironments(now time.Time) ([]model.EphemeralEnvironment, error) {
+	list := make([]model.EphemeralEnvironment, 0)
+	if err := dao.Db.Where("expires_at <= ?", now).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateEphemeralEnvironment(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EphemeralEnvironmentDAOImpl{}
+	dao.Db = gormDB
+
+	item := model.EphemeralEnvironment{EnvironmentID: 1000, BaseEnvironmentID: 999,
+		ExpiresAt: time.Now().Add(time.Hour), CreatedBy: "beta@alfa.com"}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "ephemeral_environments"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.EnvironmentID, item.BaseEnvironmentID, AnyTime{}, item.CreatedBy).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	result, err := dao.CreateEphemeralEnvironment(item)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetByEnvironmentID(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EphemeralEnvironmentDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows([]string{"id", "environment_id", "base_environment_id"}).AddRow(1, 1000, 999)
+	mock.ExpectQuery(`SELECT (.+) FROM "ephemeral_environments"`).
+		WithArgs(1000).WillReturnRows(rows)
+
+	result, err := dao.GetByEnvironmentID(1000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(999), result.BaseEnvironmentID)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListExpiredEphemeralEnvironments(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := EphemeralEnvironmentDAOImpl{}
+	dao.Db = gormDB
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "environment_id", "expires_at"}).AddRow(1, 1000, now.Add(-time.Minute))
+	mock.ExpectQuery(`SELECT (.+) FROM "ephemeral_environments" WHERE (.+)expires_at <= (.+)`).
+		WithArgs(now).WillReturnRows(rows)
+
+	result, err := dao.ListExpiredEphemeralEnvironments(now)
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	mock.ExpectationsWereMet()
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//GroupDAOInterface GroupDAOInterface
+type GroupDAOInterface interface {
+	CreateGroup(group model.Group) (int, error)
+	EditGroup(group model.Group) error
+	DeleteGroup(id int) error
+	GetGroupByID(id int) (*model.Group, error)
+	ListGroups() ([]model.Group, error)
+	ListGroupVariables(groupID int) ([]model.GroupVariable, error)
+	ListGroupVariablesByName(group string) ([]model.GroupVariable, error)
+	SaveGroupVariable(variable model.GroupVariable) (int, error)
+	DeleteGroupVariable(id int) error
+	ListGroupRoles(groupID int) ([]model.GroupRole, error)
+	CreateOrUpdateGroupRole(role model.GroupRole) error
+	DeleteGroupRole(id int) error
+}
+
+//GroupDAOImpl GroupDAOImpl
+type GroupDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateGroup - Create a new group
+func (dao GroupDAOImpl) CreateGroup(group model.Group) (int, error) {
+	if err := dao.Db.Create(&group).Error; err != nil {
+		return -1, err
+	}
+	return int(group.ID), nil
+}
+
+//EditGroup - Updates a group, moving its environments when it is renamed
+func (dao GroupDAOImpl) EditGroup(group model.Group) error {
+	var stored model.Group
+	if err := dao.Db.First(&stored, group.ID).Error; err != nil {
+		return err
+	}
+
+	tx := dao.Db.Begin()
+	if stored.Name != group.Name {
+		if err := tx.Model(&model.Environment{}).Where(`"group" = ?`, stored.Name).
+			Update("group", group.Name).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Save(&group).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+//DeleteGroup - Deletes a group with its variables and roles, its environments are kept
+func (dao GroupDAOImpl) DeleteGroup(id int) error {
+	return dao.Db.Unscoped().Delete(model.Group{}, id).Error
+}
+
+//GetGroupByID - Get a group
+func (dao GroupDAOImpl) GetGroupByID(id int) (*model.Group, error) {
+	var group model.Group
+	if err := dao.Db.First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+//ListGroups - List groups
+func (dao GroupDAOImpl) ListGroups() ([]model.Group, error) {
+	list := make([]model.Group, 0)
+	if err := dao.Db.Order("name").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//ListGroupVariables - List the variables of a group
+func (dao GroupDAOImpl) ListGroupVariables(groupID int) ([]model.GroupVariable, error) {
+	list := make([]model.GroupVariable, 0)
+	if err := dao.Db.Where(&model.GroupVariable{GroupID: uint(groupID)}).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//ListGroupVariablesByName - List the variables of a group by the group name
+func (dao GroupDAOImpl) ListGroupVariablesByName(group string) ([]model.GroupVariable, error) {
+	list := make([]model.GroupVariable, 0)
+	if err := dao.Db.Joins(`INNER JOIN groups ON groups.id = group_variables.group_id AND groups.deleted_at IS NULL`).
+		Where("groups.name = ?", group).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//SaveGroupVariable - Create or update a group variable
+func (dao GroupDAOImpl) SaveGroupVariable(variable model.GroupVariable) (int, error) {
+	if err := dao.Db.Save(&variable).Error; err != nil {
+		return -1, err
+	}
+	return int(variable.ID), nil
+}
+
+//DeleteGroupVariable - Deletes a group variable
+func (dao GroupDAOImpl) DeleteGroupVariable(id int) error {
+	return dao.Db.Unscoped().Delete(model.GroupVariable{}, id).Error
+}
+
+//ListGroupRoles - List the roles granted on a group
+func (dao GroupDAOImpl) ListGroupRoles(groupID int) ([]model.GroupRole, error) {
+	list := make([]model.GroupRole, 0)
+	if err := dao.Db.Where(&model.GroupRole{GroupID: uint(groupID)}).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+//CreateOrUpdateGroupRole - Grants a security operation to a user on a group, replacing the previous one
+func (dao GroupDAOImpl) CreateOrUpdateGroupRole(role model.GroupRole) error {
+	var stored model.GroupRole
+	if err := dao.Db.Where(&model.GroupRole{GroupID: role.GroupID, UserID: role.UserID}).First(&stored).Error; err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+		return dao.Db.Create(&role).Error
+	}
+	stored.SecurityOperationID = role.SecurityOperationID
+	return dao.Db.Save(&stored).Error
+}
+
+//DeleteGroupRole - Revokes a group role
+func (dao GroupDAOImpl) DeleteGroupRole(id int) error {
+	return dao.Db.Unscoped().Delete(model.GroupRole{}, id).Error
+}
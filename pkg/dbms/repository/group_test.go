@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateGroup(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := GroupDAOImpl{}
+	dao.Db = gormDB
+
+	group := model.Group{Name: "foo", Description: "Foo environments"}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "groups"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, group.Name, group.Description).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	result, err := dao.CreateGroup(group)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListGroupVariablesByName(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := GroupDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "name", "value"}).AddRow(1, 1, "domain", "foo.com")
+	mock.ExpectQuery(`SELECT (.+) FROM "group_variables" INNER JOIN groups ON (.+) WHERE (.+)groups.name = (.+)`).
+		WithArgs("foo").WillReturnRows(rows)
+
+	result, err := dao.ListGroupVariablesByName("foo")
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "domain", result[0].Name)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestCreateOrUpdateGroupRole_Update(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := GroupDAOImpl{}
+	dao.Db = gormDB
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "user_id", "security_operation_id"}).AddRow(1, 1, 2, 3)
+	mock.ExpectQuery(`SELECT (.+) FROM "group_roles"`).
+		WithArgs(1, 2).WillReturnRows(rows)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "group_roles"`).
+		WithArgs(AnyTime{}, nil, 1, 2, 4, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = dao.CreateOrUpdateGroupRole(model.GroupRole{GroupID: 1, UserID: 2, SecurityOperationID: 4})
+	assert.Nil(t, err)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeleteGroup(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.MatchExpectationsInOrder(false)
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	dao := GroupDAOImpl{}
+	dao.Db = gormDB
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "groups"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = dao.DeleteGroup(1)
+	assert.Nil(t, err)
+
+	mock.ExpectationsWereMet()
+}
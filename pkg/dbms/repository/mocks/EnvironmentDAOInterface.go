@@ -33,6 +33,27 @@ func (_m *EnvironmentDAOInterface) CreateEnvironment(env model2.Environment) (in
 	return r0, r1
 }
 
+// CreateEnvironmentWithVariables provides a mock function with given fields: env, variables
+func (_m *EnvironmentDAOInterface) CreateEnvironmentWithVariables(env model2.Environment, variables []model2.Variable) (int, error) {
+	ret := _m.Called(env, variables)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(model2.Environment, []model2.Variable) int); ok {
+		r0 = rf(env, variables)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(model2.Environment, []model2.Variable) error); ok {
+		r1 = rf(env, variables)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteEnvironment provides a mock function with given fields: env
 func (_m *EnvironmentDAOInterface) DeleteEnvironment(env model2.Environment) error {
 	ret := _m.Called(env)
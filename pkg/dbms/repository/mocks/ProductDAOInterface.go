@@ -315,3 +315,17 @@ func (_m *ProductDAOInterface) ListProductsVersions(id int) ([]model.ProductVers
 
 	return r0, r1
 }
+
+// UpdateProductVersionServiceTags provides a mock function with given fields: id, availableTag, dockerImageTag
+func (_m *ProductDAOInterface) UpdateProductVersionServiceTags(id uint, availableTag string, dockerImageTag string) error {
+	ret := _m.Called(id, availableTag, dockerImageTag)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string, string) error); ok {
+		r0 = rf(id, availableTag, dockerImageTag)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
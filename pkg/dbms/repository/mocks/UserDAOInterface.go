@@ -89,6 +89,27 @@ func (_m *UserDAOInterface) FindByEmail(email string) (model.User, error) {
 	return r0, r1
 }
 
+// FindByID provides a mock function with given fields: id
+func (_m *UserDAOInterface) FindByID(id int) (model.User, error) {
+	ret := _m.Called(id)
+
+	var r0 model.User
+	if rf, ok := ret.Get(0).(func(int) model.User); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListAllUsers provides a mock function with given fields:
 func (_m *UserDAOInterface) ListAllUsers() ([]model.User, error) {
 	ret := _m.Called()
@@ -61,6 +61,27 @@ func (_m *WebHookDAOInterface) EditWebHook(e model.WebHook) error {
 	return r0
 }
 
+// GetWebHook provides a mock function with given fields: id
+func (_m *WebHookDAOInterface) GetWebHook(id int) (model.WebHook, error) {
+	ret := _m.Called(id)
+
+	var r0 model.WebHook
+	if rf, ok := ret.Get(0).(func(int) model.WebHook); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(model.WebHook)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListWebHooks provides a mock function with given fields:
 func (_m *WebHookDAOInterface) ListWebHooks() ([]model.WebHook, error) {
 	ret := _m.Called()
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//NotificationTemplateDAOInterface NotificationTemplateDAOInterface
+type NotificationTemplateDAOInterface interface {
+	CreateOrUpdateNotificationTemplate(t model.NotificationTemplate) error
+	DeleteNotificationTemplate(id int) error
+	GetNotificationTemplate(channel string, event string) (*model.NotificationTemplate, error)
+	ListNotificationTemplates() ([]model.NotificationTemplate, error)
+}
+
+//NotificationTemplateDAOImpl NotificationTemplateDAOImpl
+type NotificationTemplateDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateOrUpdateNotificationTemplate - Stores the template of a channel and event, replacing the stored one
+func (dao NotificationTemplateDAOImpl) CreateOrUpdateNotificationTemplate(t model.NotificationTemplate) error {
+	var stored model.NotificationTemplate
+	if err := dao.Db.Where(&model.NotificationTemplate{Channel: t.Channel, Event: t.Event}).First(&stored).Error; err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+		return dao.Db.Create(&t).Error
+	}
+	stored.Template = t.Template
+	return dao.Db.Save(&stored).Error
+}
+
+//DeleteNotificationTemplate - Deletes a template, restoring the default one
+func (dao NotificationTemplateDAOImpl) DeleteNotificationTemplate(id int) error {
+	return dao.Db.Unscoped().Delete(model.NotificationTemplate{}, id).Error
+}
+
+//GetNotificationTemplate - Get the template of a channel and event, nil when there is none
+func (dao NotificationTemplateDAOImpl) GetNotificationTemplate(channel string, event string) (*model.NotificationTemplate, error) {
+	var result model.NotificationTemplate
+	if err := dao.Db.Where(&model.NotificationTemplate{Channel: channel, Event: event}).First(&result).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+//ListNotificationTemplates - List the stored templates
+func (dao NotificationTemplateDAOImpl) ListNotificationTemplates() ([]model.NotificationTemplate, error) {
+	list := make([]model.NotificationTemplate, 0)
+	if err := dao.Db.Order("channel, event").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
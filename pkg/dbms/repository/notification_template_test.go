@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func getNotificationTemplate() model.NotificationTemplate {
+	var item model.NotificationTemplate
+	item.Channel = model.ChannelSlack
+	item.Event = model.HookDeployFinished
+	item.Template = `{{bold .environment}}`
+	return item
+}
+
+func beforeNotificationTemplateTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, NotificationTemplateDAOImpl, model.NotificationTemplate) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := NotificationTemplateDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao, getNotificationTemplate()
+}
+
+func TestCreateNotificationTemplate(t *testing.T) {
+	gormDB, mock, dao, item := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM "notification_templates" WHERE (.+)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "notification_templates"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Channel, item.Event, item.Template).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	e := dao.CreateOrUpdateNotificationTemplate(item)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestUpdateNotificationTemplate(t *testing.T) {
+	gormDB, mock, dao, item := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "channel", "event", "template"}).
+		AddRow(1, item.Channel, item.Event, "old")
+	mock.ExpectQuery(`SELECT (.+) FROM "notification_templates" WHERE (.+)`).
+		WillReturnRows(rows)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "notification_templates" SET (.*) WHERE (.*)`).
+		WithArgs(AnyTime{}, nil, item.Channel, item.Event, item.Template, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.CreateOrUpdateNotificationTemplate(item)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeleteNotificationTemplate(t *testing.T) {
+	gormDB, mock, dao, _ := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "notification_templates" WHERE (.*)`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.DeleteNotificationTemplate(1)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetNotificationTemplate(t *testing.T) {
+	gormDB, mock, dao, item := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "channel", "event", "template"}).
+		AddRow(1, item.Channel, item.Event, item.Template)
+	mock.ExpectQuery(`SELECT (.+) FROM "notification_templates" WHERE (.+)`).
+		WithArgs(item.Channel, item.Event).
+		WillReturnRows(rows)
+
+	result, err := dao.GetNotificationTemplate(item.Channel, item.Event)
+	assert.Nil(t, err)
+	assert.Equal(t, item.Template, result.Template)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetNotificationTemplate_NotFound(t *testing.T) {
+	gormDB, mock, dao, item := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM "notification_templates" WHERE (.+)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := dao.GetNotificationTemplate(item.Channel, item.Event)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListNotificationTemplates(t *testing.T) {
+	gormDB, mock, dao, item := beforeNotificationTemplateTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "channel", "event", "template"}).
+		AddRow(1, item.Channel, item.Event, item.Template)
+	mock.ExpectQuery(`SELECT (.+) FROM "notification_templates" WHERE (.+) ORDER BY channel, event`).
+		WillReturnRows(rows)
+
+	result, err := dao.ListNotificationTemplates()
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	mock.ExpectationsWereMet()
+}
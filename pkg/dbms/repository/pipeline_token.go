@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//PipelineTokenDAOInterface PipelineTokenDAOInterface
+type PipelineTokenDAOInterface interface {
+	CreatePipelineToken(t model.PipelineToken) (int, error)
+	DeletePipelineToken(id int) error
+	FindPipelineTokenByHash(hash string) (*model.PipelineToken, error)
+	ListPipelineTokens() ([]model.PipelineToken, error)
+}
+
+//PipelineTokenDAOImpl PipelineTokenDAOImpl
+type PipelineTokenDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreatePipelineToken - Create a new pipeline token
+func (dao PipelineTokenDAOImpl) CreatePipelineToken(t model.PipelineToken) (int, error) {
+	if err := dao.Db.Create(&t).Error; err != nil {
+		return -1, err
+	}
+	return int(t.ID), nil
+}
+
+//DeletePipelineToken - Revokes a pipeline token
+func (dao PipelineTokenDAOImpl) DeletePipelineToken(id int) error {
+	return dao.Db.Unscoped().Delete(model.PipelineToken{}, id).Error
+}
+
+//FindPipelineTokenByHash - Find the pipeline token with the given hash, nil when there is none
+func (dao PipelineTokenDAOImpl) FindPipelineTokenByHash(hash string) (*model.PipelineToken, error) {
+	var result model.PipelineToken
+	if err := dao.Db.Where(&model.PipelineToken{TokenHash: hash}).First(&result).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+//ListPipelineTokens - List the pipeline tokens
+func (dao PipelineTokenDAOImpl) ListPipelineTokens() ([]model.PipelineToken, error) {
+	list := make([]model.PipelineToken, 0)
+	if err := dao.Db.Order("name").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func getPipelineToken() model.PipelineToken {
+	var item model.PipelineToken
+	item.Name = "my-pipeline"
+	item.TokenHash = "hash"
+	item.Environments = []int64{999}
+	item.Scopes = []string{model.PipelineScopeDeploy}
+	item.CreatedBy = "beta@alfa.com"
+	return item
+}
+
+func beforePipelineTokenTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, PipelineTokenDAOImpl, model.PipelineToken) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := PipelineTokenDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao, getPipelineToken()
+}
+
+func TestCreatePipelineToken(t *testing.T) {
+	gormDB, mock, dao, item := beforePipelineTokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "pipeline_tokens"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.TokenHash, "{999}", `{"deploy"}`, item.CreatedBy).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	result, e := dao.CreatePipelineToken(item)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeletePipelineToken(t *testing.T) {
+	gormDB, mock, dao, _ := beforePipelineTokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "pipeline_tokens" WHERE (.*)`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.DeletePipelineToken(1)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestFindPipelineTokenByHash(t *testing.T) {
+	gormDB, mock, dao, item := beforePipelineTokenTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "token_hash", "environments"}).
+		AddRow(1, item.Name, item.TokenHash, "{999}")
+	mock.ExpectQuery(`SELECT (.+) FROM "pipeline_tokens" WHERE (.+)`).
+		WithArgs(item.TokenHash).
+		WillReturnRows(rows)
+
+	result, err := dao.FindPipelineTokenByHash(item.TokenHash)
+	assert.Nil(t, err)
+	assert.Equal(t, item.Name, result.Name)
+	assert.Equal(t, []int64{999}, []int64(result.Environments))
+
+	mock.ExpectationsWereMet()
+}
+
+func TestFindPipelineTokenByHash_NotFound(t *testing.T) {
+	gormDB, mock, dao, _ := beforePipelineTokenTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectQuery(`SELECT (.+) FROM "pipeline_tokens" WHERE (.+)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := dao.FindPipelineTokenByHash("unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListPipelineTokens(t *testing.T) {
+	gormDB, mock, dao, item := beforePipelineTokenTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, item.Name)
+	mock.ExpectQuery(`SELECT (.+) FROM "pipeline_tokens" WHERE (.+) ORDER BY "name"`).
+		WillReturnRows(rows)
+
+	result, err := dao.ListPipelineTokens()
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	mock.ExpectationsWereMet()
+}
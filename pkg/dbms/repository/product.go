@@ -23,6 +23,7 @@ type ProductDAOInterface interface {
 	ListProductsVersionServices(id int) ([]model2.ProductVersionService, error)
 	CreateProductVersionCopying(payload model2.ProductVersion) (int, error)
 	ListProductVersionsByID(id int) (*model2.ProductVersion, error)
+	UpdateProductVersionServiceTags(id uint, availableTag string, dockerImageTag string) error
 }
 
 //ProductDAOImpl ProductDAOImpl
@@ -96,6 +97,12 @@ func (dao ProductDAOImpl) EditProductVersionService(e model2.ProductVersionServi
 	return dao.Db.Save(&e).Error
 }
 
+//UpdateProductVersionServiceTags - Updates only the tags of a product version service
+func (dao ProductDAOImpl) UpdateProductVersionServiceTags(id uint, availableTag string, dockerImageTag string) error {
+	return dao.Db.Model(&model2.ProductVersionService{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"available_tag": availableTag, "docker_image_tag": dockerImageTag}).Error
+}
+
 //DeleteProduct - Deletes a product
 func (dao ProductDAOImpl) DeleteProduct(id int) error {
 	return dao.Db.Unscoped().Delete(model2.Product{}, id).Error
@@ -161,11 +168,12 @@ func (dao ProductDAOImpl) ListProductsVersionServices(id int) ([]model2.ProductV
 	var ID uint
 	var serviceName string
 	var dockerImageTag string
+	var availableTag string
 	var notes string
 
 	rows, err := dao.Db.Table("product_version_services").Select("product_version_services.id, " +
 		" product_version_services.service_name, " +
-		" product_version_services.docker_image_tag, notes.text").Joins("LEFT JOIN notes on product_version_services.service_name = notes.service_name").Where(&model2.ProductVersionService{ProductVersionID: id}).Rows()
+		" product_version_services.docker_image_tag, product_version_services.available_tag, notes.text").Joins("LEFT JOIN notes on product_version_services.service_name = notes.service_name").Where(&model2.ProductVersionService{ProductVersionID: id}).Rows()
 
 	if err != nil {
 		if gorm.IsRecordNotFoundError(err) {
@@ -179,9 +187,11 @@ func (dao ProductDAOImpl) ListProductsVersionServices(id int) ([]model2.ProductV
 		ID = 0
 		serviceName = ""
 		dockerImageTag = ""
+		availableTag = ""
 		notes = ""
-		rows.Scan(&ID, &serviceName, &dockerImageTag, &notes)
-		p := &model2.ProductVersionService{ServiceName: serviceName, DockerImageTag: dockerImageTag, Notes: notes}
+		rows.Scan(&ID, &serviceName, &dockerImageTag, &availableTag, &notes)
+		p := &model2.ProductVersionService{ServiceName: serviceName, DockerImageTag: dockerImageTag,
+			AvailableTag: availableTag, Notes: notes}
 		p.ID = ID
 		list = append(list, *p)
 	}
@@ -92,7 +92,7 @@ func TestCreateProduct(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(99)
 
 	mock.ExpectQuery(`INSERT INTO "products"`).
-		WithArgs(AnyTime{}, AnyTime{}, nil, product.Name, product.ValidateReleases).WillReturnRows(rows)
+		WithArgs(AnyTime{}, AnyTime{}, nil, product.Name, product.ValidateReleases, product.AutoUpdateTags).WillReturnRows(rows)
 
 	_, err = produtDAO.CreateProduct(product)
 	assert.Nil(t, err)
@@ -119,7 +119,7 @@ func TestEdit(t *testing.T) {
 	product.ValidateReleases = true
 
 	mock.ExpectExec(`UPDATE "products" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, product.Name, product.ValidateReleases, product.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+		WithArgs(AnyTime{}, nil, product.Name, product.ValidateReleases, product.AutoUpdateTags, product.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = produtDAO.EditProduct(product)
 	assert.Nil(t, err)
@@ -150,12 +150,20 @@ func TestEdit(t *testing.T) {
 
 	mock.ExpectExec(`UPDATE "product_version_services" SET (.*) WHERE (.*)`).
 		WithArgs(AnyTime{}, nil, productVersionService.ProductVersionID, productVersionService.ServiceName,
-			productVersionService.DockerImageTag, productVersionService.Notes, productVersionService.ID).
+			productVersionService.DockerImageTag, productVersionService.AvailableTag, productVersionService.Notes,
+			productVersionService.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = produtDAO.EditProductVersionService(productVersionService)
 	assert.Nil(t, err)
 
+	mock.ExpectExec(`UPDATE "product_version_services" SET (.*) WHERE (.*)`).
+		WithArgs("19.3.1-0", "19.3.1-0", AnyTime{}, productVersionService.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = produtDAO.UpdateProductVersionServiceTags(productVersionService.ID, "19.3.1-0", "19.3.1-0")
+	assert.Nil(t, err)
+
 	mock.ExpectationsWereMet()
 }
 
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//ServiceAccountDAOInterface ServiceAccountDAOInterface
+type ServiceAccountDAOInterface interface {
+	CreateServiceAccount(sa model.ServiceAccount) (int, error)
+	DeleteServiceAccount(id int) error
+	GetServiceAccount(id int) (model.ServiceAccount, error)
+	ListServiceAccounts() ([]model.ServiceAccount, error)
+}
+
+//ServiceAccountDAOImpl ServiceAccountDAOImpl
+type ServiceAccountDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateServiceAccount - Create a new service account
+func (dao ServiceAccountDAOImpl) CreateServiceAccount(sa model.ServiceAccount) (int, error) {
+	if err := dao.Db.Create(&sa).Error; err != nil {
+		return -1, err
+	}
+	return int(sa.ID), nil
+}
+
+//DeleteServiceAccount - Delete a service account
+func (dao ServiceAccountDAOImpl) DeleteServiceAccount(id int) error {
+	return dao.Db.Unscoped().Delete(model.ServiceAccount{}, id).Error
+}
+
+//GetServiceAccount - Get a service account
+func (dao ServiceAccountDAOImpl) GetServiceAccount(id int) (model.ServiceAccount, error) {
+	var result model.ServiceAccount
+	if err := dao.Db.First(&result, id).Error; err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+//ListServiceAccounts - List the service accounts
+func (dao ServiceAccountDAOImpl) ListServiceAccounts() ([]model.ServiceAccount, error) {
+	list := make([]model.ServiceAccount, 0)
+	if err := dao.Db.Order("name").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
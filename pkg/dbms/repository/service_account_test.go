@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func beforeServiceAccountTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, ServiceAccountDAOImpl) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := ServiceAccountDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	gormDB, mock, dao := beforeServiceAccountTest(t)
+	defer gormDB.Close()
+
+	item := model.ServiceAccount{Name: "deployer", Description: "CI", UserID: 8, CreatedBy: "beta@alfa.com"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "service_accounts"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.Description, item.UserID, item.CreatedBy).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	result, e := dao.CreateServiceAccount(item)
+	assert.Nil(t, e)
+	assert.Equal(t, 3, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestDeleteServiceAccount(t *testing.T) {
+	gormDB, mock, dao := beforeServiceAccountTest(t)
+	defer gormDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "service_accounts" WHERE (.*)`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.DeleteServiceAccount(3)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetServiceAccount(t *testing.T) {
+	gormDB, mock, dao := beforeServiceAccountTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "user_id"}).AddRow(3, "deployer", 8)
+	mock.ExpectQuery(`SELECT (.+) FROM "service_accounts" WHERE (.+)`).
+		WillReturnRows(rows)
+
+	result, err := dao.GetServiceAccount(3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(8), result.UserID)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListServiceAccounts(t *testing.T) {
+	gormDB, mock, dao := beforeServiceAccountTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "deployer")
+	mock.ExpectQuery(`SELECT (.+) FROM "service_accounts" WHERE (.+) ORDER BY "name"`).
+		WillReturnRows(rows)
+
+	result, err := dao.ListServiceAccounts()
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	mock.ExpectationsWereMet()
+}
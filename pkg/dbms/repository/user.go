@@ -13,6 +13,7 @@ type UserDAOInterface interface {
 	ListAllUsers() ([]model2.User, error)
 	CreateOrUpdateUser(user model2.User) error
 	FindByEmail(email string) (model2.User, error)
+	FindByID(id int) (model2.User, error)
 }
 
 //UserDAOImpl UserDAOImpl
@@ -152,3 +153,12 @@ func (dao UserDAOImpl) FindByEmail(email string) (model2.User, error) {
 	}
 	return user, nil
 }
+
+//FindByID FindByID
+func (dao UserDAOImpl) FindByID(id int) (model2.User, error) {
+	var user model2.User
+	if err := dao.Db.First(&user, id).Error; err != nil {
+		return user, err
+	}
+	return user, nil
+}
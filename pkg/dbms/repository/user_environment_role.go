@@ -54,18 +54,32 @@ func (dao UserEnvironmentRoleDAOImpl) create(so model2.UserEnvironmentRole) erro
 	return nil
 }
 
-//GetRoleByUserAndEnvironment - GetRoleByUserAndEnvironment
+//GetRoleByUserAndEnvironment - Role of a user on an environment, falling back to the role on its group
 func (dao UserEnvironmentRoleDAOImpl) GetRoleByUserAndEnvironment(user model2.User,
 	envID uint) (*model2.SecurityOperation, error) {
+	var securityOperationID uint
 	var userEnvironmentRole model2.UserEnvironmentRole
 	if err := dao.Db.Where(model2.UserEnvironmentRole{UserID: user.ID, EnvironmentID: envID}).Find(&userEnvironmentRole).Error; err != nil {
 		if !gorm.IsRecordNotFoundError(err) {
 			return nil, err
 		}
-		return nil, nil
+		//Without a role on the environment, the role granted on its group applies
+		var groupRole model2.GroupRole
+		if err := dao.Db.Joins(`INNER JOIN groups ON groups.id = group_roles.group_id AND groups.deleted_at IS NULL`).
+			Joins(`INNER JOIN environments ON environments."group" = groups.name`).
+			Where("environments.id = ? AND group_roles.user_id = ?", envID, user.ID).
+			First(&groupRole).Error; err != nil {
+			if !gorm.IsRecordNotFoundError(err) {
+				return nil, err
+			}
+			return nil, nil
+		}
+		securityOperationID = groupRole.SecurityOperationID
+	} else {
+		securityOperationID = userEnvironmentRole.SecurityOperationID
 	}
 	var result model2.SecurityOperation
-	if err := dao.Db.First(&result, userEnvironmentRole.SecurityOperationID).Error; err != nil {
+	if err := dao.Db.First(&result, securityOperationID).Error; err != nil {
 		if !gorm.IsRecordNotFoundError(err) {
 			return nil, err
 		}
@@ -270,3 +270,24 @@ func TestFindByEmail(t *testing.T) {
 
 	mock.ExpectationsWereMet()
 }
+
+func TestFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	mock.MatchExpectationsInOrder(false)
+	assert.Nil(t, err)
+
+	gormDB, err := gorm.Open("postgres", db)
+	defer gormDB.Close()
+
+	userDAO := UserDAOImpl{}
+	userDAO.Db = gormDB
+
+	row1 := sqlmock.NewRows([]string{"id", "email"}).AddRow(888, "musk@mars.com")
+
+	mock.ExpectQuery(`SELECT .+ FROM "users" WHERE "users"."deleted_at" IS NULL AND \(\("users"."id" = 888\)\)`).
+		WillReturnRows(row1)
+
+	u, e := userDAO.FindByID(888)
+	assert.NoError(t, e)
+	assert.Equal(t, "musk@mars.com", u.Email)
+}
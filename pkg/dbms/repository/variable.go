@@ -43,7 +43,7 @@ func (dao VariableDAOImpl) CreateVariable(variable model2.Variable) (map[string]
 		Scope:         variable.Scope,
 		Name:          variable.Name}).First(&variableEntity).Error; err == nil {
 
-		if variable.Value != variableEntity.Value {
+		if variable.Value != variableEntity.Value || variable.Type != variableEntity.Type {
 
 			auditValues["variable_name"] = variableEntity.Name
 			auditValues["variable_old_value"] = variableEntity.Value
@@ -51,6 +51,7 @@ func (dao VariableDAOImpl) CreateVariable(variable model2.Variable) (map[string]
 			auditValues["scope"] = variable.Scope
 
 			variableEntity.Value = variable.Value
+			variableEntity.Type = variable.Type
 			if err := dao.Db.Save(variableEntity).Error; err != nil {
 				return auditValues, updated, err
 			}
@@ -39,7 +39,7 @@ func TestCreateVariableRule(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 
 	mock.ExpectQuery(`INSERT INTO "variable_rules"`).
-		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.EnvironmentID, item.Group, item.Scope).
 		WillReturnRows(rows)
 
 	result, e := dao.CreateVariableRule(item)
@@ -54,7 +54,7 @@ func TestCreateVariableRule_Error(t *testing.T) {
 	defer gormDB.Close()
 
 	mock.ExpectQuery(`INSERT INTO "variable_rules"`).
-		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.EnvironmentID, item.Group, item.Scope).
 		WillReturnError(errors.New("some error"))
 
 	_, e := dao.CreateVariableRule(item)
@@ -70,7 +70,7 @@ func TestEditVariableRule(t *testing.T) {
 	item.ID = 999
 
 	mock.ExpectExec(`UPDATE "variable_rules" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, item.Name, item.ID).
+		WithArgs(AnyTime{}, nil, item.Name, item.EnvironmentID, item.Group, item.Scope, item.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	e := dao.EditVariableRule(item)
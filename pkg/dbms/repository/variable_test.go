@@ -27,7 +27,7 @@ func TestEditVariable(t *testing.T) {
 	v := getVariable()
 
 	mock.ExpectExec(`UPDATE "variables" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID, v.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID, v.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = dao.EditVariable(v)
 
@@ -60,7 +60,7 @@ func TestCreateVariable(t *testing.T) {
 		WillReturnError(errors.New("mock error"))
 
 	mock.ExpectQuery(`INSERT INTO "variables"`).
-		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID).
+		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID).
 		WillReturnRows(rows)
 
 	audit, updated, err := dao.CreateVariable(v)
@@ -92,7 +92,7 @@ func TestCreateVariable_Error(t *testing.T) {
 		WillReturnError(errors.New("mock error"))
 
 	mock.ExpectQuery(`INSERT INTO "variables"`).
-		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID).
+		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID).
 		WillReturnError(errors.New("mock error"))
 
 	audit, updated, err := dao.CreateVariable(v)
@@ -127,7 +127,7 @@ func TestCreateVariable_Audit(t *testing.T) {
 		WillReturnRows(rows1)
 
 	mock.ExpectExec(`UPDATE "variables" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID, v.ID).
+		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID, v.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	audit, updated, err := dao.CreateVariable(v)
@@ -162,7 +162,7 @@ func TestCreateVariable_AuditSaveError(t *testing.T) {
 		WillReturnRows(rows1)
 
 	mock.ExpectExec(`UPDATE "variables" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID, v.ID).
+		WithArgs(AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID, v.ID).
 		WillReturnError(errors.New("mock error"))
 
 	audit, updated, err := dao.CreateVariable(v)
@@ -196,7 +196,7 @@ func TestCreateVariableWithDefaultValue(t *testing.T) {
 		WillReturnError(gorm.ErrRecordNotFound)
 
 	mock.ExpectQuery(`INSERT INTO "variables"`).
-		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Secret, v.Description, v.EnvironmentID).
+		WithArgs(999, AnyTime{}, AnyTime{}, nil, v.Scope, v.Name, v.Value, v.Type, v.Secret, v.Description, v.EnvironmentID).
 		WillReturnRows(rows)
 
 	audit, updated, err := dao.CreateVariableWithDefaultValue(v)
@@ -296,6 +296,7 @@ func getVariable() model.Variable {
 	v.Description = "Description value"
 	v.Secret = false
 	v.Value = "value value"
+	v.Type = model.VariableTypeString
 	v.Name = "name value"
 	v.Scope = "serviceA"
 	v.ID = 999
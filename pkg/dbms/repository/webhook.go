@@ -10,6 +10,7 @@ type WebHookDAOInterface interface {
 	CreateWebHook(e model.WebHook) (int, error)
 	EditWebHook(e model.WebHook) error
 	DeleteWebHook(id int) error
+	GetWebHook(id int) (model.WebHook, error)
 	ListWebHooks() ([]model.WebHook, error)
 	ListWebHooksByEnvAndType(environmentID int, hookType string) ([]model.WebHook, error)
 }
@@ -37,6 +38,15 @@ func (dao WebHookDAOImpl) DeleteWebHook(id int) error {
 	return dao.Db.Unscoped().Delete(model.WebHook{}, id).Error
 }
 
+//GetWebHook - Get a webhook by id
+func (dao WebHookDAOImpl) GetWebHook(id int) (model.WebHook, error) {
+	var result model.WebHook
+	if err := dao.Db.First(&result, id).Error; err != nil {
+		return model.WebHook{}, err
+	}
+	return result, nil
+}
+
 //ListWebHooks - List webhooks
 func (dao WebHookDAOImpl) ListWebHooks() ([]model.WebHook, error) {
 	list := make([]model.WebHook, 0)
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	model "github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//MaxWebHookDeliveries is the number of deliveries listed for a webhook
+const MaxWebHookDeliveries = 100
+
+//WebHookDeliveryDAOInterface WebHookDeliveryDAOInterface
+type WebHookDeliveryDAOInterface interface {
+	CreateWebHookDelivery(e model.WebHookDelivery) (int, error)
+	EditWebHookDelivery(e model.WebHookDelivery) error
+	GetWebHookDelivery(id int) (model.WebHookDelivery, error)
+	ListWebHookDeliveries(webHookID int) ([]model.WebHookDelivery, error)
+}
+
+//WebHookDeliveryDAOImpl WebHookDeliveryDAOImpl
+type WebHookDeliveryDAOImpl struct {
+	Db *gorm.DB
+}
+
+//CreateWebHookDelivery - Create a new webhook delivery
+func (dao WebHookDeliveryDAOImpl) CreateWebHookDelivery(e model.WebHookDelivery) (int, error) {
+	if err := dao.Db.Create(&e).Error; err != nil {
+		return -1, err
+	}
+	return int(e.ID), nil
+}
+
+//EditWebHookDelivery - Updates an existing webhook delivery
+func (dao WebHookDeliveryDAOImpl) EditWebHookDelivery(e model.WebHookDelivery) error {
+	return dao.Db.Save(&e).Error
+}
+
+//GetWebHookDelivery - Get a webhook delivery by id
+func (dao WebHookDeliveryDAOImpl) GetWebHookDelivery(id int) (model.WebHookDelivery, error) {
+	var result model.WebHookDelivery
+	if err := dao.Db.First(&result, id).Error; err != nil {
+		return model.WebHookDelivery{}, err
+	}
+	return result, nil
+}
+
+//ListWebHookDeliveries - List the latest deliveries of a webhook, newest first
+func (dao WebHookDeliveryDAOImpl) ListWebHookDeliveries(webHookID int) ([]model.WebHookDelivery, error) {
+	list := make([]model.WebHookDelivery, 0)
+	if err := dao.Db.Where(&model.WebHookDelivery{WebHookID: uint(webHookID)}).
+		Order("id desc").Limit(MaxWebHookDeliveries).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func getWebHookDelivery() model.WebHookDelivery {
+	var item model.WebHookDelivery
+	item.WebHookID = 999
+	item.Event = "HOOK_DEPLOY_PRODUCT"
+	item.URL = "http://example.com"
+	item.Payload = "{}"
+	item.Status = model.WebHookDeliveryPending
+	return item
+}
+
+func beforeWebHookDeliveryTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, WebHookDeliveryDAOImpl, model.WebHookDelivery) {
+	db, mock, err := sqlmock.New()
+	gormDB, err := gorm.Open("postgres", db)
+
+	assert.Nil(t, err)
+
+	dao := WebHookDeliveryDAOImpl{}
+	dao.Db = gormDB
+
+	mock.MatchExpectationsInOrder(false)
+
+	return gormDB, mock, dao, getWebHookDelivery()
+}
+
+func TestCreateWebHookDelivery(t *testing.T) {
+	gormDB, mock, dao, item := beforeWebHookDeliveryTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "web_hook_deliveries"`).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.WebHookID, item.Event, item.URL, item.Payload, item.Status,
+			0, 0, "", "", 0).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	result, e := dao.CreateWebHookDelivery(item)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, result)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestEditWebHookDelivery(t *testing.T) {
+	gormDB, mock, dao, item := beforeWebHookDeliveryTest(t)
+	defer gormDB.Close()
+
+	item.ID = 1
+	item.Attempts = 2
+	item.Status = model.WebHookDeliveryDelivered
+	item.StatusCode = 200
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "web_hook_deliveries" SET (.*) WHERE (.*)`).
+		WithArgs(AnyTime{}, nil, item.WebHookID, item.Event, item.URL, item.Payload, item.Status,
+			2, 200, "", "", 0, item.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	e := dao.EditWebHookDelivery(item)
+	assert.Nil(t, e)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestGetWebHookDelivery(t *testing.T) {
+	gormDB, mock, dao, _ := beforeWebHookDeliveryTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "web_hook_id", "payload"}).AddRow(1, 999, "{}")
+	mock.ExpectQuery(`SELECT (.+) FROM "web_hook_deliveries" WHERE (.+)`).
+		WillReturnRows(rows)
+
+	result, err := dao.GetWebHookDelivery(1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(999), result.WebHookID)
+
+	mock.ExpectationsWereMet()
+}
+
+func TestListWebHookDeliveries(t *testing.T) {
+	gormDB, mock, dao, _ := beforeWebHookDeliveryTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "web_hook_id"}).AddRow(2, 999).AddRow(1, 999)
+	mock.ExpectQuery(`SELECT (.+) FROM "web_hook_deliveries" WHERE (.+) ORDER BY id desc LIMIT 100`).
+		WithArgs(999).
+		WillReturnRows(rows)
+
+	result, err := dao.ListWebHookDeliveries(999)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	mock.ExpectationsWereMet()
+}
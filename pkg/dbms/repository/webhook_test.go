@@ -43,7 +43,7 @@ func TestCreateWebHook(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 
 	mock.ExpectQuery(`INSERT INTO "web_hooks" *`).
-		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.Type, item.URL, item.EnvironmentID, item.AdditionalData).
+		WithArgs(AnyTime{}, AnyTime{}, nil, item.Name, item.Type, item.URL, item.EnvironmentID, item.AdditionalData, item.Secret, item.TimeoutSeconds, item.Channel).
 		WillReturnRows(rows)
 
 	result, e := dao.CreateWebHook(item)
@@ -74,7 +74,7 @@ func TestEditWebHook(t *testing.T) {
 	item.ID = 999
 
 	mock.ExpectExec(`UPDATE "web_hooks" SET (.*) WHERE (.*)`).
-		WithArgs(AnyTime{}, nil, item.Name, item.Type, item.URL, item.EnvironmentID, item.AdditionalData, item.ID).
+		WithArgs(AnyTime{}, nil, item.Name, item.Type, item.URL, item.EnvironmentID, item.AdditionalData, item.Secret, item.TimeoutSeconds, item.Channel, item.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	e := dao.EditWebHook(item)
@@ -188,3 +188,21 @@ func TestListWebHooksByEnvAndType_Error(t *testing.T) {
 
 	mock.ExpectationsWereMet()
 }
+
+func TestGetWebHook(t *testing.T) {
+	gormDB, mock, dao, item := beforeWebHookTest(t)
+	defer gormDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "secret"}).
+		AddRow(999, item.Name, "abc")
+
+	mock.ExpectQuery(`SELECT (.+) FROM "web_hooks" WHERE (.+)`).
+		WillReturnRows(rows)
+
+	result, err := dao.GetWebHook(999)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(999), result.ID)
+	assert.Equal(t, "abc", result.Secret)
+
+	mock.ExpectationsWereMet()
+}
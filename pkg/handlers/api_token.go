@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//apiTokenPrefix tells the API tokens apart from the JWTs and the pipeline tokens
+const apiTokenPrefix = "tka_"
+
+//DefaultAPITokenTTL lifetime of an API token created without an expiration
+const DefaultAPITokenTTL = 90 * 24 * time.Hour
+
+//apiTokenLastUsedResolution avoids writing the last use of a token on every request
+const apiTokenLastUsedResolution = time.Minute
+
+//serviceAccountEmail is the email of the user backing a service account
+func serviceAccountEmail(name string) string {
+	return "serviceaccount:" + name
+}
+
+//authenticateAPITokens sets the principal of the requests authenticated by an API token,
+//denying the requests of unknown, expired or revoked tokens
+func (appContext *AppContext) authenticateAPITokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if !strings.HasPrefix(token, apiTokenPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := appContext.getAPITokenPrincipal(token, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if principal == nil {
+			r.Header.Del("principal")
+			http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+			return
+		}
+
+		data, _ := json.Marshal(*principal)
+		r.Header.Set("principal", string(data))
+		next.ServeHTTP(w, r)
+	})
+}
+
+//getAPITokenPrincipal returns the principal of a valid API token, nil when the token is not valid at the given time
+func (appContext *AppContext) getAPITokenPrincipal(token string, now time.Time) (*model.Principal, error) {
+	apiToken, err := appContext.Repositories.APITokenDAO.FindAPITokenByHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if apiToken == nil || apiToken.RevokedAt != nil || (apiToken.ExpiresAt != nil && now.After(*apiToken.ExpiresAt)) {
+		return nil, nil
+	}
+
+	user, err := appContext.Repositories.UserDAO.FindByID(int(apiToken.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= apiTokenLastUsedResolution {
+		if err := appContext.Repositories.APITokenDAO.TouchAPIToken(int(apiToken.ID), now); err != nil {
+			log.Println("Error trying to record the use of API token", apiToken.ID, err)
+		}
+	}
+
+	return &model.Principal{
+		Name:  apiToken.Name,
+		Email: user.Email,
+		Token: &model.TokenScope{
+			ID:           apiToken.ID,
+			Environments: apiToken.Environments,
+			Policies:     apiToken.Policies,
+		},
+	}, nil
+}
+
+//createAPIToken stores a new token of a user and writes it to the response, the only time it is shown
+func (appContext *AppContext) createAPIToken(w http.ResponseWriter, r *http.Request, userID uint, serviceAccountID uint) {
+
+	var payload model.APIToken
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(payload.Name) == 0 || len(payload.Environments) == 0 {
+		http.Error(w, "name and environments are required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if payload.ExpiresAt == nil {
+		expiresAt := now.Add(DefaultAPITokenTTL)
+		payload.ExpiresAt = &expiresAt
+	} else if !payload.ExpiresAt.After(now) {
+		http.Error(w, "expiresAt must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	token, err := generateToken(apiTokenPrefix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiToken := model.APIToken{
+		Name:             payload.Name,
+		TokenHash:        hashToken(token),
+		UserID:           userID,
+		ServiceAccountID: serviceAccountID,
+		Environments:     payload.Environments,
+		Policies:         payload.Policies,
+		ExpiresAt:        payload.ExpiresAt,
+		CreatedBy:        util.GetPrincipal(r).Email,
+	}
+	id, err := appContext.Repositories.APITokenDAO.CreateAPIToken(apiToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(model.NewAPITokenResponse{ID: id, Token: token})
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (appContext *AppContext) writeAPITokens(w http.ResponseWriter, userID uint) {
+	result := &model.APITokenResponse{}
+	var err error
+	if result.List, err = appContext.Repositories.APITokenDAO.ListAPITokens(int(userID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//inTokenScope tells whether the request may act on the environment, answering forbidden when it is
+//authenticated by an API token not scoped to that environment
+func inTokenScope(w http.ResponseWriter, r *http.Request, envID int) bool {
+	principal := util.GetPrincipal(r)
+	if principal.Token != nil && !containsEnvironment(principal.Token.Environments, envID) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+//isTokenAdmin tells whether the principal manages every token, tokens never manage other tokens
+func isTokenAdmin(principal model.Principal) bool {
+	return principal.Token == nil && util.Contains(principal.Roles, constraints.TenkaiAdmin)
+}
+
+func (appContext *AppContext) listAPITokens(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if principal.Token != nil {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.writeAPITokens(w, user.ID)
+}
+
+func (appContext *AppContext) newAPIToken(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if principal.Token != nil || len(principal.Email) == 0 {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.createAPIToken(w, r, user.ID, 0)
+}
+
+func (appContext *AppContext) revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if principal.Token != nil {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	apiToken, err := appContext.Repositories.APITokenDAO.GetAPIToken(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	//Users revoke their own tokens, admins revoke any token
+	if !isTokenAdmin(principal) {
+		user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if user.ID != apiToken.UserID {
+			http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if err := appContext.Repositories.APITokenDAO.RevokeAPIToken(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) listServiceAccounts(w http.ResponseWriter, r *http.Request) {
+
+	if !isTokenAdmin(util.GetPrincipal(r)) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result := &model.ServiceAccountResponse{}
+	var err error
+	if result.List, err = appContext.Repositories.ServiceAccountDAO.ListServiceAccounts(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//newServiceAccount creates a service account and the user backing it, environments and roles
+//are then granted to the user of the service account like to any other user
+func (appContext *AppContext) newServiceAccount(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !isTokenAdmin(principal) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.ServiceAccount
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(payload.Name) == 0 {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	email := serviceAccountEmail(payload.Name)
+	if err := appContext.Repositories.UserDAO.CreateUser(model.User{Email: email}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user, err := appContext.Repositories.UserDAO.FindByEmail(email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	payload.UserID = user.ID
+	payload.CreatedBy = principal.Email
+	id, err := appContext.Repositories.ServiceAccountDAO.CreateServiceAccount(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(map[string]int{"id": id})
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+//deleteServiceAccount revokes the tokens of a service account and deletes it with its user
+func (appContext *AppContext) deleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+
+	sa, ok := appContext.getServiceAccountAsAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := appContext.Repositories.APITokenDAO.RevokeUserAPITokens(int(sa.UserID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := appContext.Repositories.ServiceAccountDAO.DeleteServiceAccount(int(sa.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := appContext.Repositories.UserDAO.DeleteUser(int(sa.UserID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) listServiceAccountTokens(w http.ResponseWriter, r *http.Request) {
+	if sa, ok := appContext.getServiceAccountAsAdmin(w, r); ok {
+		appContext.writeAPITokens(w, sa.UserID)
+	}
+}
+
+func (appContext *AppContext) newServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	if sa, ok := appContext.getServiceAccountAsAdmin(w, r); ok {
+		appContext.createAPIToken(w, r, sa.UserID, sa.ID)
+	}
+}
+
+//getServiceAccountAsAdmin returns the service account of the request, writing the error response when
+//the principal is not an admin or the service account does not exist
+func (appContext *AppContext) getServiceAccountAsAdmin(w http.ResponseWriter, r *http.Request) (model.ServiceAccount, bool) {
+	if !isTokenAdmin(util.GetPrincipal(r)) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return model.ServiceAccount{}, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return model.ServiceAccount{}, false
+	}
+
+	sa, err := appContext.Repositories.ServiceAccountDAO.GetServiceAccount(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return model.ServiceAccount{}, false
+	}
+	return sa, true
+}
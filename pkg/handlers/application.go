@@ -17,60 +17,78 @@ import (
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	"github.com/softplan/tenkai-api/pkg/dbms/repository"
 	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/mail"
 	"github.com/softplan/tenkai-api/pkg/rabbitmq"
 	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
 	"github.com/softplan/tenkai-api/pkg/service/core"
 	dockerapi "github.com/softplan/tenkai-api/pkg/service/docker"
 	"github.com/softplan/tenkai-api/pkg/tenkaihelm"
+	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/softplan/tenkai-api/pkg/webhook"
 	"github.com/streadway/amqp"
 	"go.elastic.co/apm/module/apmgorilla"
 )
 
 //Repositories  Repositories
 type Repositories struct {
-	ConfigDAO              repository.ConfigDAOInterface
-	DockerDAO              repository.DockerDAOInterface
-	EnvironmentDAO         repository.EnvironmentDAOInterface
-	ProductDAO             repository.ProductDAOInterface
-	SolutionDAO            repository.SolutionDAOInterface
-	SolutionChartDAO       repository.SolutionChartDAOInterface
-	UserDAO                repository.UserDAOInterface
-	VariableDAO            repository.VariableDAOInterface
-	ValueRuleDAO           repository.ValueRuleDAOInterface
-	VariableRuleDAO        repository.VariableRuleDAOInterface
-	CompareEnvsQueryDAO    repository.CompareEnvsQueryDAOInterface
-	SecurityOperationDAO   repository.SecurityOperationDAOInterface
-	UserEnvironmentRoleDAO repository.UserEnvironmentRoleDAOInterface
-	NotesDAO               repository.NotesDAOInterface
-	WebHookDAO             repository.WebHookDAOInterface
-	DeploymentDAO          repository.DeploymentDAOInterface
-	RequestDeploymentDAO   repository.RequestDeploymentDAOInterface
+	ConfigDAO               repository.ConfigDAOInterface
+	DockerDAO               repository.DockerDAOInterface
+	EnvironmentDAO          repository.EnvironmentDAOInterface
+	ProductDAO              repository.ProductDAOInterface
+	SolutionDAO             repository.SolutionDAOInterface
+	SolutionChartDAO        repository.SolutionChartDAOInterface
+	UserDAO                 repository.UserDAOInterface
+	VariableDAO             repository.VariableDAOInterface
+	ValueRuleDAO            repository.ValueRuleDAOInterface
+	VariableRuleDAO         repository.VariableRuleDAOInterface
+	CompareEnvsQueryDAO     repository.CompareEnvsQueryDAOInterface
+	SecurityOperationDAO    repository.SecurityOperationDAOInterface
+	UserEnvironmentRoleDAO  repository.UserEnvironmentRoleDAOInterface
+	NotesDAO                repository.NotesDAOInterface
+	WebHookDAO              repository.WebHookDAOInterface
+	WebHookDeliveryDAO      repository.WebHookDeliveryDAOInterface
+	NotificationTemplateDAO repository.NotificationTemplateDAOInterface
+	EmailSubscriptionDAO    repository.EmailSubscriptionDAOInterface
+	PipelineTokenDAO        repository.PipelineTokenDAOInterface
+	ServiceAccountDAO       repository.ServiceAccountDAOInterface
+	APITokenDAO             repository.APITokenDAOInterface
+	DeploymentDAO           repository.DeploymentDAOInterface
+	RequestDeploymentDAO    repository.RequestDeploymentDAOInterface
+	EnvironmentStatusDAO    repository.EnvironmentStatusDAOInterface
+	EnvironmentTemplateDAO  repository.EnvironmentTemplateDAOInterface
+	EphemeralEnvironmentDAO repository.EphemeralEnvironmentDAOInterface
+	GroupDAO                repository.GroupDAOInterface
+	AuditDAO                repository.AuditDAOInterface
 }
 
 //AppContext AppContext
 type AppContext struct {
-	ConventionInterface core.ConventionInterface
-	DockerServiceAPI    dockerapi.DockerServiceInterface
-	HelmServiceAPI      helmapi.HelmServiceInterface
-	Auditing            audit.AuditingInterface
-	K8sConfigPath       string
-	Configuration       *configs.Configuration
-	Repositories        Repositories
-	Database            dbms.Database
-	Elk                 *elastic.Client
-	Mutex               sync.Mutex
-	ChartImageCache     sync.Map
-	DockerTagsCache     sync.Map
-	ConfigMapCache      sync.Map
-	RabbitMQConn        *amqp.Connection
-	RabbitMQChannel     *amqp.Channel
-	RabbitImpl          rabbitmq.RabbitInterface
-	HelmService         tenkaihelm.HelmAPIInteface
+	KubeConfigProvider core.KubeConfigProviderInterface
+	DockerServiceAPI   dockerapi.DockerServiceInterface
+	HelmServiceAPI     helmapi.HelmServiceInterface
+	Auditing           audit.AuditingInterface
+	K8sConfigPath      string
+	Configuration      *configs.Configuration
+	Repositories       Repositories
+	Database           dbms.Database
+	Elk                *elastic.Client
+	Mutex              sync.Mutex
+	ChartImageCache    sync.Map
+	DockerTagsCache    sync.Map
+	ConfigMapCache     sync.Map
+	RabbitMQConn       *amqp.Connection
+	RabbitMQChannel    *amqp.Channel
+	RabbitImpl         rabbitmq.RabbitInterface
+	HelmService        tenkaihelm.HelmAPIInteface
+	WebHookDispatcher  webhook.DispatcherInterface
+	Mailer             mail.MailerInterface
 }
 
 func defineRotes(r *mux.Router, appContext *AppContext) {
 
 	r.Use(apmgorilla.Middleware())
+	r.Use(appContext.auditRequests)
+	r.Use(appContext.authenticateAPITokens)
 
 	r.HandleFunc("/getVirtualServices", appContext.getVirtualServices).Methods("GET")
 	r.HandleFunc("/install", appContext.install).Methods("POST")
@@ -92,6 +110,8 @@ func defineRotes(r *mux.Router, appContext *AppContext) {
 
 	r.HandleFunc("/variables", appContext.editVariable).Methods("POST")
 	r.HandleFunc("/variables/copy-value", appContext.copyVariableValue).Methods("POST")
+	r.HandleFunc("/variables/resolve", appContext.resolveVariables).Methods("POST")
+	r.HandleFunc("/variables/effective", appContext.getEffectiveVariables).Methods("GET")
 	r.HandleFunc("/variables/{envId}", appContext.getVariables).Methods("GET")
 	r.HandleFunc("/variables/delete/{id}", appContext.deleteVariable).Methods("DELETE")
 	r.HandleFunc("/deletePod", appContext.deletePod).Methods("DELETE")
@@ -104,6 +124,27 @@ func defineRotes(r *mux.Router, appContext *AppContext) {
 	r.HandleFunc("/environments", appContext.getEnvironments).Methods("GET")
 	r.HandleFunc("/environments/all", appContext.getAllEnvironments).Methods("GET")
 	r.HandleFunc("/environments/export/{id}", appContext.export).Methods("GET")
+	r.HandleFunc("/environments/{id}/connectivity", appContext.getEnvironmentConnectivity).Methods("GET")
+	r.HandleFunc("/environments/from-template", appContext.createEnvironmentFromTemplate).Methods("POST")
+	r.HandleFunc("/environments/ephemeral", appContext.listEphemeralEnvironments).Methods("GET")
+	r.HandleFunc("/environments/ephemeral", appContext.createEphemeralEnvironment).Methods("POST")
+	r.HandleFunc("/environments/ephemeral/{id}/extend", appContext.extendEphemeralEnvironment).Methods("POST")
+
+	r.HandleFunc("/environment-templates", appContext.listEnvironmentTemplates).Methods("GET")
+	r.HandleFunc("/environment-templates", appContext.newEnvironmentTemplate).Methods("POST")
+	r.HandleFunc("/environment-templates/edit", appContext.editEnvironmentTemplate).Methods("POST")
+	r.HandleFunc("/environment-templates/{id}", appContext.deleteEnvironmentTemplate).Methods("DELETE")
+
+	r.HandleFunc("/groups", appContext.listGroups).Methods("GET")
+	r.HandleFunc("/groups", appContext.newGroup).Methods("POST")
+	r.HandleFunc("/groups/edit", appContext.editGroup).Methods("POST")
+	r.HandleFunc("/groups/{id}", appContext.deleteGroup).Methods("DELETE")
+	r.HandleFunc("/groups/{id}/variables", appContext.listGroupVariables).Methods("GET")
+	r.HandleFunc("/groups/variables", appContext.saveGroupVariable).Methods("POST")
+	r.HandleFunc("/groups/variables/{id}", appContext.deleteGroupVariable).Methods("DELETE")
+	r.HandleFunc("/groups/{id}/roles", appContext.listGroupRoles).Methods("GET")
+	r.HandleFunc("/groups/roles", appContext.createOrUpdateGroupRole).Methods("POST")
+	r.HandleFunc("/groups/roles/{id}", appContext.deleteGroupRole).Methods("DELETE")
 	r.HandleFunc("/hasConfigMap", appContext.hasConfigMap).Methods("POST")
 
 	r.HandleFunc("/revision", appContext.revision).Methods("POST")
@@ -203,10 +244,37 @@ func defineRotes(r *mux.Router, appContext *AppContext) {
 	r.HandleFunc("/webhooks", appContext.newWebHook).Methods("POST")
 	r.HandleFunc("/webhooks/edit", appContext.editWebHook).Methods("POST")
 	r.HandleFunc("/webhooks/{id}", appContext.deleteWebHook).Methods("DELETE")
+	r.HandleFunc("/webhooks/{id}/deliveries", appContext.listWebHookDeliveries).Methods("GET")
+	r.HandleFunc("/webhooks/deliveries/{id}/redeliver", appContext.redeliverWebHook).Methods("POST")
+	r.HandleFunc("/notificationTemplates", appContext.listNotificationTemplates).Methods("GET")
+	r.HandleFunc("/notificationTemplates", appContext.saveNotificationTemplate).Methods("POST")
+	r.HandleFunc("/notificationTemplates/{id}", appContext.deleteNotificationTemplate).Methods("DELETE")
+
+	r.HandleFunc("/emailSubscriptions", appContext.listEmailSubscriptions).Methods("GET")
+	r.HandleFunc("/emailSubscriptions/{envId}", appContext.subscribeEmail).Methods("POST")
+	r.HandleFunc("/emailSubscriptions/{envId}", appContext.unsubscribeEmail).Methods("DELETE")
+
+	r.HandleFunc("/pipelineTokens", appContext.listPipelineTokens).Methods("GET")
+	r.HandleFunc("/pipelineTokens", appContext.newPipelineToken).Methods("POST")
+	r.HandleFunc("/pipelineTokens/{id}", appContext.deletePipelineToken).Methods("DELETE")
+	r.HandleFunc("/hooks/ci/deploy", appContext.ciDeploy).Methods("POST")
+	r.HandleFunc("/hooks/registry", appContext.registryPush).Methods("POST")
+
+	r.HandleFunc("/serviceAccounts", appContext.listServiceAccounts).Methods("GET")
+	r.HandleFunc("/serviceAccounts", appContext.newServiceAccount).Methods("POST")
+	r.HandleFunc("/serviceAccounts/{id}", appContext.deleteServiceAccount).Methods("DELETE")
+	r.HandleFunc("/serviceAccounts/{id}/tokens", appContext.listServiceAccountTokens).Methods("GET")
+	r.HandleFunc("/serviceAccounts/{id}/tokens", appContext.newServiceAccountToken).Methods("POST")
+	r.HandleFunc("/apiTokens", appContext.listAPITokens).Methods("GET")
+	r.HandleFunc("/apiTokens", appContext.newAPIToken).Methods("POST")
+	r.HandleFunc("/apiTokens/{id}", appContext.revokeAPIToken).Methods("DELETE")
 
 	r.HandleFunc("/requestDeployments", appContext.listRequestDeployments).Methods("GET")
 	r.HandleFunc("/requestDeployments/{id}", appContext.listDeployments).Methods("GET")
 
+	r.HandleFunc("/audit", appContext.listAudits).Methods("GET")
+	r.HandleFunc("/audit/verify", appContext.verifyAuditChain).Methods("GET")
+
 	r.HandleFunc("/health", appContext.healthRabbit).Methods("GET")
 
 	r.HandleFunc("/", appContext.rootHandler)
@@ -223,7 +291,8 @@ func StartHTTPServer(appContext *AppContext) {
 
 	defineRotes(r, appContext)
 
-	log.Fatal(http.ListenAndServe(":"+port, commonHandler(r)))
+	trustedProxies := splitTrustedProxies(appContext.Configuration.Server.TrustedProxies)
+	log.Fatal(http.ListenAndServe(":"+port, commonHandler(r, trustedProxies)))
 
 }
 
@@ -257,6 +326,7 @@ func StartConsumerQueue(appContext *AppContext, queue string) {
 			deployment.Processed = true
 			err = appContext.Repositories.DeploymentDAO.EditDeployment(deployment)
 			checkError(err, functionName)
+			appContext.triggerDeploymentResultWebHook(deployment)
 
 			requestDeploymentID := deployment.RequestDeploymentID
 
@@ -264,17 +334,11 @@ func StartConsumerQueue(appContext *AppContext, queue string) {
 			if finish {
 				requestError, err := appContext.Repositories.RequestDeploymentDAO.HasErrorInRequest(int(requestDeploymentID))
 				fmt.Println(err)
-				if !requestError {
-					rd, _ := appContext.Repositories.RequestDeploymentDAO.GetRequestDeploymentByID(int(requestDeploymentID))
-					rd.Success = true
-					rd.Processed = true
-					appContext.Repositories.RequestDeploymentDAO.EditRequestDeployment(rd)
-				} else {
-					rd, _ := appContext.Repositories.RequestDeploymentDAO.GetRequestDeploymentByID(int(requestDeploymentID))
-					rd.Success = false
-					rd.Processed = true
-					appContext.Repositories.RequestDeploymentDAO.EditRequestDeployment(rd)
-				}
+				rd, _ := appContext.Repositories.RequestDeploymentDAO.GetRequestDeploymentByID(int(requestDeploymentID))
+				rd.Success = !requestError
+				rd.Processed = true
+				appContext.Repositories.RequestDeploymentDAO.EditRequestDeployment(rd)
+				appContext.notifyDeployFinished(rd, deployment.EnvironmentID)
 			}
 
 			global.Logger.Info(global.AppFields{global.Function: functionName}, "Update Deployment on Database")
@@ -311,12 +375,13 @@ func extractToken(reqToken string) *model.Principal {
 	return nil
 }
 
-func commonHandler(next http.Handler) http.Handler {
+func commonHandler(next http.Handler, trustedProxies []string) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			return
@@ -331,13 +396,16 @@ func commonHandler(next http.Handler) http.Handler {
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withRequestInfo(w, r, trustedProxies))
 	})
 }
 
-func (appContext *AppContext) hasAccess(email string, envID int) (bool, error) {
+func (appContext *AppContext) hasAccess(principal model.Principal, envID int) (bool, error) {
+	if principal.Token != nil && !containsEnvironment(principal.Token.Environments, envID) {
+		return false, nil
+	}
 	result := false
-	environments, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments(email)
+	environments, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments(principal.Email)
 	if err != nil {
 		return false, err
 	}
@@ -351,6 +419,10 @@ func (appContext *AppContext) hasAccess(email string, envID int) (bool, error) {
 }
 
 func (appContext *AppContext) hasEnvironmentRole(principal model.Principal, envID uint, role string) (bool, error) {
+	if principal.Token != nil && (!containsEnvironment(principal.Token.Environments, int(envID)) ||
+		!util.Contains(principal.Token.Policies, role)) {
+		return false, nil
+	}
 	var user model.User
 	var err error
 	if user, err = appContext.Repositories.UserDAO.FindByEmail(principal.Email); err != nil {
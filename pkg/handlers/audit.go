@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/audit"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//Page sizes of the audit query
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+const requestIDHeader = "X-Request-ID"
+
+//validRequestID limits the request ids taken from the clients, as they end up in the audit chain
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+const anonymousActor = "anonymous"
+
+//statusRecorder keeps the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(data []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(data)
+}
+
+//auditRequests records an audit event for every request changing something, and for every
+//request denied whatever its method, so no mutating endpoint is left without a trace. A successful
+//request whose handler already recorded its own event, linked to it by the request id, is not
+//recorded twice.
+func (appContext *AppContext) auditRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		outcome := getAuditOutcome(status)
+		if r.Method == http.MethodGet && outcome != model.AuditOutcomeDenied {
+			return
+		}
+		if outcome == model.AuditOutcomeSuccess && audit.IsAudited(r.Context()) {
+			return
+		}
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, requestAuditEvent(r, status, outcome))
+	})
+}
+
+func getAuditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return model.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		return model.AuditOutcomeFailure
+	default:
+		return model.AuditOutcomeSuccess
+	}
+}
+
+//requestAuditEvent builds the audit event of a request, identified by its method and route
+func requestAuditEvent(r *http.Request, status int, outcome string) model.AuditEvent {
+	event := model.AuditEvent{
+		Actor:        util.GetPrincipal(r).Email,
+		Action:       model.AuditActionRequest,
+		ResourceType: "endpoint",
+		ResourceID:   r.Method + " " + r.URL.Path,
+		Outcome:      outcome,
+	}
+	if len(event.Actor) == 0 {
+		event.Actor = anonymousActor
+	}
+	if outcome == model.AuditOutcomeDenied {
+		event.Action = model.AuditActionDenied
+	}
+
+	details := []string{"status=" + strconv.Itoa(status)}
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			event.ResourceID = r.Method + " " + template
+		}
+	}
+	vars := mux.Vars(r)
+	for _, name := range sortedVars(vars) {
+		details = append(details, name+"="+vars[name])
+	}
+	if envID, err := strconv.ParseUint(vars["envId"], 10, 32); err == nil {
+		event.EnvironmentID = uint(envID)
+	}
+	event.After = strings.Join(details, " ")
+	return event
+}
+
+func sortedVars(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (appContext *AppContext) listAudits(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := getAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := &model.AuditEventResult{Page: filter.Page, PageSize: filter.PageSize}
+	if result.List, result.Total, err = appContext.Repositories.AuditDAO.ListAuditEvents(filter); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//verifyAuditChain checks the audit hash chain. A chain whose latest events were removed is still valid,
+//so the lastHash of a previous verification, kept outside of Tenkai, should be passed back in the
+//expectedHash query parameter to detect the truncation.
+func (appContext *AppContext) verifyAuditChain(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result, err := appContext.Repositories.AuditDAO.VerifyAuditChain(r.URL.Query().Get("expectedHash"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//withRequestInfo identifies the request in its context, so the audit events can refer to it.
+//The request id is taken from the X-Request-ID header, or generated when missing or not a short
+//identifier made of letters, digits and the characters ._:-
+func withRequestInfo(w http.ResponseWriter, r *http.Request, trustedProxies []string) *http.Request {
+	requestID := r.Header.Get(requestIDHeader)
+	if !validRequestID.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	w.Header().Set(requestIDHeader, requestID)
+
+	info := audit.RequestInfo{RequestID: requestID, ClientIP: getClientIP(r, trustedProxies)}
+	return r.WithContext(audit.WithRequestInfo(r.Context(), info))
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+//splitTrustedProxies splits the comma separated list of trusted proxies of the configuration
+func splitTrustedProxies(value string) []string {
+	var result []string
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); len(e) > 0 {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+//isTrustedProxy tells whether an address matches one of the trusted proxy addresses or CIDRs
+func isTrustedProxy(address string, trustedProxies []string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, e := range trustedProxies {
+		if _, network, err := net.ParseCIDR(e); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if ip.Equal(net.ParseIP(e)) {
+			return true
+		}
+	}
+	return false
+}
+
+//getClientIP returns the remote address of the request. When it comes from a trusted proxy, the
+//X-Forwarded-For addresses are walked from the nearest one and the first untrusted address is the client,
+//so a client cannot spoof its address by sending the header itself.
+func getClientIP(r *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if len(address) == 0 {
+			continue
+		}
+		host = address
+		if !isTrustedProxy(address, trustedProxies) {
+			break
+		}
+	}
+	return host
+}
+
+//getAuditFilter reads the audit filter from the query parameters actor, action, environmentId,
+//from and to (RFC 3339), page and pageSize.
+func getAuditFilter(query url.Values) (model.AuditEventFilter, error) {
+	filter := model.AuditEventFilter{
+		Actor:    query.Get("actor"),
+		Action:   query.Get("action"),
+		Page:     1,
+		PageSize: DefaultAuditPageSize,
+	}
+
+	var err error
+	if value := query.Get("environmentId"); len(value) > 0 {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, errors.New("environmentId must be a number")
+		}
+		filter.EnvironmentID = uint(id)
+	}
+	if value := query.Get("from"); len(value) > 0 {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("from must be a RFC 3339 time")
+		}
+	}
+	if value := query.Get("to"); len(value) > 0 {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("to must be a RFC 3339 time")
+		}
+	}
+	if value := query.Get("page"); len(value) > 0 {
+		if filter.Page, err = strconv.Atoi(value); err != nil || filter.Page < 1 {
+			return filter, errors.New("page must be a positive number")
+		}
+	}
+	if value := query.Get("pageSize"); len(value) > 0 {
+		if filter.PageSize, err = strconv.Atoi(value); err != nil || filter.PageSize < 1 || filter.PageSize > MaxAuditPageSize {
+			return filter, errors.New("pageSize must be between 1 and " + strconv.Itoa(MaxAuditPageSize))
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/audit"
+	mockAud "github.com/softplan/tenkai-api/pkg/audit/mocks"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListAudits(t *testing.T) {
+	appContext := AppContext{}
+
+	mockAuditDAO := &mockRepo.AuditDAOInterface{}
+	mockAuditDAO.On("ListAuditEvents", mock.MatchedBy(func(filter model.AuditEventFilter) bool {
+		return filter.Actor == "alfa" && filter.Action == "deploy" && filter.EnvironmentID == 999 &&
+			filter.From.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) && filter.Page == 2 && filter.PageSize == DefaultAuditPageSize
+	})).Return([]model.AuditEvent{{ID: 1, Actor: "alfa", Action: "deploy"}}, 51, nil)
+	appContext.Repositories.AuditDAO = mockAuditDAO
+
+	req, err := http.NewRequest("GET", "/audit?actor=alfa&action=deploy&environmentId=999&from=2020-01-01T00:00:00Z&page=2", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listAudits)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"total":51`)
+	assert.Contains(t, rr.Body.String(), `"page":2`)
+	mockAuditDAO.AssertNumberOfCalls(t, "ListAuditEvents", 1)
+}
+
+func TestListAudits_InvalidFilter(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("GET", "/audit?environmentId=dev", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listAudits)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestGetAuditFilter_PageSize(t *testing.T) {
+	_, err := getAuditFilter(map[string][]string{"pageSize": {"1000"}})
+	assert.Error(t, err)
+
+	filter, err := getAuditFilter(map[string][]string{"pageSize": {"20"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, filter.PageSize)
+	assert.Equal(t, 1, filter.Page)
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	appContext := AppContext{}
+
+	mockAuditDAO := &mockRepo.AuditDAOInterface{}
+	mockAuditDAO.On("VerifyAuditChain", "abc").Return(&model.AuditChainVerification{Valid: false, Checked: 3, InvalidEventID: 4}, nil)
+	appContext.Repositories.AuditDAO = mockAuditDAO
+
+	req, err := http.NewRequest("GET", "/audit/verify?expectedHash=abc", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.verifyAuditChain)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"valid":false`)
+	assert.Contains(t, rr.Body.String(), `"invalidEventId":4`)
+}
+
+func TestWithRequestInfo(t *testing.T) {
+	req, err := http.NewRequest("POST", "/groups", nil)
+	assert.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:53000"
+	req.Header.Set(requestIDHeader, "abc")
+
+	rr := httptest.NewRecorder()
+	info := audit.GetRequestInfo(withRequestInfo(rr, req, nil).Context())
+	assert.Equal(t, "abc", info.RequestID)
+	assert.Equal(t, "10.0.0.1", info.ClientIP)
+	assert.Equal(t, "abc", rr.Header().Get(requestIDHeader))
+
+	req.Header.Del(requestIDHeader)
+	req.Header.Set("X-Forwarded-For", "172.16.0.3, 192.168.0.7, 10.0.0.2")
+	info = audit.GetRequestInfo(withRequestInfo(httptest.NewRecorder(), req, []string{"10.0.0.0/24"}).Context())
+	assert.Len(t, info.RequestID, 32)
+	assert.Equal(t, "192.168.0.7", info.ClientIP)
+}
+
+func TestWithRequestInfo_InvalidRequestID(t *testing.T) {
+	for _, requestID := range []string{"abc\nforged=1", "abc def", strings.Repeat("a", 65)} {
+		req, err := http.NewRequest("POST", "/groups", nil)
+		assert.NoError(t, err)
+		req.Header.Set(requestIDHeader, requestID)
+
+		rr := httptest.NewRecorder()
+		info := audit.GetRequestInfo(withRequestInfo(rr, req, nil).Context())
+		assert.Len(t, info.RequestID, 32)
+		assert.Equal(t, info.RequestID, rr.Header().Get(requestIDHeader))
+	}
+}
+
+func TestGetClientIP_UntrustedProxy(t *testing.T) {
+	req, err := http.NewRequest("POST", "/groups", nil)
+	assert.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:53000"
+	req.Header.Set("X-Forwarded-For", "192.168.0.7")
+
+	assert.Equal(t, "10.0.0.1", getClientIP(req, nil))
+	assert.Equal(t, "10.0.0.1", getClientIP(req, []string{"10.0.0.2"}))
+	assert.Equal(t, "192.168.0.7", getClientIP(req, []string{"10.0.0.1"}))
+}
+
+func TestSplitTrustedProxies(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.0/16"}, splitTrustedProxies(" 10.0.0.1, ,10.1.0.0/16"))
+	assert.Nil(t, splitTrustedProxies(""))
+}
+
+func serveAuditedRequest(appContext *AppContext, method string, path string, status int) *httptest.ResponseRecorder {
+	return serveRequest(appContext, method, path, status, false)
+}
+
+func serveRequest(appContext *AppContext, method string, path string, status int, audited bool) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.Use(appContext.auditRequests)
+	r.HandleFunc("/environments/{envId}/rollback", func(w http.ResponseWriter, r *http.Request) {
+		if audited {
+			audit.MarkAudited(r.Context())
+		}
+		w.WriteHeader(status)
+	}).Methods(method)
+
+	req, _ := http.NewRequest(method, path, nil)
+	req = req.WithContext(audit.WithRequestInfo(req.Context(), audit.RequestInfo{RequestID: "abc"}))
+	mockPrincipal(req)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuditRequests(t *testing.T) {
+	appContext := AppContext{}
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: model.AuditActionRequest, EnvironmentID: 999,
+		ResourceType: "endpoint", ResourceID: "POST /environments/{envId}/rollback", After: "status=500 envId=999",
+		Outcome: model.AuditOutcomeFailure})
+
+	rr := serveAuditedRequest(&appContext, "POST", "/environments/999/rollback", http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestAuditRequests_Denied(t *testing.T) {
+	appContext := AppContext{}
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: model.AuditActionDenied, EnvironmentID: 999,
+		ResourceType: "endpoint", ResourceID: "GET /environments/{envId}/rollback", After: "status=401 envId=999",
+		Outcome: model.AuditOutcomeDenied})
+
+	serveAuditedRequest(&appContext, "GET", "/environments/999/rollback", http.StatusUnauthorized)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestAuditRequests_AlreadyAudited(t *testing.T) {
+	appContext := AppContext{}
+	mockAudit := &mockAud.AuditingInterface{}
+	appContext.Auditing = mockAudit
+
+	serveRequest(&appContext, "POST", "/environments/999/rollback", http.StatusOK, true)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 0)
+
+	mockAudit = mockDoAudit(&appContext, model.AuditEvent{Action: model.AuditActionRequest, EnvironmentID: 999,
+		ResourceType: "endpoint", ResourceID: "POST /environments/{envId}/rollback", After: "status=500 envId=999",
+		Outcome: model.AuditOutcomeFailure})
+	serveRequest(&appContext, "POST", "/environments/999/rollback", http.StatusInternalServerError, true)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestAuditRequests_IgnoresReads(t *testing.T) {
+	appContext := AppContext{}
+	mockAudit := &mockAud.AuditingInterface{}
+	appContext.Auditing = mockAudit
+
+	serveAuditedRequest(&appContext, "GET", "/environments/999/rollback", http.StatusOK)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 0)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"k8s.io/helm/pkg/strvals"
+)
+
+const chartSchemaFile = "values.schema.json"
+
+//getChartSchema retrieves the values.schema.json of a chart, returning nil when the chart does not have one.
+func (appContext *AppContext) getChartSchema(chart string, chartVersion string) ([]byte, error) {
+	chart, err := appContext.getValuesChart(chart)
+	if err != nil {
+		return nil, err
+	}
+	return appContext.HelmServiceAPI.GetTemplate(&appContext.Mutex, chart, chartVersion, "schema")
+}
+
+//validateChartSchema merges the helm arguments over the chart values and validates the result
+//against the chart values.schema.json.
+func (appContext *AppContext) validateChartSchema(chart string, chartVersion string,
+	chartValues map[string]interface{}, args []string, stringArgs []string) ([]model.SchemaViolation, error) {
+
+	schema, err := appContext.getChartSchema(chart, chartVersion)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(schema)) == 0 {
+		return []model.SchemaViolation{}, nil
+	}
+
+	values, err := mergeHelmValues(chartValues, args, stringArgs)
+	if err != nil {
+		return nil, err
+	}
+	return validateValuesSchema(chart, schema, values)
+}
+
+//schemaError is a schema problem blocking a deploy, reported to the client as a bad request
+type schemaError struct {
+	message string
+}
+
+func (e schemaError) Error() string {
+	return e.message
+}
+
+//checkChartSchema validates the values of a deploy against the chart schema. Schema problems, including
+//a schema that can not be fetched or parsed, only block the deploy when the environment asks for it.
+func (appContext *AppContext) checkChartSchema(environment *model.Environment, installPayload model.InstallPayload,
+	chartValues map[string]interface{}, args []string, stringArgs []string) error {
+
+	violations, err := appContext.validateChartSchema(installPayload.Chart, installPayload.ChartVersion, chartValues, args, stringArgs)
+	if err != nil {
+		if environment.BlockOnSchemaErrors {
+			return schemaError{message: err.Error()}
+		}
+		log.Println("Skipping schema validation of chart " + installPayload.Chart + ": " + err.Error())
+		return nil
+	}
+	if len(violations) > 0 {
+		if environment.BlockOnSchemaErrors {
+			return schemaError{message: schemaViolationsError(violations).Error()}
+		}
+		log.Println(schemaViolationsError(violations).Error())
+	}
+	return nil
+}
+
+//installErrorStatus returns the status code of a failed install, a bad request for schema problems
+func installErrorStatus(err error, status int) int {
+	if _, ok := err.(schemaError); ok {
+		return http.StatusBadRequest
+	}
+	return status
+}
+
+//mergeHelmValues renders the values helm receives from the chart defaults and the --set/--set-string arguments.
+func mergeHelmValues(chartValues map[string]interface{}, args []string, stringArgs []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	data, err := json.Marshal(chartValues)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	for _, arg := range args {
+		if err := strvals.ParseInto(arg, values); err != nil {
+			return nil, fmt.Errorf("failed parsing --set %s: %s", arg, err.Error())
+		}
+	}
+	for _, arg := range stringArgs {
+		if err := strvals.ParseIntoString(arg, values); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-string %s: %s", arg, err.Error())
+		}
+	}
+	return values, nil
+}
+
+func validateValuesSchema(chart string, schema []byte, values map[string]interface{}) ([]model.SchemaViolation, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(chartSchemaFile, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("invalid %s of chart %s: %s", chartSchemaFile, chart, err.Error())
+	}
+	compiled, err := compiler.Compile(chartSchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s of chart %s: %s", chartSchemaFile, chart, err.Error())
+	}
+
+	document, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]model.SchemaViolation, 0)
+	if err := compiled.Validate(bytes.NewReader(document)); err != nil {
+		validationError, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, err
+		}
+		collectSchemaViolations(chart, validationError, &violations)
+	}
+	return violations, nil
+}
+
+//collectSchemaViolations flattens the validation error tree, keeping only the leaf causes.
+func collectSchemaViolations(chart string, err *jsonschema.ValidationError, violations *[]model.SchemaViolation) {
+	if len(err.Causes) == 0 {
+		path := strings.TrimPrefix(err.InstancePtr, "#")
+		if path == "" {
+			path = "/"
+		}
+		*violations = append(*violations, model.SchemaViolation{
+			Chart:      chart,
+			Path:       path,
+			Message:    err.Message,
+			SchemaPath: strings.TrimPrefix(err.SchemaPtr, "#"),
+		})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectSchemaViolations(chart, cause, violations)
+	}
+}
+
+func schemaViolationsError(violations []model.SchemaViolation) error {
+	messages := make([]string, 0, len(violations))
+	for _, e := range violations {
+		messages = append(messages, e.Path+": "+e.Message)
+	}
+	return fmt.Errorf("values of chart %s do not match %s: %s", violations[0].Chart, chartSchemaFile, strings.Join(messages, "; "))
+}
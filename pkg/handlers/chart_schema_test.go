@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testChartSchema = `{
+	"type": "object",
+	"required": ["app"],
+	"properties": {
+		"app": {
+			"type": "object",
+			"required": ["replicas"],
+			"properties": {
+				"replicas": {"type": "integer", "minimum": 1},
+				"password": {"type": "string", "minLength": 8}
+			}
+		}
+	}
+}`
+
+func mockChartSchema(appContext *AppContext, values string, schema string) *mocks.HelmServiceInterface {
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, "values").Return([]byte(values), nil)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, "schema").Return([]byte(schema), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+	return mockHelmSvc
+}
+
+func TestMergeHelmValues(t *testing.T) {
+	chartValues := map[string]interface{}{"app": map[string]interface{}{"replicas": 1, "name": "foo"}}
+
+	values, err := mergeHelmValues(chartValues, []string{"app.replicas=3"}, []string{"app.port=8080"})
+	assert.NoError(t, err)
+
+	app := values["app"].(map[string]interface{})
+	assert.Equal(t, int64(3), app["replicas"])
+	assert.Equal(t, "8080", app["port"])
+	assert.Equal(t, "foo", app["name"])
+	assert.Equal(t, 1, chartValues["app"].(map[string]interface{})["replicas"])
+}
+
+func TestMergeHelmValues_Error(t *testing.T) {
+	_, err := mergeHelmValues(nil, []string{"app.list[x]=1"}, nil)
+	assert.Error(t, err)
+}
+
+func TestValidateValuesSchema(t *testing.T) {
+	values := map[string]interface{}{"app": map[string]interface{}{"replicas": 0, "password": "short"}}
+
+	violations, err := validateValuesSchema("repo/my-chart", []byte(testChartSchema), values)
+	assert.NoError(t, err)
+	assert.Len(t, violations, 2)
+	for _, e := range violations {
+		assert.Equal(t, "repo/my-chart", e.Chart)
+		assert.NotEmpty(t, e.Message)
+	}
+	paths := []string{violations[0].Path, violations[1].Path}
+	assert.Contains(t, paths, "/app/replicas")
+	assert.Contains(t, paths, "/app/password")
+}
+
+func TestValidateValuesSchema_InvalidSchema(t *testing.T) {
+	_, err := validateValuesSchema("repo/my-chart", []byte(`{"type": 1`), map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestValidateChartSchema_WithoutSchema(t *testing.T) {
+	appContext := AppContext{}
+	mockChartSchema(&appContext, `{}`, "")
+
+	violations, err := appContext.validateChartSchema("repo/my-chart", "0.1.0", nil, []string{"app.replicas=0"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestSimpleInstall_SchemaViolationBlocksDeploy(t *testing.T) {
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockGetAllVariablesByEnvironmentAndScope(&appContext)
+	mockHelmSvc := mockChartSchema(&appContext, `{"app":{"replicas":0}}`, testChartSchema)
+
+	environment := mockGetEnv()
+	environment.BlockOnSchemaErrors = true
+	payload := model.InstallPayload{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"}
+
+	_, err := appContext.simpleInstall(&environment, payload, &bytes.Buffer{}, false, false, "", -1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "/app/replicas")
+	mockHelmSvc.AssertNumberOfCalls(t, "GetTemplate", 2)
+}
+
+func TestSimpleInstall_SchemaViolationHelmCommandOnly(t *testing.T) {
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockGetAllVariablesByEnvironmentAndScope(&appContext)
+	mockKubeConfigProvider(&appContext)
+	mockHelmSvc := mockChartSchema(&appContext, `{"app":{"replicas":0}}`, testChartSchema)
+
+	environment := mockGetEnv()
+	environment.BlockOnSchemaErrors = true
+	payload := model.InstallPayload{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"}
+
+	message, err := appContext.simpleInstall(&environment, payload, &bytes.Buffer{}, false, true, "", -1)
+	assert.NoError(t, err)
+	assert.Contains(t, message, "helm upgrade --install")
+	mockHelmSvc.AssertNumberOfCalls(t, "GetTemplate", 1)
+}
+
+func TestCheckChartSchema_InvalidSchema(t *testing.T) {
+	appContext := AppContext{}
+	mockChartSchema(&appContext, `{}`, `{"type": 1`)
+
+	environment := mockGetEnv()
+	payload := model.InstallPayload{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"}
+
+	err := appContext.checkChartSchema(&environment, payload, nil, nil, nil)
+	assert.NoError(t, err, "schema problems should not block the deploy by default")
+
+	environment.BlockOnSchemaErrors = true
+	err = appContext.checkChartSchema(&environment, payload, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid values.schema.json")
+	assert.Equal(t, http.StatusBadRequest, installErrorStatus(err, 501))
+}
+
+func TestCheckChartSchema_SchemaNotFound(t *testing.T) {
+	appContext := AppContext{}
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, "schema").
+		Return(nil, errors.New("chart not found"))
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	environment := mockGetEnv()
+	payload := model.InstallPayload{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"}
+
+	assert.NoError(t, appContext.checkChartSchema(&environment, payload, nil, nil, nil))
+	assert.Equal(t, 501, installErrorStatus(errors.New("tiller unreachable"), 501))
+}
+
+func TestValidateVariables_SchemaViolations(t *testing.T) {
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockGetByID(&appContext)
+	mockGetAllVariablesByEnvironmentAndScope(&appContext)
+	mockChartSchema(&appContext, `{"app":{"replicas":0}}`, testChartSchema)
+
+	mockVariableRuleDAO := &mockRepo.VariableRuleDAOInterface{}
+	mockVariableRuleDAO.On("ListVariableRules").Return([]model.VariableRule{}, nil)
+	appContext.Repositories.VariableRuleDAO = mockVariableRuleDAO
+
+	payload, _ := json.Marshal(map[string]interface{}{"environmentId": 999, "scope": "bar", "chartVersion": "0.1.0"})
+	req, err := http.NewRequest("POST", "/validateVariables", bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.validateVariables)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok.")
+
+	var result model.InvalidVariablesResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result.SchemaViolations, 1)
+	assert.Equal(t, "/app/replicas", result.SchemaViolations[0].Path)
+	assert.Equal(t, "bar", result.SchemaViolations[0].Chart)
+}
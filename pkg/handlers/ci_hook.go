@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//authenticatePipeline returns the pipeline token of the request, nil when the token is missing, unknown
+//or lacks the given scope
+func (appContext *AppContext) authenticatePipeline(r *http.Request, scope string) (*model.PipelineToken, error) {
+	token := bearerToken(r)
+	if !strings.HasPrefix(token, pipelineTokenPrefix) {
+		return nil, nil
+	}
+	pipeline, err := appContext.Repositories.PipelineTokenDAO.FindPipelineTokenByHash(hashToken(token))
+	if err != nil || pipeline == nil || !pipelineHasScope(*pipeline, scope) {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+//pipelineHasScope tells whether a pipeline token has a scope, tokens without scopes may only deploy
+func pipelineHasScope(pipeline model.PipelineToken, scope string) bool {
+	if len(pipeline.Scopes) == 0 {
+		return scope == model.PipelineScopeDeploy
+	}
+	return util.Contains(pipeline.Scopes, scope)
+}
+
+func containsEnvironment(environments []int64, environmentID int) bool {
+	for _, e := range environments {
+		if e == int64(environmentID) {
+			return true
+		}
+	}
+	return false
+}
+
+//ciImageTagVariable returns the image tag variable of the chart with the pushed tag, keeping the type of the
+//stored variable or inferring it from the chart default like saveVariableValues
+func (appContext *AppContext) ciImageTagVariable(payload model.CIDeployPayload) (model.Variable, error) {
+	variable := model.Variable{
+		EnvironmentID: payload.EnvironmentID,
+		Scope:         payload.Chart,
+		Name:          imageTagVariable,
+		Value:         payload.Tag,
+	}
+
+	stored, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(payload.EnvironmentID, payload.Chart)
+	if err != nil {
+		return variable, err
+	}
+	for _, e := range stored {
+		if e.Name == imageTagVariable {
+			variable.Type = e.Type
+			return variable, nil
+		}
+	}
+
+	//The image tag is not an app variable, so its default is looked up in the whole chart values
+	values, err := appContext.getHelmChartValues(payload.Chart, payload.ChartVersion)
+	if err != nil {
+		return variable, err
+	}
+	if defaultValue, ok := lookupChartValue(values, imageTagVariable); ok {
+		variable.Type = inferVariableType(defaultValue)
+	}
+	return variable, nil
+}
+
+//ciDeploy updates the image tag of a chart in an environment and queues its install on behalf
+//of a CI pipeline, authenticated by its pipeline token instead of a user JWT
+func (appContext *AppContext) ciDeploy(w http.ResponseWriter, r *http.Request) {
+
+	pipeline, err := appContext.authenticatePipeline(r, model.PipelineScopeDeploy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pipeline == nil {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.CIDeployPayload
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.EnvironmentID == 0 || len(payload.Chart) == 0 || len(payload.Tag) == 0 {
+		http.Error(w, "environmentId, chart and tag are required", http.StatusBadRequest)
+		return
+	}
+	if !containsEnvironment(pipeline.Environments, payload.EnvironmentID) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.EnvironmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	principal := model.Principal{Name: pipeline.Name, Email: "pipeline:" + pipeline.Name}
+
+	name := payload.Name
+	if len(name) == 0 {
+		name = payload.Chart[strings.LastIndex(payload.Chart, "/")+1:]
+	}
+	deployables, err := appContext.loadConfigMap([]model.InstallPayload{{
+		EnvironmentID: payload.EnvironmentID,
+		Chart:         payload.Chart,
+		ChartVersion:  payload.ChartVersion,
+		Name:          name,
+	}}, payload.EnvironmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !appContext.checkDeployGate(w, r, principal, environment, deployables, false) {
+		return
+	}
+
+	//Like a user without the ACTION_SAVE_VARIABLES role, the pipeline only changes the image tag
+	variable, err := appContext.ciImageTagVariable(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := validateVariableValue(variable); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	auditValues, updated, err := appContext.Repositories.VariableDAO.CreateVariable(variable)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	appContext.audit(updated, auditValues, environment, principal, r)
+
+	//The request belongs to the user who created the pipeline token, who is told of the deploy result
+	requestDeployment := model.RequestDeployment{}
+	if len(pipeline.CreatedBy) > 0 {
+		owner, err := appContext.Repositories.UserDAO.FindByEmail(pipeline.CreatedBy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		requestDeployment.UserID = owner.ID
+	}
+	requestDeploymentID, err := appContext.Repositories.RequestDeploymentDAO.CreateRequestDeployment(requestDeployment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out := &bytes.Buffer{}
+	for _, deployable := range deployables {
+		if _, err := appContext.simpleInstall(environment, deployable, out, false, false, principal.Email, requestDeploymentID); err != nil {
+			http.Error(w, err.Error(), installErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, deployAuditEvent(principal, environment, deployable))
+	}
+
+	data, _ := json.Marshal(model.CIDeployResponse{RequestDeploymentID: requestDeploymentID})
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+}
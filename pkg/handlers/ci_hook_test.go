@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockAud "github.com/softplan/tenkai-api/pkg/audit/mocks"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testPipelineToken = "tkp_0123456789"
+
+func mockPipelineToken(appContext *AppContext) *mockRepo.PipelineTokenDAOInterface {
+	token := model.PipelineToken{Name: "my-pipeline", Environments: []int64{999}, CreatedBy: "beta@alfa.com"}
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("FindPipelineTokenByHash", hashToken(testPipelineToken)).Return(&token, nil)
+	mockPipelineTokenDAO.On("FindPipelineTokenByHash", mock.Anything).Return(nil, nil)
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+	return mockPipelineTokenDAO
+}
+
+func getCIDeployPayload() *bytes.Buffer {
+	payload := model.CIDeployPayload{EnvironmentID: 999, Chart: "repo/foo", ChartVersion: "0.1.0", Tag: "1.2.3"}
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestCIDeploy(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", getCIDeployPayload())
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testPipelineToken)
+
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockConfiguration(&appContext)
+	mockPipelineToken(&appContext)
+
+	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
+	mockConfigDAO.On("GetConfigByName", "commonValuesConfigMapChart").Return(model.ConfigMap{}, nil)
+	appContext.Repositories.ConfigDAO = mockConfigDAO
+
+	mockDeploymentDAO := &mockRepo.DeploymentDAOInterface{}
+	mockDeploymentDAO.On("CreateDeployment", mock.Anything).Return(1, nil)
+	appContext.Repositories.DeploymentDAO = mockDeploymentDAO
+
+	mockRequestDeploymentDAO := &mockRepo.RequestDeploymentDAOInterface{}
+	mockRequestDeploymentDAO.On("CreateRequestDeployment", model.RequestDeployment{UserID: 5}).Return(10, nil)
+	appContext.Repositories.RequestDeploymentDAO = mockRequestDeploymentDAO
+
+	mockUserDAO := &mockRepo.UserDAOInterface{}
+	owner := model.User{Email: "beta@alfa.com"}
+	owner.ID = 5
+	mockUserDAO.On("FindByEmail", "beta@alfa.com").Return(owner, nil)
+	appContext.Repositories.UserDAO = mockUserDAO
+
+	mockGetByID(&appContext)
+	mockVariableDAO := mockGetAllVariablesByEnvironmentAndScope(&appContext)
+	mockVariableDAO.On("CreateVariable", model.Variable{
+		EnvironmentID: 999,
+		Scope:         "repo/foo",
+		Name:          "image.tag",
+		Value:         "1.2.3",
+		Type:          model.VariableTypeString,
+	}).Return(map[string]string{"scope": "repo/foo", "variable_name": "image.tag"}, true, nil)
+	mockKubeConfigProvider(&appContext)
+	mockHelmSvc := mockUpgrade(&appContext)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(`{"app":{"myvar":"myvalue"},"image":{"tag":"1.0.0"}}`), nil)
+
+	mockAudit := &mockAud.AuditingInterface{}
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, mock.Anything)
+	appContext.Auditing = mockAudit
+
+	mockRabbitMQ := getMockRabbitMQ()
+	appContext.RabbitImpl = mockRabbitMQ
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code, "Response should be Accepted.")
+	assert.Equal(t, `{"requestDeploymentId":10}`, rr.Body.String())
+	mockVariableDAO.AssertNumberOfCalls(t, "CreateVariable", 1)
+	mockRabbitMQ.AssertNumberOfCalls(t, "Publish", 1)
+	mockRequestDeploymentDAO.AssertNumberOfCalls(t, "CreateRequestDeployment", 1)
+	mockAudit.AssertCalled(t, "DoAudit", mock.Anything, mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+		return e.Action == "deploy" && e.Actor == "pipeline:my-pipeline"
+	}))
+}
+
+func TestCIDeploy_InvalidToken(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", getCIDeployPayload())
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer tkp_unknown")
+
+	appContext := AppContext{}
+	mockPipelineToken(&appContext)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestCIDeploy_MissingToken(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", getCIDeployPayload())
+	assert.NoError(t, err)
+
+	appContext := AppContext{}
+	mockPipelineTokenDAO := mockPipelineToken(&appContext)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	mockPipelineTokenDAO.AssertNumberOfCalls(t, "FindPipelineTokenByHash", 0)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestCIDeploy_EnvironmentNotAllowed(t *testing.T) {
+	payload := model.CIDeployPayload{EnvironmentID: 1000, Chart: "repo/foo", Tag: "1.2.3"}
+	data, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testPipelineToken)
+
+	appContext := AppContext{}
+	mockPipelineToken(&appContext)
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	mockVariableDAO.AssertNumberOfCalls(t, "CreateVariable", 0)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestCIDeploy_MissingTag(t *testing.T) {
+	payload := model.CIDeployPayload{EnvironmentID: 999, Chart: "repo/foo"}
+	data, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testPipelineToken)
+
+	appContext := AppContext{}
+	mockPipelineToken(&appContext)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestCIDeploy_KeepsStoredType(t *testing.T) {
+	appContext := AppContext{}
+	stored := model.Variable{EnvironmentID: 999, Scope: "repo/foo", Name: "image.tag", Value: "1.2.2"}
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/foo").Return([]model.Variable{stored}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	payload := model.CIDeployPayload{EnvironmentID: 999, Chart: "repo/foo", ChartVersion: "0.1.0", Tag: "1.2.3"}
+	variable, err := appContext.ciImageTagVariable(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "", variable.Type)
+	assert.Equal(t, "1.2.3", variable.Value)
+}
+
+func TestCIDeploy_DeployGateKeepsTag(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/ci/deploy", getCIDeployPayload())
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testPipelineToken)
+
+	appContext := AppContext{}
+	mockPipelineToken(&appContext)
+	mockVariableDAO, environment := mockDeployGate(&appContext, "")
+
+	mockEnvDAO := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDAO.On("GetByID", 999).Return(environment, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDAO
+
+	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
+	mockConfigDAO.On("GetConfigByName", "commonValuesConfigMapChart").Return(model.ConfigMap{}, nil)
+	appContext.Repositories.ConfigDAO = mockConfigDAO
+	mockKubeConfigProvider(&appContext)
+	mockHelmSvc := mockUpgrade(&appContext)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(`{"app":{"myvar":"myvalue"}}`), nil)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.ciDeploy)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response should be 422.")
+	mockVariableDAO.AssertNumberOfCalls(t, "CreateVariable", 0)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//getVariableScope returns the scope holding the variables of a deployable.
+func getVariableScope(installPayload model.InstallPayload) string {
+	if strings.Index(installPayload.Name, "gcm") > -1 {
+		return installPayload.Name
+	}
+	return installPayload.Chart
+}
+
+//getOverrideVariables returns the per-deploy overrides of the deployables as variables of their scope.
+func getOverrideVariables(environment *model.Environment, deployables []model.InstallPayload) []model.Variable {
+	var result []model.Variable
+	for _, deployable := range deployables {
+		names := make([]string, 0, len(deployable.Overrides))
+		for name := range deployable.Overrides {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result = append(result, model.Variable{
+				EnvironmentID: int(environment.ID),
+				Scope:         getVariableScope(deployable),
+				Name:          name,
+				Value:         deployable.Overrides[name],
+			})
+		}
+	}
+	return result
+}
+
+//applyOverrides replaces the value of the stored variables overridden on deploy, adding the new ones.
+func applyOverrides(variables []model.Variable, overrides []model.Variable) []model.Variable {
+	for _, o := range overrides {
+		found := false
+		for i := range variables {
+			if variables[i].Scope == o.Scope && variables[i].Name == o.Name {
+				variables[i].Value = o.Value
+				found = true
+			}
+		}
+		if !found {
+			variables = append(variables, o)
+		}
+	}
+	return variables
+}
+
+//checkDeployOverrides gives per-deploy overrides the protections of saved variables: only admins and
+//holders of ACTION_SAVE_VARIABLES may set them and their values must match the type of the variable.
+//It writes the response and returns false when the deploy must not proceed.
+func (appContext *AppContext) checkDeployOverrides(w http.ResponseWriter, principal model.Principal,
+	environment *model.Environment, overrides []model.Variable) bool {
+
+	if len(overrides) == 0 {
+		return true
+	}
+
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		has, err := appContext.hasEnvironmentRole(principal, environment.ID, "ACTION_SAVE_VARIABLES")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
+		if !has {
+			http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+			return false
+		}
+	}
+
+	stored := make(map[string][]model.Variable)
+	for _, o := range overrides {
+		vars, ok := stored[o.Scope]
+		if !ok {
+			var err error
+			vars, err = appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), o.Scope)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return false
+			}
+			stored[o.Scope] = vars
+		}
+		for _, v := range vars {
+			if v.Name == o.Name {
+				o.Type = v.Type
+			}
+		}
+		if err := validateVariableValue(o); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+//getDeployScopes returns the variable scopes of the deployables
+func getDeployScopes(deployables []model.InstallPayload) []string {
+	var scopes []string
+	for _, deployable := range deployables {
+		scopes = append(scopes, getVariableScope(deployable))
+	}
+	return scopes
+}
+
+//validateDeployGate validates the variables of the deployables and the global scope of an environment,
+//with the overrides of the deploy applied, returning only the violations of error severity.
+func (appContext *AppContext) validateDeployGate(environment *model.Environment,
+	deployables []model.InstallPayload) (*model.InvalidVariablesResult, error) {
+
+	var variables []model.Variable
+	visited := make(map[string]bool)
+	for _, scope := range append([]string{globalScope}, getDeployScopes(deployables)...) {
+		if visited[scope] {
+			continue
+		}
+		visited[scope] = true
+		vars, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), scope)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, vars...)
+	}
+	variables = applyOverrides(variables, getOverrideVariables(environment, deployables))
+
+	vrs, err := appContext.Repositories.VariableRuleDAO.ListVariableRules()
+	if err != nil {
+		return nil, err
+	}
+
+	if vrs, err = appContext.filterVariableRules(int(environment.ID), vrs); err != nil {
+		return nil, err
+	}
+
+	ivr, err := appContext.validate(variables, vrs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.InvalidVariablesResult{InvalidVariables: []model.InvalidVariable{}}
+	for _, e := range ivr.InvalidVariables {
+		if e.Severity != model.SeverityWarning {
+			result.InvalidVariables = append(result.InvalidVariables, e)
+		}
+	}
+	return result, nil
+}
+
+//overrideValidation tells whether the deploy request asks to override the deploy gate,
+//through the overrideValidation query parameter of install, multipleInstall and promote
+func overrideValidation(r *http.Request) bool {
+	return r.URL.Query().Get("overrideValidation") == "true"
+}
+
+//checkDeployGate checks the overrides of the deployables and enforces variable validation on environments
+//where it is enabled. It writes the response and returns false when the deploy must not proceed.
+//Admins may override the gate, which is audited.
+func (appContext *AppContext) checkDeployGate(w http.ResponseWriter, r *http.Request, principal model.Principal,
+	environment *model.Environment, deployables []model.InstallPayload, override bool) bool {
+
+	if !appContext.checkDeployOverrides(w, principal, environment, getOverrideVariables(environment, deployables)) {
+		return false
+	}
+
+	if !environment.BlockOnInvalidVariables {
+		return true
+	}
+
+	result, err := appContext.validateDeployGate(environment, deployables)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	if len(result.InvalidVariables) == 0 {
+		return true
+	}
+
+	if override && util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+			Actor:         principal.Email,
+			Action:        "overrideVariableValidation",
+			EnvironmentID: environment.ID,
+			ResourceType:  "scope",
+			ResourceID:    strings.Join(getDeployScopes(deployables), ","),
+			After:         "invalidVariables=" + strconv.Itoa(len(result.InvalidVariables)),
+		})
+		return true
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(data)
+	return false
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockAud "github.com/softplan/tenkai-api/pkg/audit/mocks"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockDeployGate(appContext *AppContext, severity string) (*mockRepo.VariableDAOInterface, *model.Environment) {
+	environment := mockGetEnv()
+	environment.BlockOnInvalidVariables = true
+
+	invalid := getVar("dbUsername", "")
+	invalid.Scope = "repo/foo"
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{getVar("username", "user")}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/foo").Return([]model.Variable{invalid}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	rule := getVarRule("dbUsername", "NotEmpty", "")
+	rule.ValueRules[0].Severity = severity
+	mockVariableRuleDAO := &mockRepo.VariableRuleDAOInterface{}
+	mockVariableRuleDAO.On("ListVariableRules").Return([]model.VariableRule{rule}, nil)
+	appContext.Repositories.VariableRuleDAO = mockVariableRuleDAO
+
+	return mockVariableDAO, &environment
+}
+
+func getGatePrincipal(roles ...string) model.Principal {
+	return model.Principal{Name: "alfa", Email: "beta@alfa.com", Roles: roles}
+}
+
+func TestCheckDeployGate_Disabled(t *testing.T) {
+	appContext := AppContext{}
+	mockVariableDAO, environment := mockDeployGate(&appContext, "")
+	environment.BlockOnInvalidVariables = false
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	assert.True(t, appContext.checkDeployGate(rr, req, getGatePrincipal(), environment, []model.InstallPayload{{Chart: "repo/foo"}}, false))
+	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 0)
+}
+
+func TestCheckDeployGate_Blocks(t *testing.T) {
+	appContext := AppContext{}
+	mockVariableDAO, environment := mockDeployGate(&appContext, "")
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	assert.False(t, appContext.checkDeployGate(rr, req, getGatePrincipal(), environment, []model.InstallPayload{{Chart: "repo/foo"}, {Chart: "repo/foo"}}, true))
+	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response should be 422.")
+
+	var result model.InvalidVariablesResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result.InvalidVariables, 1)
+	assert.Equal(t, "repo/foo", result.InvalidVariables[0].Scope)
+}
+
+func TestCheckDeployGate_WarningsDoNotBlock(t *testing.T) {
+	appContext := AppContext{}
+	_, environment := mockDeployGate(&appContext, model.SeverityWarning)
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	assert.True(t, appContext.checkDeployGate(rr, req, getGatePrincipal(), environment, []model.InstallPayload{{Chart: "repo/foo"}}, false))
+}
+
+func TestCheckDeployGate_AdminOverride(t *testing.T) {
+	appContext := AppContext{}
+	_, environment := mockDeployGate(&appContext, "")
+
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "overrideVariableValidation", EnvironmentID: 999,
+		ResourceType: "scope", ResourceID: "repo/foo", After: "invalidVariables=1"})
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	principal := getGatePrincipal("tenkai-admin")
+	assert.True(t, appContext.checkDeployGate(rr, req, principal, environment, []model.InstallPayload{{Chart: "repo/foo"}}, true))
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestOverrideValidation(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/install?overrideValidation=true", nil)
+	assert.True(t, overrideValidation(req))
+
+	req, _ = http.NewRequest("GET", "/promote?mode=full&srcEnvID=1&targetEnvID=2", nil)
+	assert.False(t, overrideValidation(req))
+}
+
+func TestInstall_DeployGate(t *testing.T) {
+	req, err := http.NewRequest("POST", "/install", getInstallPayload())
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	appContext := AppContext{}
+	mockVariableDAO, environment := mockDeployGate(&appContext, "")
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "foo").Return([]model.Variable{getVar("dbUsername", "")}, nil)
+
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("GetByID", 999).Return(environment, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
+	mockConfigDAO.On("GetConfigByName", "commonValuesConfigMapChart").Return(model.ConfigMap{Value: "myvalue"}, nil)
+	appContext.Repositories.ConfigDAO = mockConfigDAO
+
+	mockHelmSvc := mockUpgrade(&appContext)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(""), nil)
+
+	mockRequestDeploymentDAO := &mockRepo.RequestDeploymentDAOInterface{}
+	appContext.Repositories.RequestDeploymentDAO = mockRequestDeploymentDAO
+	appContext.Auditing = &mockAud.AuditingInterface{}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.install)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response should be 422.")
+	assert.Contains(t, rr.Body.String(), `"name":"dbUsername"`)
+	mockRequestDeploymentDAO.AssertNumberOfCalls(t, "CreateRequestDeployment", 0)
+}
+
+func TestDeployCharts_DeployGate(t *testing.T) {
+	appContext := AppContext{}
+	_, environment := mockDeployGate(&appContext, "")
+
+	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
+	mockConfigDAO.On("GetConfigByName", "commonValuesConfigMapChart").Return(model.ConfigMap{}, nil)
+	appContext.Repositories.ConfigDAO = mockConfigDAO
+
+	mockHelmSvc := mockUpgrade(&appContext)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(""), nil)
+
+	mockRequestDeploymentDAO := &mockRepo.RequestDeploymentDAOInterface{}
+	appContext.Repositories.RequestDeploymentDAO = mockRequestDeploymentDAO
+
+	req, _ := http.NewRequest("POST", "/environments/ephemeral", nil)
+	rr := httptest.NewRecorder()
+
+	deployables := []model.InstallPayload{{EnvironmentID: 999, Chart: "repo/foo", Name: "foo"}}
+	assert.False(t, appContext.deployCharts(rr, req, getGatePrincipal(), environment, 0, deployables))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response should be 422.")
+	mockRequestDeploymentDAO.AssertNumberOfCalls(t, "CreateRequestDeployment", 0)
+}
+
+func TestCheckDeployGate_OverridesNeedSaveVariables(t *testing.T) {
+	appContext := AppContext{}
+	mockVariableDAO, environment := mockDeployGate(&appContext, "")
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	principal := getGatePrincipal()
+	principal.Token = &model.TokenScope{Environments: []int64{999}, Policies: []string{"ACTION_DEPLOY"}}
+	deployables := []model.InstallPayload{{Chart: "repo/foo", Overrides: map[string]string{"dbUsername": "admin"}}}
+
+	assert.False(t, appContext.checkDeployGate(rr, req, principal, environment, deployables, false))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 0)
+}
+
+func TestCheckDeployGate_OverridesKeepVariableType(t *testing.T) {
+	appContext := AppContext{}
+	typed := getVar("replicas", "1")
+	typed.Scope = "repo/foo"
+	typed.Type = model.VariableTypeInt
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/foo").Return([]model.Variable{typed}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+	environment := mockGetEnv()
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	deployables := []model.InstallPayload{{Chart: "repo/foo", Overrides: map[string]string{"replicas": "many"}}}
+	assert.False(t, appContext.checkDeployGate(rr, req, getGatePrincipal("tenkai-admin"), &environment, deployables, false))
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestCheckDeployGate_OverridesAreValidated(t *testing.T) {
+	appContext := AppContext{}
+	_, environment := mockDeployGate(&appContext, "")
+
+	req, _ := http.NewRequest("POST", "/install", nil)
+	rr := httptest.NewRecorder()
+
+	principal := getGatePrincipal("tenkai-admin")
+	fixed := []model.InstallPayload{{Chart: "repo/foo", Overrides: map[string]string{"dbUsername": "admin"}}}
+	assert.True(t, appContext.checkDeployGate(rr, req, principal, environment, fixed, false))
+
+	valid := getVar("dbUsername", "user")
+	valid.Scope = "repo/foo"
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/foo").Return([]model.Variable{valid}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	broken := []model.InstallPayload{{Chart: "repo/foo", Overrides: map[string]string{"dbUsername": ""}}}
+	assert.False(t, appContext.checkDeployGate(rr, req, principal, environment, broken, false))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Response should be 422.")
+}
+
+func TestDeployAuditEvent_Overrides(t *testing.T) {
+	environment := mockGetEnv()
+	deployable := model.InstallPayload{Name: "foo-dev", Chart: "repo/foo", ChartVersion: "0.1.0",
+		Overrides: map[string]string{"replicas": "2", "image.tag": "1.0.0"}}
+
+	event := deployAuditEvent(getGatePrincipal(), &environment, deployable)
+	assert.Equal(t, "chart=repo/foo version=0.1.0 overrides=image.tag=1.0.0,replicas=2", event.After)
+}
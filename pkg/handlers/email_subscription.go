@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/mail"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//emailDeployFinished emails the result of a deploy request to the user who requested it,
+//when the user opted in to the emails of the environment
+func (appContext *AppContext) emailDeployFinished(userID uint, summary model.WebHookDeployFinishedPayload,
+	deployments []model.Deployments) {
+
+	if appContext.Mailer == nil || len(summary.User) == 0 {
+		return
+	}
+
+	subscribed, err := appContext.Repositories.EmailSubscriptionDAO.IsSubscribed(int(userID), int(summary.EnvironmentID))
+	if err != nil {
+		log.Println("Error trying to check the email subscription of", summary.User, err)
+		return
+	}
+	if !subscribed {
+		return
+	}
+
+	subject, body := mail.DeployFinishedMessage(summary, deployments)
+	if err := appContext.Mailer.Send([]string{summary.User}, subject, body); err != nil {
+		log.Println("Error trying to email the result of request", summary.RequestDeploymentID, err)
+	}
+}
+
+func (appContext *AppContext) listEmailSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := &model.EmailSubscriptionResponse{}
+	if result.List, err = appContext.Repositories.EmailSubscriptionDAO.ListEmailSubscriptions(int(user.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) subscribeEmail(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	envID, err := strconv.Atoi(mux.Vars(r)["envId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	has, err := appContext.hasAccess(principal, envID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !has {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	subscription := model.EmailSubscription{UserID: user.ID, EnvironmentID: uint(envID)}
+	if err := appContext.Repositories.EmailSubscriptionDAO.CreateEmailSubscription(subscription); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) unsubscribeEmail(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	envID, err := strconv.Atoi(mux.Vars(r)["envId"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := appContext.Repositories.EmailSubscriptionDAO.DeleteEmailSubscription(int(user.ID), envID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	mockMail "github.com/softplan/tenkai-api/pkg/mail/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockFindUserByEmail(appContext *AppContext) *mockRepo.UserDAOInterface {
+	user := model.User{Email: "beta@alfa.com"}
+	user.ID = 5
+	mockUserDAO := &mockRepo.UserDAOInterface{}
+	mockUserDAO.On("FindByEmail", "beta@alfa.com").Return(user, nil)
+	appContext.Repositories.UserDAO = mockUserDAO
+	return mockUserDAO
+}
+
+func getDeployFinishedSummary() model.WebHookDeployFinishedPayload {
+	return model.WebHookDeployFinishedPayload{
+		Event:               model.HookDeployFinished,
+		Environment:         "bar",
+		EnvironmentID:       999,
+		RequestDeploymentID: 10,
+		User:                "beta@alfa.com",
+	}
+}
+
+func TestListEmailSubscriptions(t *testing.T) {
+	appContext := AppContext{}
+	mockFindUserByEmail(&appContext)
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	mockSubscriptionDAO.On("ListEmailSubscriptions", 5).
+		Return([]model.EmailSubscription{{UserID: 5, EnvironmentID: 999}}, nil)
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	req, err := http.NewRequest("GET", "/emailSubscriptions", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listEmailSubscriptions)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"environmentId":999`)
+}
+
+func TestSubscribeEmail(t *testing.T) {
+	appContext := AppContext{}
+	mockFindUserByEmail(&appContext)
+
+	mockEnvDAO := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDAO.On("GetAllEnvironments", "beta@alfa.com").Return([]model.Environment{mockGetEnv()}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDAO
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	mockSubscriptionDAO.On("CreateEmailSubscription", model.EmailSubscription{UserID: 5, EnvironmentID: 999}).Return(nil)
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	req, err := http.NewRequest("POST", "/emailSubscriptions/999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/emailSubscriptions/{envId}", appContext.subscribeEmail).Methods("POST")
+	r.ServeHTTP(rr, req)
+
+	mockSubscriptionDAO.AssertNumberOfCalls(t, "CreateEmailSubscription", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+}
+
+func TestSubscribeEmail_AccessDenied(t *testing.T) {
+	appContext := AppContext{}
+
+	mockEnvDAO := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDAO.On("GetAllEnvironments", "beta@alfa.com").Return([]model.Environment{}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDAO
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	req, err := http.NewRequest("POST", "/emailSubscriptions/999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/emailSubscriptions/{envId}", appContext.subscribeEmail).Methods("POST")
+	r.ServeHTTP(rr, req)
+
+	mockSubscriptionDAO.AssertNumberOfCalls(t, "CreateEmailSubscription", 0)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestUnsubscribeEmail(t *testing.T) {
+	appContext := AppContext{}
+	mockFindUserByEmail(&appContext)
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	mockSubscriptionDAO.On("DeleteEmailSubscription", 5, 999).Return(nil)
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	req, err := http.NewRequest("DELETE", "/emailSubscriptions/999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/emailSubscriptions/{envId}", appContext.unsubscribeEmail).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	mockSubscriptionDAO.AssertNumberOfCalls(t, "DeleteEmailSubscription", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+}
+
+func TestEmailDeployFinished(t *testing.T) {
+	appContext := AppContext{}
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	mockSubscriptionDAO.On("IsSubscribed", 5, 999).Return(true, nil)
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	mockMailer := &mockMail.MailerInterface{}
+	mockMailer.On("Send", []string{"beta@alfa.com"}, "[Tenkai] Deploy failed in bar", mock.Anything).
+		Return(errors.New("some error"))
+	appContext.Mailer = mockMailer
+
+	deployments := []model.Deployments{{Chart: "repo/beta", Message: "timeout"}}
+	appContext.emailDeployFinished(5, getDeployFinishedSummary(), deployments)
+
+	mockMailer.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestEmailDeployFinished_NotSubscribed(t *testing.T) {
+	appContext := AppContext{}
+
+	mockSubscriptionDAO := &mockRepo.EmailSubscriptionDAOInterface{}
+	mockSubscriptionDAO.On("IsSubscribed", 5, 999).Return(false, nil)
+	appContext.Repositories.EmailSubscriptionDAO = mockSubscriptionDAO
+
+	mockMailer := &mockMail.MailerInterface{}
+	appContext.Mailer = mockMailer
+
+	appContext.emailDeployFinished(5, getDeployFinishedSummary(), nil)
+
+	mockMailer.AssertNumberOfCalls(t, "Send", 0)
+}
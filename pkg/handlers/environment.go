@@ -2,18 +2,16 @@ package handlers
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
 
 	"github.com/softplan/tenkai-api/pkg/constraints"
 	"github.com/softplan/tenkai-api/pkg/global"
 
+	"github.com/ghodss/yaml"
 	"github.com/gorilla/mux"
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	"github.com/softplan/tenkai-api/pkg/util"
@@ -44,17 +42,31 @@ func (appContext *AppContext) deleteEnvironment(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if purge, _ := strconv.ParseBool(r.URL.Query().Get("purge")); purge {
+		releases, err := appContext.purgeEnvironment(env)
+		if err != nil {
+			log.Println("Error purging environment: ", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+			Actor:         principal.Email,
+			Action:        "deleteNamespace",
+			EnvironmentID: env.ID,
+			ResourceType:  "namespace",
+			ResourceID:    env.Namespace,
+			After:         "releases=" + strconv.Itoa(releases),
+		})
+	}
+
 	if err := appContext.Repositories.EnvironmentDAO.DeleteEnvironment(*env); err != nil {
 		log.Println("Error deleting environment: ", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := removeEnvironmentFile(env.Group + "_" + env.Name); err != nil {
-		log.Println("Error deleting environment file: ", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	appContext.KubeConfigProvider.Invalidate(env.ID)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -82,17 +94,17 @@ func (appContext *AppContext) editEnvironment(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	oldFile := result.Group + "_" + result.Name
-	removeEnvironmentFile(oldFile)
-
-	createEnvironmentFile(env.Name, env.Token, appContext.K8sConfigPath+env.Group+"_"+env.Name,
-		env.CACertificate, env.ClusterURI, env.Namespace)
+	keepStoredCredentials(&env, result)
+	appContext.DecryptEnvironmentCredentials(&env)
+	appContext.encryptEnvironmentCredentials(&env)
 
 	if err := appContext.Repositories.EnvironmentDAO.EditEnvironment(env); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	appContext.KubeConfigProvider.Invalidate(env.ID)
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -134,9 +146,8 @@ func (appContext *AppContext) duplicateEnvironments(w http.ResponseWriter, r *ht
 	env.Token = environment.Token
 	env.ClusterURI = environment.ClusterURI
 	env.Gateway = environment.Gateway
-
-	createEnvironmentFile(env.Name, env.Token, appContext.K8sConfigPath+env.Group+"_"+env.Name,
-		env.CACertificate, env.ClusterURI, env.Namespace)
+	env.BlockOnSchemaErrors = environment.BlockOnSchemaErrors
+	env.BlockOnInvalidVariables = environment.BlockOnInvalidVariables
 
 	var envID int
 	if envID, err = appContext.Repositories.EnvironmentDAO.CreateEnvironment(env); err != nil {
@@ -179,13 +190,31 @@ func (appContext *AppContext) addEnvironments(w http.ResponseWriter, r *http.Req
 
 	env := payload.Data
 
-	createEnvironmentFile(env.Name, env.Token, appContext.K8sConfigPath+env.Group+"_"+env.Name,
-		env.CACertificate, env.ClusterURI, env.Namespace)
-
-	if _, err := appContext.Repositories.EnvironmentDAO.CreateEnvironment(env); err != nil {
+	appContext.encryptEnvironmentCredentials(&env)
+	envID, err := appContext.Repositories.EnvironmentDAO.CreateEnvironment(env)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	env.ID = uint(envID)
+
+	if payload.Namespace != nil && payload.Namespace.Create {
+		if err := appContext.createEnvironmentNamespace(&env, *payload.Namespace); err != nil {
+			//The environment is useless without its namespace, so it is not kept
+			appContext.Repositories.EnvironmentDAO.DeleteEnvironment(env)
+			appContext.KubeConfigProvider.Invalidate(env.ID)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+			Actor:         principal.Email,
+			Action:        "createNamespace",
+			EnvironmentID: env.ID,
+			ResourceType:  "namespace",
+			ResourceID:    env.Namespace,
+		})
+	}
 
 	w.WriteHeader(http.StatusCreated)
 
@@ -208,6 +237,13 @@ func (appContext *AppContext) getEnvironments(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := appContext.fillEnvironmentStatus(envResult.Envs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.showEnvironmentCredentials(r, envResult.Envs)
+
 	w.WriteHeader(http.StatusOK)
 	data, _ := json.Marshal(envResult)
 	w.Write(data)
@@ -216,11 +252,8 @@ func (appContext *AppContext) getEnvironments(w http.ResponseWriter, r *http.Req
 
 func (appContext *AppContext) export(w http.ResponseWriter, r *http.Request) {
 
-	w.Header().Set(global.ContentType, "text/plain; charset=UTF-8")
-	w.Header().Set("Content-Disposition", "attachment; filename=environment.txt")
-
 	vars := mux.Vars(r)
-	log.Println("Deleting environment: ", vars["id"])
+	log.Println("Exporting environment: ", vars["id"])
 
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -229,20 +262,84 @@ func (appContext *AppContext) export(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format := exportFormatText
+	formats, ok := r.URL.Query()["format"]
+	if ok && len(formats[0]) > 0 {
+		format = formats[0]
+	}
+
+	revealSecrets := false
+	secrets, ok := r.URL.Query()["secrets"]
+	if ok && len(secrets[0]) > 0 {
+		revealSecrets, _ = strconv.ParseBool(secrets[0])
+	}
+
+	if revealSecrets {
+		principal := util.GetPrincipal(r)
+		if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+			http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
 	var variables []model.Variable
 	if variables, err = appContext.Repositories.VariableDAO.GetAllVariablesByEnvironment(id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	ibid := bytes.NewBufferString("\n")
+	variables = appContext.prepareExportVariables(variables, revealSecrets)
+
+	if format == exportFormatText {
+		ibid := bytes.NewBufferString("\n")
 
-	for _, element := range variables {
-		ibid.WriteString(element.Scope + " " + element.Name + "=" + element.Value + "\n")
+		for _, element := range variables {
+			ibid.WriteString(element.Scope + " " + element.Name + "=" + element.Value + "\n")
+		}
+
+		w.Header().Set(global.ContentType, "text/plain; charset=UTF-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=environment.txt")
+		w.WriteHeader(http.StatusOK)
+		w.Write(ibid.Bytes())
+		return
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var data []byte
+	var contentType, fileName string
+
+	switch format {
+	case exportFormatJSON:
+		data, err = json.MarshalIndent(getEnvironmentExport(environment, variables), "", "  ")
+		contentType = global.JSONContentType
+		fileName = "environment.json"
+	case exportFormatYAML:
+		data, err = yaml.Marshal(getEnvironmentExport(environment, variables))
+		contentType = "application/x-yaml; charset=UTF-8"
+		fileName = "environment.yaml"
+	case exportFormatHelmValues:
+		data, err = appContext.getHelmValuesArchive(environment, variables, revealSecrets)
+		contentType = "application/zip"
+		fileName = environment.Group + "_" + environment.Name + ".zip"
+	default:
+		http.Error(w, "Invalid export format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
+	w.Header().Set(global.ContentType, contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
 	w.WriteHeader(http.StatusOK)
-	w.Write(ibid.Bytes())
+	w.Write(data)
 
 }
 
@@ -258,66 +355,9 @@ func (appContext *AppContext) getAllEnvironments(w http.ResponseWriter, r *http.
 		return
 	}
 
+	appContext.showEnvironmentCredentials(r, envResult.Envs)
+
 	w.WriteHeader(http.StatusOK)
 	data, _ := json.Marshal(envResult)
 	w.Write(data)
 }
-
-func removeEnvironmentFile(fileName string) error {
-	log.Println("Removing file: " + fileName)
-
-	if _, err := os.Stat("./" + fileName); err == nil {
-		err := os.Remove("./" + fileName)
-		if err != nil {
-			log.Println("Error removing file", err)
-			return err
-		}
-	}
-	return nil
-}
-
-//CreateEnvironmentFile export function "createEnvironmentFile" for other packages
-func CreateEnvironmentFile(clusterName, clusterUserToken, fileName, ca, server, namespace string) {
-	createEnvironmentFile(clusterName, clusterUserToken, fileName, ca, server, namespace)
-}
-
-func createEnvironmentFile(clusterName string, clusterUserToken string,
-	fileName string, ca string, server string, namespace string) {
-
-	file, err := os.Create(fileName)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	ca = strings.TrimSuffix(ca, "\n")
-	caBase64 := base64.StdEncoding.EncodeToString([]byte(ca))
-
-	startIndex := strings.Index(clusterUserToken, "kubeconfig-")
-	clusterUser := "xpto"
-	if startIndex > 0 {
-		startIndex = startIndex + 11
-		endIndex := strings.Index(clusterUserToken, ":")
-		clusterUser = clusterUserToken[startIndex:endIndex]
-	}
-
-	file.WriteString("apiVersion: v1\n")
-	file.WriteString("clusters:\n")
-	file.WriteString("- cluster:\n")
-	file.WriteString("    certificate-authority-data: " + caBase64 + "\n")
-	file.WriteString("    server: " + server + "\n")
-	file.WriteString("  name: " + clusterName + "\n")
-	file.WriteString("contexts:\n")
-	file.WriteString("- context:\n")
-	file.WriteString("    cluster: " + clusterName + "\n")
-	file.WriteString("    namespace: " + namespace + "\n")
-	file.WriteString("    user: " + clusterUser + "\n")
-	file.WriteString("  name: " + clusterName + "\n")
-	file.WriteString("current-context: " + clusterName + "\n")
-	file.WriteString("kind: Config\n")
-	file.WriteString("preferences: {}\n")
-	file.WriteString("users:\n")
-	file.WriteString("- name: " + clusterUser + "\n")
-	file.WriteString("  user:\n")
-	file.WriteString("    token: " + clusterUserToken + "\n")
-
-}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//EnvironmentMonitorInterval interval between two connectivity checks of the environments
+const EnvironmentMonitorInterval = 10 * time.Minute
+
+//Connectivity check names computed from the environment credentials
+const (
+	checkKubeConfig  = "kubeconfig"
+	checkToken       = "token"
+	checkCertificate = "certificate"
+)
+
+func (appContext *AppContext) getEnvironmentConnectivity(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	has, err := appContext.hasAccess(principal, id)
+	if err != nil || !has {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := appContext.checkEnvironmentConnectivity(environment)
+	if err := appContext.Repositories.EnvironmentStatusDAO.SaveEnvironmentStatus(getEnvironmentStatus(result)); err != nil {
+		global.Logger.Error(global.AppFields{global.Function: "getEnvironmentConnectivity"}, "Error saving environment status: "+err.Error())
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//checkEnvironmentConnectivity checks the cluster of an environment and the expiry of its credentials.
+func (appContext *AppContext) checkEnvironmentConnectivity(environment *model.Environment) model.EnvironmentConnectivity {
+	now := time.Now()
+	result := model.EnvironmentConnectivity{EnvironmentID: environment.ID, CheckedAt: now}
+
+	credentials := *environment
+	appContext.DecryptEnvironmentCredentials(&credentials)
+
+	kubeConfig, err := appContext.KubeConfigProvider.GetKubeConfig(&credentials)
+	if err != nil {
+		result.Checks = []model.ConnectivityCheck{{Name: checkKubeConfig, Message: err.Error()}}
+	} else {
+		result.Checks = appContext.HelmServiceAPI.CheckConnectivity(kubeConfig, environment.Namespace)
+	}
+
+	result.TokenExpiresAt = getTokenExpiry(credentials.Token)
+	if result.TokenExpiresAt != nil && result.TokenExpiresAt.Before(now) {
+		result.Checks = append(result.Checks, model.ConnectivityCheck{Name: checkToken,
+			Message: "token expired at " + result.TokenExpiresAt.Format(time.RFC3339)})
+	}
+
+	result.CertificateExpiresAt = getCertificateExpiry(credentials.CACertificate)
+	if result.CertificateExpiresAt != nil && result.CertificateExpiresAt.Before(now) {
+		result.Checks = append(result.Checks, model.ConnectivityCheck{Name: checkCertificate,
+			Message: "certificate expired at " + result.CertificateExpiresAt.Format(time.RFC3339)})
+	}
+
+	result.Healthy = len(result.Checks) > 0
+	for _, e := range result.Checks {
+		if !e.Success {
+			result.Healthy = false
+		}
+	}
+	return result
+}
+
+func getEnvironmentStatus(connectivity model.EnvironmentConnectivity) model.EnvironmentStatus {
+	var failures []string
+	for _, e := range connectivity.Checks {
+		if !e.Success {
+			failures = append(failures, e.Name+": "+e.Message)
+		}
+	}
+	return model.EnvironmentStatus{
+		EnvironmentID:        connectivity.EnvironmentID,
+		Healthy:              connectivity.Healthy,
+		Message:              strings.Join(failures, "; "),
+		TokenExpiresAt:       connectivity.TokenExpiresAt,
+		CertificateExpiresAt: connectivity.CertificateExpiresAt,
+		CheckedAt:            connectivity.CheckedAt,
+	}
+}
+
+//getTokenExpiry returns the expiry of a JWT token, or nil if the token does not expire.
+func getTokenExpiry(token string) *time.Time {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil
+	}
+	expiry := time.Unix(int64(exp), 0)
+	return &expiry
+}
+
+//getCertificateExpiry returns the earliest expiry of the certificates of a PEM bundle.
+func getCertificateExpiry(ca string) *time.Time {
+	var expiry *time.Time
+	rest := []byte(ca)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if expiry == nil || certificate.NotAfter.Before(*expiry) {
+			notAfter := certificate.NotAfter
+			expiry = &notAfter
+		}
+	}
+	return expiry
+}
+
+//StartEnvironmentMonitor periodically records the connectivity status of every environment.
+func StartEnvironmentMonitor(appContext *AppContext, interval time.Duration) {
+	for {
+		appContext.monitorEnvironments()
+		time.Sleep(interval)
+	}
+}
+
+func (appContext *AppContext) monitorEnvironments() {
+	logFields := global.AppFields{global.Function: "monitorEnvironments"}
+
+	environments, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments("")
+	if err != nil {
+		global.Logger.Error(logFields, "Error retrieving environments: "+err.Error())
+		return
+	}
+
+	for i := range environments {
+		status := getEnvironmentStatus(appContext.checkEnvironmentConnectivity(&environments[i]))
+		if err := appContext.Repositories.EnvironmentStatusDAO.SaveEnvironmentStatus(status); err != nil {
+			global.Logger.Error(logFields, "Error saving status of environment "+environments[i].Name+": "+err.Error())
+		}
+	}
+}
+
+//fillEnvironmentStatus attaches the last recorded status to each environment.
+func (appContext *AppContext) fillEnvironmentStatus(environments []model.Environment) error {
+	statuses, err := appContext.Repositories.EnvironmentStatusDAO.ListEnvironmentStatuses()
+	if err != nil {
+		return err
+	}
+	byEnvironment := make(map[uint]model.EnvironmentStatus)
+	for _, e := range statuses {
+		byEnvironment[e.EnvironmentID] = e
+	}
+	for i := range environments {
+		if status, ok := byEnvironment[environments[i].ID]; ok {
+			environments[i].Status = &status
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getTestToken(t *testing.T, exp time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": exp.Unix()})
+	signed, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	return signed
+}
+
+func getTestCertificate(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tenkai"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func getConnectivityChecks() []model.ConnectivityCheck {
+	return []model.ConnectivityCheck{
+		{Name: "api", Success: true},
+		{Name: "namespace", Success: true},
+		{Name: "tiller", Success: true},
+	}
+}
+
+func TestGetTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	expiry := getTokenExpiry(getTestToken(t, exp))
+	assert.NotNil(t, expiry)
+	assert.True(t, exp.Equal(*expiry))
+
+	assert.Nil(t, getTokenExpiry("kubeconfig-user-ph111:abbkdd57t68tq2lppg6lwb65sb69282jhsmh3ndwn4vhjtt8blmhh2"))
+}
+
+func TestGetCertificateExpiry(t *testing.T) {
+	first := time.Now().Add(time.Hour).Truncate(time.Second)
+	second := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	bundle := getTestCertificate(t, second) + getTestCertificate(t, first)
+
+	expiry := getCertificateExpiry(bundle)
+	assert.NotNil(t, expiry)
+	assert.True(t, first.Equal(*expiry))
+
+	assert.Nil(t, getCertificateExpiry("my-certificate"))
+}
+
+func TestCheckEnvironmentConnectivity(t *testing.T) {
+	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("CheckConnectivity", "./config/foo_bar", "dev").Return(getConnectivityChecks())
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	env := mockGetEnv()
+	result := appContext.checkEnvironmentConnectivity(&env)
+	assert.True(t, result.Healthy)
+	assert.Len(t, result.Checks, 3)
+	assert.Nil(t, result.TokenExpiresAt)
+
+	env.Token = getTestToken(t, time.Now().Add(-time.Hour))
+	env.CACertificate = getTestCertificate(t, time.Now().Add(-time.Minute))
+	result = appContext.checkEnvironmentConnectivity(&env)
+	assert.False(t, result.Healthy)
+	assert.Len(t, result.Checks, 5)
+	assert.Equal(t, checkToken, result.Checks[3].Name)
+	assert.Equal(t, checkCertificate, result.Checks[4].Name)
+
+	status := getEnvironmentStatus(result)
+	assert.False(t, status.Healthy)
+	assert.Contains(t, status.Message, "token: token expired at")
+	assert.Contains(t, status.Message, "; certificate: certificate expired at")
+}
+
+func TestGetEnvironmentConnectivity(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockGetAllEnvironments(&appContext)
+	env := mockGetEnv()
+	mockEnvDao.On("GetByID", 999).Return(&env, nil)
+	mockKubeConfigProvider(&appContext)
+
+	checks := getConnectivityChecks()
+	checks[2] = model.ConnectivityCheck{Name: "tiller", Message: "connection refused"}
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("CheckConnectivity", "./config/foo_bar", "dev").Return(checks)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockEnvStatusDAO := &mockRepo.EnvironmentStatusDAOInterface{}
+	mockEnvStatusDAO.On("SaveEnvironmentStatus", mock.MatchedBy(func(status model.EnvironmentStatus) bool {
+		return status.EnvironmentID == 999 && !status.Healthy && status.Message == "tiller: connection refused"
+	})).Return(nil)
+	appContext.Repositories.EnvironmentStatusDAO = mockEnvStatusDAO
+
+	req, err := http.NewRequest("GET", "/environments/999/connectivity", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/{id}/connectivity", appContext.getEnvironmentConnectivity).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockEnvStatusDAO.AssertNumberOfCalls(t, "SaveEnvironmentStatus", 1)
+
+	var result model.EnvironmentConnectivity
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.False(t, result.Healthy)
+	assert.Len(t, result.Checks, 3)
+}
+
+func TestGetEnvironmentConnectivity_AccessDenied(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("GetAllEnvironments", "beta@alfa.com").Return([]model.Environment{}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	req, err := http.NewRequest("GET", "/environments/999/connectivity", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/{id}/connectivity", appContext.getEnvironmentConnectivity).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestGetEnvironments_WithStatus(t *testing.T) {
+	appContext := AppContext{}
+	mockGetAllEnvironments(&appContext)
+
+	mockEnvStatusDAO := &mockRepo.EnvironmentStatusDAOInterface{}
+	mockEnvStatusDAO.On("ListEnvironmentStatuses").Return([]model.EnvironmentStatus{
+		{EnvironmentID: 999, Healthy: false, Message: "tiller: connection refused"},
+	}, nil)
+	appContext.Repositories.EnvironmentStatusDAO = mockEnvStatusDAO
+
+	req, err := http.NewRequest("GET", "/environments", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getEnvironments)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"healthy":false,"message":"tiller: connection refused"`)
+}
+
+func TestMonitorEnvironments(t *testing.T) {
+	appContext := AppContext{}
+	mockGetAllEnvironmentsPrincipal(&appContext, "")
+	mockKubeConfigProvider(&appContext)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("CheckConnectivity", "./config/foo_bar", "dev").Return(getConnectivityChecks())
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockEnvStatusDAO := &mockRepo.EnvironmentStatusDAOInterface{}
+	mockEnvStatusDAO.On("SaveEnvironmentStatus", mock.Anything).Return(errors.New("some error"))
+	appContext.Repositories.EnvironmentStatusDAO = mockEnvStatusDAO
+
+	appContext.monitorEnvironments()
+	mockEnvStatusDAO.AssertNumberOfCalls(t, "SaveEnvironmentStatus", 1)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//MaskedCredential placeholder returned instead of the environment token and CA certificate
+const MaskedCredential = "********"
+
+func encryptCredential(value string, passkey string) string {
+	if len(value) == 0 {
+		return value
+	}
+	return hex.EncodeToString(util.Encrypt([]byte(value), passkey))
+}
+
+//decryptCredential returns the plain value of a credential, or the value itself
+//when it was stored before credentials were encrypted.
+func (appContext *AppContext) decryptCredential(value string) (string, bool) {
+	byteValues, err := hex.DecodeString(value)
+	if err != nil {
+		return value, false
+	}
+	plain, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
+	if err != nil {
+		return value, false
+	}
+	return string(plain), true
+}
+
+//encryptEnvironmentCredentials encrypts the token and CA certificate of an environment to be stored
+func (appContext *AppContext) encryptEnvironmentCredentials(env *model.Environment) {
+	env.Token = encryptCredential(env.Token, appContext.Configuration.App.Passkey)
+	env.CACertificate = encryptCredential(env.CACertificate, appContext.Configuration.App.Passkey)
+}
+
+//DecryptEnvironmentCredentials replaces the stored token and CA certificate of an environment by their plain values
+func (appContext *AppContext) DecryptEnvironmentCredentials(env *model.Environment) {
+	env.Token, _ = appContext.decryptCredential(env.Token)
+	env.CACertificate, _ = appContext.decryptCredential(env.CACertificate)
+}
+
+//getKubeConfig returns the kubeconfig of an environment, built from its decrypted credentials
+func (appContext *AppContext) getKubeConfig(environment *model.Environment) (string, error) {
+	credentials := *environment
+	appContext.DecryptEnvironmentCredentials(&credentials)
+	return appContext.KubeConfigProvider.GetKubeConfig(&credentials)
+}
+
+//getQueuePasskey returns the key shared with the worker to encrypt queue payloads
+func (appContext *AppContext) getQueuePasskey() string {
+	if len(appContext.Configuration.App.Rabbit.Passkey) > 0 {
+		return appContext.Configuration.App.Rabbit.Passkey
+	}
+	return appContext.Configuration.App.Passkey
+}
+
+//keepStoredCredentials keeps the stored credentials when the payload sends them back masked
+func keepStoredCredentials(env *model.Environment, stored *model.Environment) {
+	if env.Token == MaskedCredential {
+		env.Token = stored.Token
+	}
+	if env.CACertificate == MaskedCredential {
+		env.CACertificate = stored.CACertificate
+	}
+}
+
+func maskEnvironmentCredentials(envs []model.Environment) {
+	for i := range envs {
+		if len(envs[i].Token) > 0 {
+			envs[i].Token = MaskedCredential
+		}
+		if len(envs[i].CACertificate) > 0 {
+			envs[i].CACertificate = MaskedCredential
+		}
+	}
+}
+
+//showEnvironmentCredentials unmasks the credentials of the environments only when
+//an admin explicitly asks for them with showCredentials=true.
+func (appContext *AppContext) showEnvironmentCredentials(r *http.Request, envs []model.Environment) {
+	principal := util.GetPrincipal(r)
+	if util.Contains(principal.Roles, constraints.TenkaiAdmin) && r.URL.Query().Get("showCredentials") == "true" {
+		for i := range envs {
+			appContext.DecryptEnvironmentCredentials(&envs[i])
+		}
+		return
+	}
+	maskEnvironmentCredentials(envs)
+}
+
+//EncryptLegacyEnvironmentCredentials encrypts the credentials of environments stored in plain text
+func EncryptLegacyEnvironmentCredentials(appContext *AppContext) {
+	logFields := global.AppFields{global.Function: "EncryptLegacyEnvironmentCredentials"}
+
+	envs, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments("")
+	if err != nil {
+		global.Logger.Error(logFields, "Error retrieving environments: "+err.Error())
+		return
+	}
+
+	for _, env := range envs {
+		_, tokenEncrypted := appContext.decryptCredential(env.Token)
+		_, caEncrypted := appContext.decryptCredential(env.CACertificate)
+		if (tokenEncrypted || len(env.Token) == 0) && (caEncrypted || len(env.CACertificate) == 0) {
+			continue
+		}
+		if !tokenEncrypted {
+			env.Token = encryptCredential(env.Token, appContext.Configuration.App.Passkey)
+		}
+		if !caEncrypted {
+			env.CACertificate = encryptCredential(env.CACertificate, appContext.Configuration.App.Passkey)
+		}
+		if err := appContext.Repositories.EnvironmentDAO.EditEnvironment(env); err != nil {
+			global.Logger.Error(logFields, "Error encrypting credentials of environment "+env.Name+": "+err.Error())
+		}
+	}
+}
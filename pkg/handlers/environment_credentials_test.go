@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnvironmentCredentials(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	env := mockGetEnv()
+	appContext.encryptEnvironmentCredentials(&env)
+	assert.NotEqual(t, mockGetEnv().Token, env.Token)
+	assert.NotEqual(t, mockGetEnv().CACertificate, env.CACertificate)
+
+	appContext.DecryptEnvironmentCredentials(&env)
+	assert.Equal(t, mockGetEnv().Token, env.Token)
+	assert.Equal(t, mockGetEnv().CACertificate, env.CACertificate)
+
+	legacy := mockGetEnv()
+	appContext.DecryptEnvironmentCredentials(&legacy)
+	assert.Equal(t, mockGetEnv().Token, legacy.Token)
+}
+
+func TestKeepStoredCredentials(t *testing.T) {
+	stored := mockGetEnv()
+	env := mockGetEnv()
+	env.Token = MaskedCredential
+	env.CACertificate = "new-certificate"
+
+	keepStoredCredentials(&env, &stored)
+	assert.Equal(t, stored.Token, env.Token)
+	assert.Equal(t, "new-certificate", env.CACertificate)
+}
+
+func TestGetEnvironments_ShowCredentials(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	env := mockGetEnv()
+	appContext.encryptEnvironmentCredentials(&env)
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("GetAllEnvironments", "").Return([]model.Environment{env}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	req, err := http.NewRequest("GET", "/environments/all?showCredentials=true", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getAllEnvironments)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"ca_certificate":"my-certificate"`)
+	assert.Contains(t, rr.Body.String(), `"token":"`+mockGetEnv().Token+`"`)
+}
+
+func TestGetEnvironments_ShowCredentialsNotAdmin(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+	mockGetAllEnvironmentsPrincipal(&appContext, "")
+
+	req, err := http.NewRequest("GET", "/environments/all?showCredentials=true", nil)
+	assert.NoError(t, err)
+	principal := model.Principal{Name: "alfa", Email: "beta@alfa.com", Roles: []string{"tenkai-user"}}
+	pSe, _ := json.Marshal(principal)
+	req.Header.Set("principal", string(pSe))
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getAllEnvironments)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"token":"********"`)
+	assert.NotContains(t, rr.Body.String(), mockGetEnv().Token)
+}
+
+func TestEditEnvironment_MaskedCredentials(t *testing.T) {
+	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+	appContext.K8sConfigPath = "/tmp/"
+	mockConfiguration(&appContext)
+
+	stored := mockGetEnv()
+	appContext.encryptEnvironmentCredentials(&stored)
+	mockEnvDAO := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDAO.On("GetByID", 999).Return(&stored, nil)
+	mockEnvDAO.On("EditEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		credentials := env
+		appContext.DecryptEnvironmentCredentials(&credentials)
+		return credentials.Token == mockGetEnv().Token && credentials.CACertificate == "new-certificate"
+	})).Return(nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDAO
+
+	var p model.DataElement
+	p.Data = mockGetEnv()
+	p.Data.Token = MaskedCredential
+	p.Data.CACertificate = "new-certificate"
+	payload, _ := json.Marshal(p)
+
+	req, err := http.NewRequest("POST", "/environments/edit", bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.editEnvironment)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockEnvDAO.AssertNumberOfCalls(t, "EditEnvironment", 1)
+}
+
+func TestEncryptLegacyEnvironmentCredentials(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	encrypted := mockGetEnv()
+	encrypted.ID = 998
+	appContext.encryptEnvironmentCredentials(&encrypted)
+
+	mockEnvDAO := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDAO.On("GetAllEnvironments", "").Return([]model.Environment{mockGetEnv(), encrypted}, nil)
+	mockEnvDAO.On("EditEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		return env.ID == 999 && env.Token != mockGetEnv().Token
+	})).Return(nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDAO
+
+	EncryptLegacyEnvironmentCredentials(&appContext)
+	mockEnvDAO.AssertNumberOfCalls(t, "EditEnvironment", 1)
+}
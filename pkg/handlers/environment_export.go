@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"k8s.io/helm/pkg/strvals"
+)
+
+const (
+	exportFormatText       = "text"
+	exportFormatJSON       = "json"
+	exportFormatYAML       = "yaml"
+	exportFormatHelmValues = "helm-values"
+
+	redactedValue = "******"
+)
+
+//prepareExportVariables decrypts secret values when they may be revealed and redacts them otherwise.
+func (appContext *AppContext) prepareExportVariables(variables []model.Variable, revealSecrets bool) []model.Variable {
+	if revealSecrets {
+		return appContext.decryptVariables(variables)
+	}
+	for i, e := range variables {
+		if e.Secret {
+			variables[i].Value = redactedValue
+		}
+	}
+	return variables
+}
+
+func getEnvironmentExport(environment *model.Environment, variables []model.Variable) model.EnvironmentExport {
+	result := model.EnvironmentExport{
+		Group:     environment.Group,
+		Name:      environment.Name,
+		Namespace: environment.Namespace,
+		Gateway:   environment.Gateway,
+		Variables: make([]model.VariableExport, 0, len(variables)),
+	}
+	for _, e := range variables {
+		result.Variables = append(result.Variables, model.VariableExport{
+			Scope:       e.Scope,
+			Name:        e.Name,
+			Value:       e.Value,
+			Type:        e.Type,
+			Secret:      e.Secret,
+			Description: e.Description,
+		})
+	}
+	return result
+}
+
+//getHelmValuesArchive builds a zip archive containing one values file per scope, merging the chart defaults,
+//group, global and scope variables through the same layers simpleInstall renders the --set arguments from.
+func (appContext *AppContext) getHelmValuesArchive(environment *model.Environment, variables []model.Variable,
+	revealSecrets bool) ([]byte, error) {
+
+	scopes := make(map[string][]model.Variable)
+	for _, e := range variables {
+		scopes[e.Scope] = append(scopes[e.Scope], e)
+	}
+
+	groupVariables := appContext.getGroupVariables(environment)
+	if !revealSecrets {
+		for i, e := range groupVariables {
+			if e.Secret {
+				groupVariables[i].Value = redactedValue
+			}
+		}
+	}
+
+	resolver := newVariableResolver(*environment, nil)
+	resolver.addScope(globalScope, mergeGroupVariables(environment, groupVariables, scopes[globalScope]))
+	var names []string
+	for scope, scopeVariables := range scopes {
+		if scope != globalScope {
+			resolver.addScope(scope, scopeVariables)
+			names = append(names, scope)
+		}
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+
+	for _, scope := range names {
+		chartValues, err := appContext.getHelmChartValues(scope, "")
+		if err != nil {
+			return nil, err
+		}
+		layers := buildVariableLayers(scope, getAppValues(chartValues), groupVariables, scopes[globalScope], scopes[scope], nil)
+		values, err := getHelmValues(environment, layers, resolver)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := archive.Create(scope + ".yaml")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(values); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func getHelmValues(environment *model.Environment, layers *variableLayers, resolver *variableResolver) ([]byte, error) {
+	base := map[string]interface{}{}
+	for _, item := range layers.items {
+		value, err := item.resolve(resolver)
+		if err != nil {
+			return nil, err
+		}
+		varType := item.varType
+		if item.secret && item.value == redactedValue {
+			varType = model.VariableTypeString
+		}
+		values, stringValues, err := renderVariable(item.key, value, varType)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range values {
+			if err := strvals.ParseInto(e, base); err != nil {
+				return nil, err
+			}
+		}
+		for _, e := range stringValues {
+			if err := strvals.ParseIntoString(e, base); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(environment.Gateway) > 0 {
+		if err := strvals.ParseInto("istio.virtualservices.gateways[0]="+environment.Gateway, base); err != nil {
+			return nil, err
+		}
+	}
+
+	return yaml.Marshal(base)
+}
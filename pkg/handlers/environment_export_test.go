@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/configs"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockExportVariables(appContext *AppContext) *mockRepo.VariableDAOInterface {
+	appContext.Configuration = &configs.Configuration{}
+	appContext.Configuration.App.Passkey = "123456"
+
+	secret := mockVariable()
+	secret.Name = "token"
+	secret.Secret = true
+	secret.Value = hex.EncodeToString(util.Encrypt([]byte("s3cr3t"), "123456"))
+
+	interpolated := mockVariable()
+	interpolated.Name = "login"
+	interpolated.Value = "${username}@${NAMESPACE}"
+
+	var variables []model.Variable
+	variables = append(variables, mockGlobalVariable())
+	variables = append(variables, mockVariable())
+	variables = append(variables, secret)
+	variables = append(variables, interpolated)
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironment", mock.Anything).Return(variables, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+	return mockVariableDAO
+}
+
+func doExport(appContext *AppContext, url string, admin bool) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	if admin {
+		mockPrincipal(req)
+	}
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/export/{id}", appContext.export).Methods("GET")
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestExport_JSON(t *testing.T) {
+	appContext := AppContext{}
+	mockExportVariables(&appContext)
+	mockEnvDAO := mockGetByID(&appContext)
+
+	rr := doExport(&appContext, "/environments/export/999?format=json", false)
+
+	mockEnvDAO.AssertNumberOfCalls(t, "GetByID", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+
+	var result model.EnvironmentExport
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, "bar", result.Name)
+	assert.Equal(t, "dev", result.Namespace)
+	assert.Len(t, result.Variables, 4)
+	assert.Equal(t, "Login password.", result.Variables[1].Description)
+	assert.True(t, result.Variables[2].Secret)
+	assert.Equal(t, redactedValue, result.Variables[2].Value)
+}
+
+func TestExport_YAMLWithSecrets(t *testing.T) {
+	appContext := AppContext{}
+	mockExportVariables(&appContext)
+	mockGetByID(&appContext)
+
+	rr := doExport(&appContext, "/environments/export/999?format=yaml&secrets=true", true)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	response := string(rr.Body.Bytes())
+	assert.Contains(t, response, "value: s3cr3t")
+	assert.Contains(t, response, "description: Login password.")
+	assert.Contains(t, response, "secret: true")
+}
+
+func TestExport_SecretsNotAdmin(t *testing.T) {
+	appContext := AppContext{}
+
+	rr := doExport(&appContext, "/environments/export/999?format=json&secrets=true", false)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestExport_InvalidFormat(t *testing.T) {
+	appContext := AppContext{}
+	mockExportVariables(&appContext)
+	mockGetByID(&appContext)
+
+	rr := doExport(&appContext, "/environments/export/999?format=xml", false)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestExport_GetByIDError(t *testing.T) {
+	appContext := AppContext{}
+	mockExportVariables(&appContext)
+	mockGetByIDError(&appContext)
+
+	rr := doExport(&appContext, "/environments/export/999?format=yaml", false)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
+}
+
+func TestExport_HelmValues(t *testing.T) {
+	appContext := AppContext{}
+	mockExportVariables(&appContext)
+	mockGetByID(&appContext)
+	mockGroupVariables(&appContext)
+	mockChartSchema(&appContext, `{"app":{"username":"nobody","port":"8080"}}`, "")
+
+	rr := doExport(&appContext, "/environments/export/999?format=helm-values", false)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+
+	body := rr.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 1)
+	assert.Equal(t, "bar.yaml", archive.File[0].Name)
+
+	file, err := archive.File[0].Open()
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadAll(file)
+
+	values := string(content)
+	assert.Contains(t, values, "app:\n")
+	assert.Contains(t, values, "  login: user@dev\n")
+	assert.Contains(t, values, "  username: user\n")
+	assert.Contains(t, values, "  port: 8080\n")
+	assert.Contains(t, values, "  password: password\n")
+	assert.Contains(t, values, "  token: '"+redactedValue+"'\n")
+	assert.Contains(t, values, "- my-gateway.istio-system.svc.cluster.local")
+}
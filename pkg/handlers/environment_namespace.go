@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
+)
+
+//createEnvironmentNamespace creates the namespace of an environment in its cluster
+func (appContext *AppContext) createEnvironmentNamespace(env *model.Environment, options model.NamespaceOptions) error {
+	kubeConfig, err := appContext.getKubeConfig(env)
+	if err != nil {
+		return err
+	}
+	return appContext.HelmServiceAPI.CreateNamespace(kubeConfig, env.Namespace, options.Labels, options.ResourceQuota)
+}
+
+//purgeEnvironment purges every helm release of the environment namespace and deletes the namespace,
+//returning how many releases were purged.
+func (appContext *AppContext) purgeEnvironment(env *model.Environment) (int, error) {
+	kubeConfig, err := appContext.getKubeConfig(env)
+	if err != nil {
+		return 0, err
+	}
+
+	releases, err := appContext.HelmServiceAPI.ListHelmDeployments(kubeConfig, env.Namespace)
+	if err != nil {
+		return 0, err
+	}
+	//Tiller sends no list response for a namespace without releases
+	if releases == nil {
+		releases = &helmapi.HelmListResult{}
+	}
+	for i, release := range releases.Releases {
+		if err := appContext.HelmServiceAPI.DeleteHelmRelease(kubeConfig, release.Name, true); err != nil {
+			return i, err
+		}
+	}
+
+	return len(releases.Releases), appContext.HelmServiceAPI.DeleteNamespace(kubeConfig, env.Namespace)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
+	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getNamespacePayload() *bytes.Buffer {
+	var payload model.DataElement
+	payload.Data = mockGetEnv()
+	payload.Data.ID = 0
+	payload.Namespace = &model.NamespaceOptions{
+		Create:        true,
+		Labels:        map[string]string{"team": "alfa"},
+		ResourceQuota: map[string]string{"pods": "20"},
+	}
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestAddEnvironments_CreateNamespace(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+	mockKubeConfigProvider(&appContext)
+
+	mockEnvDao := mockGetByID(&appContext)
+	mockEnvDao.On("CreateEnvironment", mock.Anything).Return(999, nil)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("CreateNamespace", "./config/foo_bar", "dev",
+		map[string]string{"team": "alfa"}, map[string]string{"pods": "20"}).Return(nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "createNamespace", EnvironmentID: 999,
+		ResourceType: "namespace", ResourceID: "dev"})
+
+	req, err := http.NewRequest("POST", "/environments", getNamespacePayload())
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.addEnvironments)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	mockHelmSvc.AssertNumberOfCalls(t, "CreateNamespace", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestAddEnvironments_CreateNamespaceError(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
+
+	mockEnvDao := mockGetByID(&appContext)
+	mockEnvDao.On("CreateEnvironment", mock.Anything).Return(999, nil)
+	mockEnvDao.On("DeleteEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		return env.ID == 999
+	})).Return(nil)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("CreateNamespace", mock.Anything, "dev", mock.Anything, mock.Anything).
+		Return(errors.New("namespaces is forbidden"))
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	req, err := http.NewRequest("POST", "/environments", getNamespacePayload())
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.addEnvironments)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
+	mockEnvDao.AssertNumberOfCalls(t, "DeleteEnvironment", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "Invalidate", 1)
+}
+
+func TestDeleteEnvironment_Purge(t *testing.T) {
+	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+
+	mockEnvDAO := mockGetByID(&appContext)
+	mockEnvDAO.On("DeleteEnvironment", mockGetEnv()).Return(nil)
+
+	releases := &helmapi.HelmListResult{Releases: []helmapi.ListRelease{{Name: "my-chart-dev"}}}
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("ListHelmDeployments", "./config/foo_bar", "dev").Return(releases, nil)
+	mockHelmSvc.On("DeleteHelmRelease", "./config/foo_bar", "my-chart-dev", true).Return(nil)
+	mockHelmSvc.On("DeleteNamespace", "./config/foo_bar", "dev").Return(nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "deleteNamespace", EnvironmentID: 999,
+		ResourceType: "namespace", ResourceID: "dev", After: "releases=1"})
+
+	req, err := http.NewRequest("DELETE", "/environments/delete/999?purge=true", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/delete/{id}", appContext.deleteEnvironment).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockHelmSvc.AssertNumberOfCalls(t, "DeleteHelmRelease", 1)
+	mockHelmSvc.AssertNumberOfCalls(t, "DeleteNamespace", 1)
+	mockEnvDAO.AssertNumberOfCalls(t, "DeleteEnvironment", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestPurgeEnvironment_NoReleases(t *testing.T) {
+	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("ListHelmDeployments", "./config/foo_bar", "dev").Return(nil, nil)
+	mockHelmSvc.On("DeleteNamespace", "./config/foo_bar", "dev").Return(nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	env := mockGetEnv()
+	purged, err := appContext.purgeEnvironment(&env)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	mockHelmSvc.AssertNotCalled(t, "DeleteHelmRelease", mock.Anything, mock.Anything, mock.Anything)
+	mockHelmSvc.AssertNumberOfCalls(t, "DeleteNamespace", 1)
+}
+
+func TestDeleteEnvironment_PurgeError(t *testing.T) {
+	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+
+	mockEnvDAO := mockGetByID(&appContext)
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("ListHelmDeployments", "./config/foo_bar", "dev").Return(nil, errors.New("tiller unreachable"))
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	req, err := http.NewRequest("DELETE", "/environments/delete/999?purge=true", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/delete/{id}", appContext.deleteEnvironment).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
+	mockEnvDAO.AssertNotCalled(t, "DeleteEnvironment", mock.Anything)
+}
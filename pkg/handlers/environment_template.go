@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+func (appContext *AppContext) listEnvironmentTemplates(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result := &model.EnvironmentTemplateResult{}
+	var err error
+	if result.List, err = appContext.Repositories.EnvironmentTemplateDAO.ListEnvironmentTemplates(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range result.List {
+		appContext.decryptTemplateVariables(result.List[i].Variables)
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) newEnvironmentTemplate(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.EnvironmentTemplate
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := validateEnvironmentTemplate(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	appContext.encryptTemplateVariables(payload.Variables)
+	if _, err := appContext.Repositories.EnvironmentTemplateDAO.CreateEnvironmentTemplate(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (appContext *AppContext) editEnvironmentTemplate(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.EnvironmentTemplate
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if payload.ID == 0 {
+		http.Error(w, "template id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateEnvironmentTemplate(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	appContext.encryptTemplateVariables(payload.Variables)
+	if err := appContext.Repositories.EnvironmentTemplateDAO.EditEnvironmentTemplate(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) deleteEnvironmentTemplate(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := appContext.Repositories.EnvironmentTemplateDAO.DeleteEnvironmentTemplate(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) createEnvironmentFromTemplate(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.EnvironmentFromTemplatePayload
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	env := payload.Environment
+	if len(env.Name) == 0 || len(env.Namespace) == 0 {
+		http.Error(w, "environment name and namespace are required", http.StatusBadRequest)
+		return
+	}
+
+	template, err := appContext.Repositories.EnvironmentTemplateDAO.GetEnvironmentTemplate(int(payload.TemplateID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	appContext.decryptTemplateVariables(template.Variables)
+	variables := getTemplateVariables(env, template.Variables)
+	for i, variable := range variables {
+		if variable.Secret {
+			variables[i].Value = hex.EncodeToString(util.Encrypt([]byte(variable.Value), appContext.Configuration.App.Passkey))
+		}
+	}
+
+	//The environment is only deployed once it is stored with all of its variables
+	appContext.encryptEnvironmentCredentials(&env)
+	envID, err := appContext.Repositories.EnvironmentDAO.CreateEnvironmentWithVariables(env, variables)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	env.ID = uint(envID)
+
+	if payload.Install {
+		productVersionID := payload.ProductVersionID
+		if productVersionID == 0 {
+			productVersionID = template.ProductVersionID
+		}
+		if !appContext.installTemplateCharts(w, r, principal, &env, productVersionID, template.Charts) {
+			return
+		}
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "createEnvironmentFromTemplate",
+		EnvironmentID: env.ID,
+		ResourceType:  "environment",
+		ResourceID:    env.Name,
+		After:         "template=" + template.Name + " install=" + strconv.FormatBool(payload.Install),
+	})
+
+	data, _ := json.Marshal(map[string]int{"id": envID})
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+//installTemplateCharts queues the install of the template charts in the new environment
+func (appContext *AppContext) installTemplateCharts(w http.ResponseWriter, r *http.Request, principal model.Principal,
+	env *model.Environment, productVersionID int, charts []model.EnvironmentTemplateChart) bool {
+
+	var deployables []model.InstallPayload
+	for _, chart := range charts {
+		deployables = append(deployables, model.InstallPayload{
+			EnvironmentID: int(env.ID),
+			Chart:         chart.Chart,
+			ChartVersion:  chart.ChartVersion,
+			Name:          chart.Name,
+		})
+	}
+	return appContext.deployCharts(w, r, principal, env, productVersionID, deployables)
+}
+
+//deployCharts queues the install of charts in an environment under a single deployment request,
+//once they pass the deploy gate. It writes the response and returns false when the deploy fails.
+func (appContext *AppContext) deployCharts(w http.ResponseWriter, r *http.Request, principal model.Principal,
+	env *model.Environment, productVersionID int, deployables []model.InstallPayload) bool {
+
+	if len(deployables) == 0 {
+		return true
+	}
+
+	deployables, err := appContext.loadConfigMap(deployables, int(env.ID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	if !appContext.checkDeployGate(w, r, principal, env, deployables, overrideValidation(r)) {
+		return false
+	}
+
+	if err := appContext.queueCharts(r, principal, env, productVersionID, deployables); err != nil {
+		http.Error(w, err.Error(), installErrorStatus(err, http.StatusInternalServerError))
+		return false
+	}
+	return true
+}
+
+//queueCharts queues the install of charts already checked by the deploy gate
+func (appContext *AppContext) queueCharts(r *http.Request, principal model.Principal,
+	env *model.Environment, productVersionID int, deployables []model.InstallPayload) error {
+
+	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
+	if err != nil {
+		return err
+	}
+
+	requestDeployment := model.RequestDeployment{}
+	requestDeployment.UserID = user.ID
+	requestDeploymentID, err := appContext.Repositories.RequestDeploymentDAO.CreateRequestDeployment(requestDeployment)
+	if err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	for _, deployable := range deployables {
+		if err := appContext.updateImageTagBeforeInstallProduct(productVersionID, int(env.ID), deployable.Chart); err != nil {
+			return err
+		}
+
+		if _, err := appContext.simpleInstall(env, deployable, out, false, false, principal.Email, requestDeploymentID); err != nil {
+			return err
+		}
+
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, deployAuditEvent(principal, env, deployable))
+	}
+
+	if productVersionID > 0 {
+		pv, err := appContext.Repositories.ProductDAO.ListProductVersionsByID(productVersionID)
+		if err != nil {
+			return err
+		}
+		env.ProductVersion = pv.Version
+		return appContext.Repositories.EnvironmentDAO.EditEnvironment(*env)
+	}
+	return nil
+}
+
+//deployAuditEvent builds the audit event of the deploy of a chart
+func deployAuditEvent(principal model.Principal, env *model.Environment, deployable model.InstallPayload) model.AuditEvent {
+	after := "chart=" + deployable.Chart + " version=" + deployable.ChartVersion
+	if overrides := getOverrideVariables(env, []model.InstallPayload{deployable}); len(overrides) > 0 {
+		values := make([]string, 0, len(overrides))
+		for _, o := range overrides {
+			values = append(values, o.Name+"="+o.Value)
+		}
+		after += " overrides=" + strings.Join(values, ",")
+	}
+	return model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "deploy",
+		EnvironmentID: env.ID,
+		ResourceType:  "release",
+		ResourceID:    deployable.Name,
+		After:         after,
+	}
+}
+
+//getTemplateVariables builds the variables of an environment from the template variables,
+//replacing the environment placeholders of their values.
+func getTemplateVariables(env model.Environment, templateVariables []model.EnvironmentTemplateVariable) []model.Variable {
+	resolver := newVariableResolver(env, nil)
+	variables := make([]model.Variable, 0, len(templateVariables))
+	for _, e := range templateVariables {
+		variables = append(variables, model.Variable{
+			EnvironmentID: int(env.ID),
+			Scope:         e.Scope,
+			Name:          e.Name,
+			Value:         resolver.expandEnvironmentPlaceholders(e.Value),
+			Type:          e.Type,
+			Secret:        e.Secret,
+			Description:   e.Description,
+		})
+	}
+	return variables
+}
+
+//expandEnvironmentPlaceholders replaces the environment placeholders of a value,
+//keeping the references to other variables to be resolved at deploy time.
+func (v *variableResolver) expandEnvironmentPlaceholders(value string) string {
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		end := findClosingBrace(value, start+2)
+		if end < 0 {
+			break
+		}
+		result.WriteString(value[:start])
+		if field, ok := v.environmentField(strings.TrimSpace(value[start+2 : end])); ok {
+			result.WriteString(field)
+		} else {
+			result.WriteString(value[start : end+1])
+		}
+		value = value[end+1:]
+	}
+	result.WriteString(value)
+	return result.String()
+}
+
+func (appContext *AppContext) encryptTemplateVariables(variables []model.EnvironmentTemplateVariable) {
+	for i, e := range variables {
+		if e.Secret {
+			variables[i].Value = hex.EncodeToString(util.Encrypt([]byte(e.Value), appContext.Configuration.App.Passkey))
+		}
+	}
+}
+
+func (appContext *AppContext) decryptTemplateVariables(variables []model.EnvironmentTemplateVariable) {
+	for i, e := range variables {
+		if e.Secret {
+			byteValues, _ := hex.DecodeString(e.Value)
+			value, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
+			if err == nil {
+				variables[i].Value = string(value)
+			}
+		}
+	}
+}
+
+func validateEnvironmentTemplate(template model.EnvironmentTemplate) error {
+	if len(strings.TrimSpace(template.Name)) == 0 {
+		return errors.New("template name is required")
+	}
+	for _, e := range template.Variables {
+		if len(e.Scope) == 0 || len(e.Name) == 0 {
+			return errors.New("template variables require a scope and a name")
+		}
+		if err := validateVariableValue(model.Variable{Name: e.Name, Value: e.Value, Type: e.Type}); err != nil {
+			return err
+		}
+	}
+	for _, e := range template.Charts {
+		if len(e.Chart) == 0 || len(e.Name) == 0 {
+			return errors.New("template charts require a chart and a name")
+		}
+	}
+	return nil
+}
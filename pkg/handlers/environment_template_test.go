@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getEnvironmentTemplate() model.EnvironmentTemplate {
+	template := model.EnvironmentTemplate{Name: "default", ProductVersionID: 777}
+	template.ID = 1
+	template.Variables = []model.EnvironmentTemplateVariable{
+		{Scope: "global", Name: "domain", Value: "${NAMESPACE}.my-domain.com"},
+		{Scope: "repo/my-chart", Name: "dbUrl", Value: "jdbc:postgresql://db/${ENV.name}?user=${username}"},
+		{Scope: "repo/my-chart", Name: "password", Value: "secret-${ENV.name}", Secret: true},
+	}
+	template.Charts = []model.EnvironmentTemplateChart{
+		{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"},
+	}
+	return template
+}
+
+func getFromTemplatePayload(install bool) *bytes.Buffer {
+	payload := model.EnvironmentFromTemplatePayload{TemplateID: 1, Install: install}
+	payload.Environment = mockGetEnv()
+	payload.Environment.ID = 0
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestGetTemplateVariables(t *testing.T) {
+	env := mockGetEnv()
+	variables := getTemplateVariables(env, getEnvironmentTemplate().Variables)
+
+	assert.Len(t, variables, 3)
+	assert.Equal(t, 999, variables[0].EnvironmentID)
+	assert.Equal(t, "dev.my-domain.com", variables[0].Value)
+	assert.Equal(t, "jdbc:postgresql://db/bar?user=${username}", variables[1].Value)
+	assert.Equal(t, "secret-bar", variables[2].Value)
+}
+
+func TestValidateEnvironmentTemplate(t *testing.T) {
+	assert.NoError(t, validateEnvironmentTemplate(getEnvironmentTemplate()))
+	assert.Error(t, validateEnvironmentTemplate(model.EnvironmentTemplate{}))
+
+	template := getEnvironmentTemplate()
+	template.Variables[0].Scope = ""
+	assert.Error(t, validateEnvironmentTemplate(template))
+
+	template = getEnvironmentTemplate()
+	template.Charts[0].Name = ""
+	assert.Error(t, validateEnvironmentTemplate(template))
+}
+
+func TestNewEnvironmentTemplate(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	mockTemplateDAO := &mockRepo.EnvironmentTemplateDAOInterface{}
+	mockTemplateDAO.On("CreateEnvironmentTemplate", mock.MatchedBy(func(template model.EnvironmentTemplate) bool {
+		return template.Variables[2].Value != "secret-${ENV.name}" && template.Variables[0].Value == "${NAMESPACE}.my-domain.com"
+	})).Return(1, nil)
+	appContext.Repositories.EnvironmentTemplateDAO = mockTemplateDAO
+
+	data, _ := json.Marshal(getEnvironmentTemplate())
+	req, err := http.NewRequest("POST", "/environment-templates", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newEnvironmentTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	mockTemplateDAO.AssertNumberOfCalls(t, "CreateEnvironmentTemplate", 1)
+}
+
+func TestEditEnvironmentTemplate_WithoutID(t *testing.T) {
+	appContext := AppContext{}
+
+	template := getEnvironmentTemplate()
+	template.ID = 0
+	data, _ := json.Marshal(template)
+	req, err := http.NewRequest("POST", "/environment-templates/edit", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.editEnvironmentTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestListEnvironmentTemplates(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	template := getEnvironmentTemplate()
+	appContext.encryptTemplateVariables(template.Variables)
+	mockTemplateDAO := &mockRepo.EnvironmentTemplateDAOInterface{}
+	mockTemplateDAO.On("ListEnvironmentTemplates").Return([]model.EnvironmentTemplate{template}, nil)
+	appContext.Repositories.EnvironmentTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("GET", "/environment-templates", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listEnvironmentTemplates)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"value":"secret-${ENV.name}"`)
+}
+
+func TestDeleteEnvironmentTemplate(t *testing.T) {
+	appContext := AppContext{}
+
+	mockTemplateDAO := &mockRepo.EnvironmentTemplateDAOInterface{}
+	mockTemplateDAO.On("DeleteEnvironmentTemplate", 1).Return(nil)
+	appContext.Repositories.EnvironmentTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("DELETE", "/environment-templates/1", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environment-templates/{id}", appContext.deleteEnvironmentTemplate).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockTemplateDAO.AssertNumberOfCalls(t, "DeleteEnvironmentTemplate", 1)
+}
+
+func mockCreateEnvironmentFromTemplate(appContext *AppContext) (*mockRepo.EnvironmentDAOInterface, *mockRepo.VariableDAOInterface) {
+	mockConfiguration(appContext)
+
+	template := getEnvironmentTemplate()
+	appContext.encryptTemplateVariables(template.Variables)
+	mockTemplateDAO := &mockRepo.EnvironmentTemplateDAOInterface{}
+	mockTemplateDAO.On("GetEnvironmentTemplate", 1).Return(&template, nil)
+	appContext.Repositories.EnvironmentTemplateDAO = mockTemplateDAO
+
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("CreateEnvironmentWithVariables", mock.MatchedBy(func(env model.Environment) bool {
+		return env.Name == "bar" && env.Token != mockGetEnv().Token
+	}), mock.MatchedBy(func(variables []model.Variable) bool {
+		for _, variable := range variables {
+			if variable.Value == "secret-bar" || variable.Value == "${NAMESPACE}.my-domain.com" {
+				return false
+			}
+		}
+		return len(variables) == 3
+	})).Return(999, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	return mockEnvDao, mockVariableDAO
+}
+
+func TestCreateEnvironmentFromTemplate(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao, _ := mockCreateEnvironmentFromTemplate(&appContext)
+
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "createEnvironmentFromTemplate", EnvironmentID: 999,
+		ResourceType: "environment", ResourceID: "bar", After: "template=default install=false"})
+
+	req, err := http.NewRequest("POST", "/environments/from-template", getFromTemplatePayload(false))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createEnvironmentFromTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	assert.Equal(t, `{"id":999}`, rr.Body.String())
+	mockEnvDao.AssertNumberOfCalls(t, "CreateEnvironmentWithVariables", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestCreateEnvironmentFromTemplate_Install(t *testing.T) {
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockEnvDao, mockVariableDAO := mockCreateEnvironmentFromTemplate(&appContext)
+	mockEnvDao.On("EditEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		return env.ID == 999 && env.ProductVersion == "19.0.1-0"
+	})).Return(nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, mock.Anything).Return([]model.Variable{}, nil)
+	mockVariableDAO.On("GetVarImageTagByEnvAndScope", 999, "repo/my-chart").Return(model.Variable{}, nil)
+	mockKubeConfigProvider(&appContext)
+
+	user := mockUser()
+	mockUserDAO := &mockRepo.UserDAOInterface{}
+	mockUserDAO.On("FindByEmail", user.Email).Return(user, nil)
+	appContext.Repositories.UserDAO = mockUserDAO
+
+	mockRequestDeploymentDAO := &mockRepo.RequestDeploymentDAOInterface{}
+	mockRequestDeploymentDAO.On("CreateRequestDeployment", mock.Anything).Return(1, nil)
+	appContext.Repositories.RequestDeploymentDAO = mockRequestDeploymentDAO
+
+	mockDeploymentDAO := &mockRepo.DeploymentDAOInterface{}
+	mockDeploymentDAO.On("CreateDeployment", mock.Anything).Return(1, nil)
+	appContext.Repositories.DeploymentDAO = mockDeploymentDAO
+
+	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
+	mockConfigDAO.On("GetConfigByName", "commonValuesConfigMapChart").Return(model.ConfigMap{Value: "myvalue"}, nil)
+	appContext.Repositories.ConfigDAO = mockConfigDAO
+
+	pv := model.ProductVersion{Version: "19.0.1-0", ProductID: 999}
+	pv.ID = 777
+	mockProductDAO := &mockRepo.ProductDAOInterface{}
+	mockProductDAO.On("ListProductsVersionServices", 777).Return([]model.ProductVersionService{}, nil)
+	mockProductDAO.On("ListProductVersionsByID", 777).Return(&pv, nil)
+	appContext.Repositories.ProductDAO = mockProductDAO
+
+	mockHelmSvc := mockUpgrade(&appContext)
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(`{"app":{}}`), nil)
+	appContext.RabbitImpl = getMockRabbitMQ()
+
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "deploy", EnvironmentID: 999,
+		ResourceType: "release", ResourceID: "my-chart", After: "chart=repo/my-chart version=0.1.0"})
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == "createEnvironmentFromTemplate"
+	}))
+
+	req, err := http.NewRequest("POST", "/environments/from-template", getFromTemplatePayload(true))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createEnvironmentFromTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	mockRequestDeploymentDAO.AssertNumberOfCalls(t, "CreateRequestDeployment", 1)
+	mockDeploymentDAO.AssertNumberOfCalls(t, "CreateDeployment", 1)
+	mockEnvDao.AssertNumberOfCalls(t, "EditEnvironment", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 2)
+}
+
+func TestCreateEnvironmentFromTemplate_TemplateNotFound(t *testing.T) {
+	appContext := AppContext{}
+
+	mockTemplateDAO := &mockRepo.EnvironmentTemplateDAOInterface{}
+	mockTemplateDAO.On("GetEnvironmentTemplate", 1).Return(nil, errors.New("record not found"))
+	appContext.Repositories.EnvironmentTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("POST", "/environments/from-template", getFromTemplatePayload(false))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createEnvironmentFromTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Response should be 404.")
+}
@@ -30,9 +30,12 @@ func TestAddEnvironments(t *testing.T) {
 
 	appContext := AppContext{}
 	appContext.K8sConfigPath = "/tmp/"
+	mockConfiguration(&appContext)
 
 	mockObject := &mocks.EnvironmentDAOInterface{}
-	mockObject.On("CreateEnvironment", mock.Anything).Return(1, nil)
+	mockObject.On("CreateEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		return env.Token != payload.Data.Token && env.CACertificate != payload.Data.CACertificate
+	})).Return(1, nil)
 
 	appContext.Repositories = Repositories{}
 	appContext.Repositories.EnvironmentDAO = mockObject
@@ -67,6 +70,7 @@ func TestAddEnvironments(t *testing.T) {
 
 func TestDeleteEnvironment(t *testing.T) {
 	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
 
 	env := mockGetEnv()
 	mockEnvDAO := mockGetByID(&appContext)
@@ -165,6 +169,8 @@ func TestDeleteEnvironment_DeleteEnvironmentError(t *testing.T) {
 
 func TestEditEnvironment(t *testing.T) {
 	appContext := AppContext{}
+	mockKubeConfigProvider(&appContext)
+	mockConfiguration(&appContext)
 	appContext.K8sConfigPath = "/tmp/"
 
 	mockEnvDAO := mockGetByID(&appContext)
@@ -237,6 +243,7 @@ func TestEditEnvironment_GetByIDError(t *testing.T) {
 
 func TestEditEnvironment_EditEnvironmentError(t *testing.T) {
 	appContext := AppContext{}
+	mockConfiguration(&appContext)
 	appContext.K8sConfigPath = "/tmp/"
 
 	mockEnvDAO := mockGetByID(&appContext)
@@ -426,6 +433,10 @@ func TestGetEnvironments(t *testing.T) {
 	appContext := AppContext{}
 	mockEnvDAO := mockGetAllEnvironments(&appContext)
 
+	mockEnvStatusDAO := &mocks.EnvironmentStatusDAOInterface{}
+	mockEnvStatusDAO.On("ListEnvironmentStatuses").Return([]model.EnvironmentStatus{}, nil)
+	appContext.Repositories.EnvironmentStatusDAO = mockEnvStatusDAO
+
 	req, err := http.NewRequest("GET", "/environments", bytes.NewBuffer(nil))
 	assert.NoError(t, err)
 	assert.NotNil(t, req)
@@ -443,10 +454,10 @@ func TestGetEnvironments(t *testing.T) {
 	assert.Contains(t, response, `{"Envs":[{"ID":999`)
 	assert.Contains(t, response, `"group":"foo","name":"bar"`)
 	assert.Contains(t, response, `"cluster_uri":"https://rancher-k8s-my-domain.com/k8s/clusters/c-kbfxr"`)
-	assert.Contains(t, response, `"ca_certificate":"my-certificate"`)
-	assert.Contains(t, response, `"token":"kubeconfig-user-ph111:abbkdd57t68tq2lppg6lwb65sb69282jhsmh3ndwn4vhjtt8blmhh2"`)
+	assert.Contains(t, response, `"ca_certificate":"********"`)
+	assert.Contains(t, response, `"token":"********"`)
 	assert.Contains(t, response, `"namespace":"dev","gateway":"my-gateway.istio-system.svc.cluster.local"`)
-	assert.Contains(t, response, `"productVersion":"","currentRelease":""}]}`)
+	assert.Contains(t, response, `"productVersion":"","currentRelease":"","blockOnSchemaErrors":false,"blockOnInvalidVariables":false}]}`)
 }
 
 func TestGetEnvironments_AccessDenied(t *testing.T) {
@@ -553,10 +564,10 @@ func TestGetAllEnvironments(t *testing.T) {
 	assert.Contains(t, response, `{"Envs":[{"ID":999`)
 	assert.Contains(t, response, `"group":"foo","name":"bar"`)
 	assert.Contains(t, response, `"cluster_uri":"https://rancher-k8s-my-domain.com/k8s/clusters/c-kbfxr"`)
-	assert.Contains(t, response, `"ca_certificate":"my-certificate"`)
-	assert.Contains(t, response, `"token":"kubeconfig-user-ph111:abbkdd57t68tq2lppg6lwb65sb69282jhsmh3ndwn4vhjtt8blmhh2"`)
+	assert.Contains(t, response, `"ca_certificate":"********"`)
+	assert.Contains(t, response, `"token":"********"`)
 	assert.Contains(t, response, `"namespace":"dev","gateway":"my-gateway.istio-system.svc.cluster.local"`)
-	assert.Contains(t, response, `"productVersion":"","currentRelease":""}]}`)
+	assert.Contains(t, response, `"productVersion":"","currentRelease":"","blockOnSchemaErrors":false,"blockOnInvalidVariables":false}]}`)
 }
 
 func TestGetAllEnvironments_GetAllEnvError(t *testing.T) {
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//EphemeralEnvironmentReaperInterval interval between two searches for expired ephemeral environments
+const EphemeralEnvironmentReaperInterval = time.Minute
+
+//MaxEphemeralEnvironmentTTL longest lifetime an ephemeral environment may have from now
+const MaxEphemeralEnvironmentTTL = 30 * 24 * time.Hour
+
+const ephemeralEnvironmentReaper = "tenkai-reaper"
+const imageTagVariable = "image.tag"
+
+var namespaceSuffixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+func (appContext *AppContext) listEphemeralEnvironments(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result := &model.EphemeralEnvironmentResult{}
+	var err error
+	if result.List, err = appContext.Repositories.EphemeralEnvironmentDAO.ListEphemeralEnvironments(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) createEphemeralEnvironment(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.EphemeralEnvironmentPayload
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ttl, err := parseEphemeralTTL(payload.TTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateEphemeralCharts(payload.Charts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	base, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.BaseEnvironmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	env, err := getEphemeralEnvironment(*base, payload.NamespaceSuffix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironment(payload.BaseEnvironmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	envID, err := appContext.Repositories.EnvironmentDAO.CreateEnvironment(env)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	env.ID = uint(envID)
+
+	for _, variable := range getEphemeralVariables(envID, variables, payload.Charts) {
+		if _, _, err := appContext.Repositories.VariableDAO.CreateVariable(variable); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ephemeral := model.EphemeralEnvironment{
+		EnvironmentID:     env.ID,
+		BaseEnvironmentID: base.ID,
+		ExpiresAt:         time.Now().Add(ttl),
+		CreatedBy:         principal.Email,
+	}
+	id, err := appContext.Repositories.EphemeralEnvironmentDAO.CreateEphemeralEnvironment(ephemeral)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ephemeral.ID = uint(id)
+
+	var deployables []model.InstallPayload
+	for _, chart := range payload.Charts {
+		deployables = append(deployables, model.InstallPayload{
+			EnvironmentID: envID,
+			Chart:         chart.Chart,
+			ChartVersion:  chart.ChartVersion,
+			Name:          chart.Name,
+		})
+	}
+	if !appContext.deployCharts(w, r, principal, &env, 0, deployables) {
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "createEphemeralEnvironment",
+		EnvironmentID: env.ID,
+		ResourceType:  "environment",
+		ResourceID:    env.Name,
+		After:         "base=" + base.Name + " expiresAt=" + ephemeral.ExpiresAt.Format(time.RFC3339),
+	})
+
+	data, _ := json.Marshal(ephemeral)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (appContext *AppContext) extendEphemeralEnvironment(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	envID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var payload model.ExtendEphemeralEnvironmentPayload
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ttl, err := parseEphemeralTTL(payload.TTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ephemeral, err := appContext.Repositories.EphemeralEnvironmentDAO.GetByEnvironmentID(envID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	previousExpiresAt := ephemeral.ExpiresAt
+	expiresAt := ephemeral.ExpiresAt
+	if expiresAt.Before(now) {
+		expiresAt = now
+	}
+	expiresAt = expiresAt.Add(ttl)
+	if expiresAt.After(now.Add(MaxEphemeralEnvironmentTTL)) {
+		http.Error(w, "ephemeral environments can not live longer than "+MaxEphemeralEnvironmentTTL.String(), http.StatusBadRequest)
+		return
+	}
+	ephemeral.ExpiresAt = expiresAt
+
+	if err := appContext.Repositories.EphemeralEnvironmentDAO.EditEphemeralEnvironment(*ephemeral); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "extendEphemeralEnvironment",
+		EnvironmentID: uint(envID),
+		ResourceType:  "environment",
+		ResourceID:    strconv.Itoa(envID),
+		Before:        "expiresAt=" + previousExpiresAt.Format(time.RFC3339),
+		After:         "expiresAt=" + expiresAt.Format(time.RFC3339),
+	})
+
+	data, _ := json.Marshal(ephemeral)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//StartEphemeralEnvironmentReaper removes the ephemeral environments whose TTL expired
+func StartEphemeralEnvironmentReaper(appContext *AppContext, interval time.Duration) {
+	for {
+		appContext.reapEphemeralEnvironments(time.Now())
+		time.Sleep(interval)
+	}
+}
+
+func (appContext *AppContext) reapEphemeralEnvironments(now time.Time) {
+	logFields := global.AppFields{global.Function: "reapEphemeralEnvironments"}
+
+	//A panic must not take the server down, the next run retries
+	defer func() {
+		if r := recover(); r != nil {
+			global.Logger.Error(logFields, fmt.Sprintf("Panic reaping ephemeral environments: %v", r))
+		}
+	}()
+
+	expired, err := appContext.Repositories.EphemeralEnvironmentDAO.ListExpiredEphemeralEnvironments(now)
+	if err != nil {
+		global.Logger.Error(logFields, "Error retrieving expired ephemeral environments: "+err.Error())
+		return
+	}
+
+	for _, e := range expired {
+		if err := appContext.removeEphemeralEnvironment(e); err != nil {
+			global.Logger.Error(logFields, fmt.Sprintf("Error removing ephemeral environment %d: %s", e.EnvironmentID, err.Error()))
+		}
+	}
+}
+
+//removeEphemeralEnvironment purges the environment namespace before deleting the environment,
+//so a failure leaves the registry in place to be retried on the next run.
+func (appContext *AppContext) removeEphemeralEnvironment(ephemeral model.EphemeralEnvironment) error {
+	env, err := appContext.Repositories.EnvironmentDAO.GetByID(int(ephemeral.EnvironmentID))
+	if err != nil {
+		return err
+	}
+
+	releases, err := appContext.purgeEnvironment(env)
+	if err != nil {
+		return err
+	}
+
+	if err := appContext.Repositories.EnvironmentDAO.DeleteEnvironment(*env); err != nil {
+		return err
+	}
+	appContext.KubeConfigProvider.Invalidate(env.ID)
+
+	if err := appContext.Repositories.EphemeralEnvironmentDAO.DeleteEphemeralEnvironment(int(ephemeral.ID)); err != nil {
+		return err
+	}
+
+	appContext.Auditing.DoAudit(context.Background(), appContext.Elk, model.AuditEvent{
+		Actor:         ephemeralEnvironmentReaper,
+		Action:        "removeEphemeralEnvironment",
+		EnvironmentID: env.ID,
+		ResourceType:  "environment",
+		ResourceID:    env.Name,
+		After:         "releases=" + strconv.Itoa(releases),
+	})
+	return nil
+}
+
+func parseEphemeralTTL(value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("invalid ttl, use a duration like 48h")
+	}
+	if ttl <= 0 || ttl > MaxEphemeralEnvironmentTTL {
+		return 0, errors.New("ttl must be positive and at most " + MaxEphemeralEnvironmentTTL.String())
+	}
+	return ttl, nil
+}
+
+func validateEphemeralCharts(charts []model.EphemeralEnvironmentChart) error {
+	for _, e := range charts {
+		if len(e.Chart) == 0 || len(e.Name) == 0 {
+			return errors.New("ephemeral charts require a chart and a name")
+		}
+	}
+	return nil
+}
+
+//getEphemeralEnvironment copies the base environment into a new namespace named after the suffix
+func getEphemeralEnvironment(base model.Environment, suffix string) (model.Environment, error) {
+	if !namespaceSuffixRegex.MatchString(suffix) {
+		return model.Environment{}, errors.New("namespace suffix must be lowercase alphanumeric characters or '-'")
+	}
+	namespace := base.Namespace + "-" + suffix
+	if len(namespace) > 63 {
+		return model.Environment{}, errors.New("namespace " + namespace + " is longer than 63 characters")
+	}
+
+	var env model.Environment
+	env.Group = base.Group
+	env.Name = base.Name + "-" + suffix
+	env.Namespace = namespace
+	env.ClusterURI = base.ClusterURI
+	env.CACertificate = base.CACertificate
+	env.Token = base.Token
+	env.Gateway = base.Gateway
+	env.BlockOnSchemaErrors = base.BlockOnSchemaErrors
+	env.BlockOnInvalidVariables = base.BlockOnInvalidVariables
+	return env, nil
+}
+
+//getEphemeralVariables clones the base variables, overriding the image tag of the charts that set one
+func getEphemeralVariables(envID int, variables []model.Variable, charts []model.EphemeralEnvironmentChart) []model.Variable {
+	imageTags := make(map[string]string)
+	for _, e := range charts {
+		if len(e.ImageTag) > 0 {
+			imageTags[e.Chart] = e.ImageTag
+		}
+	}
+
+	result := make([]model.Variable, 0, len(variables))
+	for _, e := range variables {
+		variable := model.Variable{
+			EnvironmentID: envID,
+			Scope:         e.Scope,
+			Name:          e.Name,
+			Value:         e.Value,
+			Type:          e.Type,
+			Secret:        e.Secret,
+			Description:   e.Description,
+		}
+		if tag, ok := imageTags[e.Scope]; ok && e.Name == imageTagVariable {
+			variable.Value = tag
+			delete(imageTags, e.Scope)
+		}
+		result = append(result, variable)
+	}
+
+	for _, e := range charts {
+		if tag, ok := imageTags[e.Chart]; ok {
+			result = append(result, model.Variable{EnvironmentID: envID, Scope: e.Chart, Name: imageTagVariable, Value: tag})
+			delete(imageTags, e.Chart)
+		}
+	}
+	return result
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	mockAud "github.com/softplan/tenkai-api/pkg/audit/mocks"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
+	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getEphemeralPayload(suffix string, ttl string) *bytes.Buffer {
+	payload := model.EphemeralEnvironmentPayload{BaseEnvironmentID: 999, NamespaceSuffix: suffix, TTL: ttl}
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestGetEphemeralEnvironment(t *testing.T) {
+	env, err := getEphemeralEnvironment(mockGetEnv(), "feature-123")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar-feature-123", env.Name)
+	assert.Equal(t, "dev-feature-123", env.Namespace)
+	assert.Equal(t, mockGetEnv().Token, env.Token)
+	assert.Equal(t, uint(0), env.ID)
+
+	_, err = getEphemeralEnvironment(mockGetEnv(), "Feature_123")
+	assert.Error(t, err)
+}
+
+func TestParseEphemeralTTL(t *testing.T) {
+	ttl, err := parseEphemeralTTL("48h")
+	assert.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, ttl)
+
+	_, err = parseEphemeralTTL("two days")
+	assert.Error(t, err)
+	_, err = parseEphemeralTTL("-1h")
+	assert.Error(t, err)
+	_, err = parseEphemeralTTL("1000h")
+	assert.Error(t, err)
+}
+
+func TestGetEphemeralVariables(t *testing.T) {
+	variables := []model.Variable{
+		{EnvironmentID: 999, Scope: "repo/my-chart", Name: "image.tag", Value: "1.0.0"},
+		{EnvironmentID: 999, Scope: "repo/my-chart", Name: "replicas", Value: "2"},
+	}
+	charts := []model.EphemeralEnvironmentChart{
+		{Chart: "repo/my-chart", Name: "my-chart", ImageTag: "feature-123"},
+		{Chart: "repo/other-chart", Name: "other-chart", ImageTag: "feature-123"},
+		{Chart: "repo/third-chart", Name: "third-chart"},
+	}
+
+	result := getEphemeralVariables(1000, variables, charts)
+	assert.Len(t, result, 3)
+	assert.Equal(t, 1000, result[0].EnvironmentID)
+	assert.Equal(t, "feature-123", result[0].Value)
+	assert.Equal(t, "2", result[1].Value)
+	assert.Equal(t, "repo/other-chart", result[2].Scope)
+	assert.Equal(t, "image.tag", result[2].Name)
+	assert.Equal(t, "feature-123", result[2].Value)
+}
+
+func TestCreateEphemeralEnvironment(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+	mockEnvDao.On("CreateEnvironment", mock.MatchedBy(func(env model.Environment) bool {
+		return env.Name == "bar-feature-123" && env.Namespace == "dev-feature-123"
+	})).Return(1000, nil)
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironment", 999).
+		Return([]model.Variable{{EnvironmentID: 999, Scope: "global", Name: "domain", Value: "my-domain.com"}}, nil)
+	mockVariableDAO.On("CreateVariable", mock.MatchedBy(func(variable model.Variable) bool {
+		return variable.EnvironmentID == 1000 && variable.Name == "domain"
+	})).Return(map[string]string{}, false, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockEphemeralDAO := &mockRepo.EphemeralEnvironmentDAOInterface{}
+	mockEphemeralDAO.On("CreateEphemeralEnvironment", mock.MatchedBy(func(item model.EphemeralEnvironment) bool {
+		return item.EnvironmentID == 1000 && item.BaseEnvironmentID == 999 && item.ExpiresAt.After(time.Now().Add(47*time.Hour))
+	})).Return(1, nil)
+	appContext.Repositories.EphemeralEnvironmentDAO = mockEphemeralDAO
+
+	mockAudit := &mockAud.AuditingInterface{}
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Actor == "beta@alfa.com" && event.Action == "createEphemeralEnvironment" && event.EnvironmentID == 1000
+	}))
+	appContext.Auditing = mockAudit
+
+	req, err := http.NewRequest("POST", "/environments/ephemeral", getEphemeralPayload("feature-123", "48h"))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createEphemeralEnvironment)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	assert.Contains(t, rr.Body.String(), `"environmentId":1000`)
+	mockVariableDAO.AssertNumberOfCalls(t, "CreateVariable", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestCreateEphemeralEnvironment_InvalidTTL(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("POST", "/environments/ephemeral", getEphemeralPayload("feature-123", "forever"))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createEphemeralEnvironment)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestExtendEphemeralEnvironment(t *testing.T) {
+	appContext := AppContext{}
+
+	expiresAt := time.Now().Add(time.Hour)
+	ephemeral := model.EphemeralEnvironment{EnvironmentID: 1000, BaseEnvironmentID: 999, ExpiresAt: expiresAt}
+	mockEphemeralDAO := &mockRepo.EphemeralEnvironmentDAOInterface{}
+	mockEphemeralDAO.On("GetByEnvironmentID", 1000).Return(&ephemeral, nil)
+	mockEphemeralDAO.On("EditEphemeralEnvironment", mock.MatchedBy(func(item model.EphemeralEnvironment) bool {
+		return item.ExpiresAt.Equal(expiresAt.Add(24 * time.Hour))
+	})).Return(nil)
+	appContext.Repositories.EphemeralEnvironmentDAO = mockEphemeralDAO
+	mockAudit := &mockAud.AuditingInterface{}
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Actor == "beta@alfa.com" && event.Action == "extendEphemeralEnvironment" &&
+			event.Before == "expiresAt="+expiresAt.Format(time.RFC3339)
+	}))
+	appContext.Auditing = mockAudit
+
+	data, _ := json.Marshal(model.ExtendEphemeralEnvironmentPayload{TTL: "24h"})
+	req, err := http.NewRequest("POST", "/environments/ephemeral/1000/extend", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/environments/ephemeral/{id}/extend", appContext.extendEphemeralEnvironment).Methods("POST")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockEphemeralDAO.AssertNumberOfCalls(t, "EditEphemeralEnvironment", 1)
+}
+
+func TestReapEphemeralEnvironments(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+	mockEnvDao.On("DeleteEnvironment", mock.Anything).Return(nil)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
+
+	now := time.Now()
+	expired := model.EphemeralEnvironment{EnvironmentID: 999, ExpiresAt: now.Add(-time.Minute)}
+	expired.ID = 1
+	mockEphemeralDAO := &mockRepo.EphemeralEnvironmentDAOInterface{}
+	mockEphemeralDAO.On("ListExpiredEphemeralEnvironments", now).Return([]model.EphemeralEnvironment{expired}, nil)
+	mockEphemeralDAO.On("DeleteEphemeralEnvironment", 1).Return(nil)
+	appContext.Repositories.EphemeralEnvironmentDAO = mockEphemeralDAO
+
+	releases := &helmapi.HelmListResult{Releases: []helmapi.ListRelease{{Name: "my-chart-dev"}, {Name: "other-chart-dev"}}}
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("ListHelmDeployments", "./config/foo_bar", "dev").Return(releases, nil)
+	mockHelmSvc.On("DeleteHelmRelease", "./config/foo_bar", mock.Anything, true).Return(nil)
+	mockHelmSvc.On("DeleteNamespace", "./config/foo_bar", "dev").Return(nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockAudit := &mockAud.AuditingInterface{}
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, model.AuditEvent{Actor: "tenkai-reaper", Action: "removeEphemeralEnvironment",
+		EnvironmentID: 999, ResourceType: "environment", ResourceID: "bar", After: "releases=2"})
+	appContext.Auditing = mockAudit
+
+	appContext.reapEphemeralEnvironments(now)
+
+	mockHelmSvc.AssertNumberOfCalls(t, "DeleteHelmRelease", 2)
+	mockEnvDao.AssertNumberOfCalls(t, "DeleteEnvironment", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "Invalidate", 1)
+	mockEphemeralDAO.AssertNumberOfCalls(t, "DeleteEphemeralEnvironment", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestReapEphemeralEnvironments_KeepOnFailure(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+	mockKubeConfigProvider(&appContext)
+
+	now := time.Now()
+	expired := model.EphemeralEnvironment{EnvironmentID: 999, ExpiresAt: now.Add(-time.Minute)}
+	mockEphemeralDAO := &mockRepo.EphemeralEnvironmentDAOInterface{}
+	mockEphemeralDAO.On("ListExpiredEphemeralEnvironments", now).Return([]model.EphemeralEnvironment{expired}, nil)
+	appContext.Repositories.EphemeralEnvironmentDAO = mockEphemeralDAO
+
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("ListHelmDeployments", mock.Anything, "dev").Return(nil, errors.New("tiller unreachable"))
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	appContext.reapEphemeralEnvironments(now)
+
+	mockEnvDao.AssertNotCalled(t, "DeleteEnvironment", mock.Anything)
+	mockEphemeralDAO.AssertNotCalled(t, "DeleteEphemeralEnvironment", mock.Anything)
+}
+
+func TestReapEphemeralEnvironments_Panic(t *testing.T) {
+	appContext := AppContext{}
+
+	//No expectations, the mock panics when called
+	appContext.Repositories.EphemeralEnvironmentDAO = &mockRepo.EphemeralEnvironmentDAOInterface{}
+
+	assert.NotPanics(t, func() { appContext.reapEphemeralEnvironments(time.Now()) })
+}
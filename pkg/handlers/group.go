@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+func (appContext *AppContext) listGroups(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result := &model.GroupResult{}
+	var err error
+	if result.List, err = appContext.Repositories.GroupDAO.ListGroups(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) newGroup(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.Group
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(strings.TrimSpace(payload.Name)) == 0 {
+		http.Error(w, "group name is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := appContext.Repositories.GroupDAO.CreateGroup(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "newGroup",
+		ResourceType: "group",
+		ResourceID:   payload.Name,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (appContext *AppContext) editGroup(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.Group
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if payload.ID == 0 || len(strings.TrimSpace(payload.Name)) == 0 {
+		http.Error(w, "group id and name are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := appContext.Repositories.GroupDAO.EditGroup(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "editGroup",
+		ResourceType: "group",
+		ResourceID:   strconv.Itoa(int(payload.ID)),
+		After:        "name=" + payload.Name,
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) deleteGroup(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := appContext.Repositories.GroupDAO.DeleteGroup(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "deleteGroup",
+		ResourceType: "group",
+		ResourceID:   vars["id"],
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) listGroupVariables(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := &model.GroupVariableResult{}
+	if result.List, err = appContext.Repositories.GroupDAO.ListGroupVariables(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	appContext.decryptGroupVariables(result.List)
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) saveGroupVariable(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.GroupVariable
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if payload.GroupID == 0 || len(payload.Name) == 0 {
+		http.Error(w, "group variables require a group and a name", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateVariableValue(model.Variable{Name: payload.Name, Value: payload.Value, Type: payload.Type}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if payload.Secret {
+		payload.Value = hex.EncodeToString(util.Encrypt([]byte(payload.Value), appContext.Configuration.App.Passkey))
+	}
+
+	if _, err := appContext.Repositories.GroupDAO.SaveGroupVariable(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "saveGroupVariable",
+		ResourceType: "groupVariable",
+		ResourceID:   strconv.Itoa(int(payload.GroupID)) + ":" + payload.Name,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (appContext *AppContext) deleteGroupVariable(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := appContext.Repositories.GroupDAO.DeleteGroupVariable(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "deleteGroupVariable",
+		ResourceType: "groupVariable",
+		ResourceID:   vars["id"],
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) listGroupRoles(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := &model.GroupRoleResult{}
+	if result.List, err = appContext.Repositories.GroupDAO.ListGroupRoles(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) createOrUpdateGroupRole(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.GroupRole
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if payload.GroupID == 0 || payload.UserID == 0 || payload.SecurityOperationID == 0 {
+		http.Error(w, "group roles require a group, a user and a security operation", http.StatusBadRequest)
+		return
+	}
+
+	if err := appContext.Repositories.GroupDAO.CreateOrUpdateGroupRole(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "createOrUpdateGroupRole",
+		ResourceType: "groupRole",
+		ResourceID:   strconv.Itoa(int(payload.GroupID)) + ":" + strconv.Itoa(int(payload.UserID)),
+		After:        "securityOperationId=" + strconv.Itoa(int(payload.SecurityOperationID)),
+	})
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (appContext *AppContext) deleteGroupRole(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := appContext.Repositories.GroupDAO.DeleteGroupRole(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:        principal.Email,
+		Action:       "deleteGroupRole",
+		ResourceType: "groupRole",
+		ResourceID:   vars["id"],
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+//CreateMissingGroups registers a group for every group name already used by an environment
+func CreateMissingGroups(appContext *AppContext) {
+	logFields := global.AppFields{global.Function: "CreateMissingGroups"}
+
+	environments, err := appContext.Repositories.EnvironmentDAO.GetAllEnvironments("")
+	if err != nil {
+		global.Logger.Error(logFields, "Error retrieving environments: "+err.Error())
+		return
+	}
+
+	groups, err := appContext.Repositories.GroupDAO.ListGroups()
+	if err != nil {
+		global.Logger.Error(logFields, "Error retrieving groups: "+err.Error())
+		return
+	}
+
+	existing := make(map[string]bool)
+	for _, e := range groups {
+		existing[e.Name] = true
+	}
+	for _, e := range environments {
+		if len(e.Group) == 0 || existing[e.Group] {
+			continue
+		}
+		if _, err := appContext.Repositories.GroupDAO.CreateGroup(model.Group{Name: e.Group}); err != nil {
+			global.Logger.Error(logFields, "Error creating group "+e.Group+": "+err.Error())
+			continue
+		}
+		existing[e.Group] = true
+	}
+}
+
+//inheritGroupVariables adds the variables of the environment group to its global variables,
+//the environment keeps precedence over the group for variables defined in both.
+func (appContext *AppContext) inheritGroupVariables(environment *model.Environment, variables []model.Variable) []model.Variable {
+	return mergeGroupVariables(environment, appContext.getGroupVariables(environment), variables)
+}
+
+//getGroupVariables returns the decrypted variables of the environment group
+func (appContext *AppContext) getGroupVariables(environment *model.Environment) []model.GroupVariable {
+	if len(environment.Group) == 0 {
+		return nil
+	}
+
+	groupVariables, err := appContext.Repositories.GroupDAO.ListGroupVariablesByName(environment.Group)
+	if err != nil {
+		return nil
+	}
+	appContext.decryptGroupVariables(groupVariables)
+	return groupVariables
+}
+
+func mergeGroupVariables(environment *model.Environment, groupVariables []model.GroupVariable, variables []model.Variable) []model.Variable {
+	if len(groupVariables) == 0 {
+		return variables
+	}
+
+	defined := make(map[string]bool)
+	for _, e := range variables {
+		defined[e.Name] = true
+	}
+
+	result := make([]model.Variable, 0, len(groupVariables)+len(variables))
+	for _, e := range groupVariables {
+		if defined[e.Name] {
+			continue
+		}
+		result = append(result, model.Variable{
+			EnvironmentID: int(environment.ID),
+			Scope:         globalScope,
+			Name:          e.Name,
+			Value:         e.Value,
+			Type:          e.Type,
+			Secret:        e.Secret,
+			Description:   e.Description,
+		})
+	}
+	return append(result, variables...)
+}
+
+func (appContext *AppContext) decryptGroupVariables(variables []model.GroupVariable) {
+	for i, e := range variables {
+		if e.Secret {
+			byteValues, _ := hex.DecodeString(e.Value)
+			value, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
+			if err == nil {
+				variables[i].Value = string(value)
+			}
+		}
+	}
+}
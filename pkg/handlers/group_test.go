@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getGroupPayload(payload interface{}) *bytes.Buffer {
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestListGroups(t *testing.T) {
+	appContext := AppContext{}
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("ListGroups").Return([]model.Group{{Name: "foo"}}, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+
+	req, err := http.NewRequest("GET", "/groups", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listGroups)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"name":"foo"`)
+}
+
+func TestNewGroup(t *testing.T) {
+	appContext := AppContext{}
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("CreateGroup", mock.Anything).Return(1, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "newGroup", ResourceType: "group", ResourceID: "foo"})
+
+	req, err := http.NewRequest("POST", "/groups", getGroupPayload(model.Group{Name: "foo"}))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newGroup)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	mockGroupDAO.AssertNumberOfCalls(t, "CreateGroup", 1)
+	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
+}
+
+func TestNewGroup_NameRequired(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("POST", "/groups", getGroupPayload(model.Group{Name: " "}))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newGroup)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestDeleteGroup(t *testing.T) {
+	appContext := AppContext{}
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("DeleteGroup", 1).Return(nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+	mockDoAudit(&appContext, model.AuditEvent{Action: "deleteGroup", ResourceType: "group", ResourceID: "1"})
+
+	req, err := http.NewRequest("DELETE", "/groups/1", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/groups/{id}", appContext.deleteGroup).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	mockGroupDAO.AssertNumberOfCalls(t, "DeleteGroup", 1)
+}
+
+func TestSaveGroupVariable_Secret(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("SaveGroupVariable", mock.MatchedBy(func(variable model.GroupVariable) bool {
+		return variable.GroupID == 1 && variable.Name == "password" && variable.Value != "secret"
+	})).Return(1, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+	mockDoAudit(&appContext, model.AuditEvent{Action: "saveGroupVariable", ResourceType: "groupVariable", ResourceID: "1:password"})
+
+	payload := model.GroupVariable{GroupID: 1, Name: "password", Value: "secret", Secret: true}
+	req, err := http.NewRequest("POST", "/groups/variables", getGroupPayload(payload))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveGroupVariable)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be 201.")
+	mockGroupDAO.AssertNumberOfCalls(t, "SaveGroupVariable", 1)
+}
+
+func TestCreateOrUpdateGroupRole_Invalid(t *testing.T) {
+	appContext := AppContext{}
+
+	payload := model.GroupRole{GroupID: 1, UserID: 2}
+	req, err := http.NewRequest("POST", "/groups/roles", getGroupPayload(payload))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.createOrUpdateGroupRole)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestInheritGroupVariables(t *testing.T) {
+	appContext := AppContext{}
+	mockConfiguration(&appContext)
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("ListGroupVariablesByName", "foo").Return([]model.GroupVariable{
+		{GroupID: 1, Name: "domain", Value: "foo.com"},
+		{GroupID: 1, Name: "region", Value: "us-east-1"},
+	}, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+
+	variables := []model.Variable{{EnvironmentID: 999, Scope: "global", Name: "domain", Value: "bar.com"}}
+	env := mockGetEnv()
+	result := appContext.inheritGroupVariables(&env, variables)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "region", result[0].Name)
+	assert.Equal(t, "global", result[0].Scope)
+	assert.Equal(t, "domain", result[1].Name)
+	assert.Equal(t, "bar.com", result[1].Value)
+}
+
+func TestCreateMissingGroups(t *testing.T) {
+	appContext := AppContext{}
+
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("GetAllEnvironments", "").Return([]model.Environment{
+		{Group: "foo"}, {Group: "bar"}, {Group: "bar"}, {Group: ""},
+	}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("ListGroups").Return([]model.Group{{Name: "foo"}}, nil)
+	mockGroupDAO.On("CreateGroup", model.Group{Name: "bar"}).Return(2, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+
+	CreateMissingGroups(&appContext)
+
+	mockGroupDAO.AssertNumberOfCalls(t, "CreateGroup", 1)
+}
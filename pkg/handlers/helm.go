@@ -2,14 +2,12 @@ package handlers
 
 import (
 	"bytes"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/softplan/tenkai-api/pkg/constraints"
 	"github.com/softplan/tenkai-api/pkg/global"
@@ -78,7 +76,7 @@ func (appContext *AppContext) deleteHelmRelease(w http.ResponseWriter, r *http.R
 	//Locate Environment
 	envID, _ := strconv.Atoi(environmentIDs[0])
 
-	has, err := appContext.hasAccess(principal.Email, envID)
+	has, err := appContext.hasAccess(principal, envID)
 	if err != nil || !has {
 		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
 		return
@@ -99,7 +97,11 @@ func (appContext *AppContext) deleteHelmRelease(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	purge, _ := strconv.ParseBool(purges[0])
 	err = appContext.HelmServiceAPI.DeleteHelmRelease(kubeConfig, releasesName[0], purge)
@@ -108,12 +110,23 @@ func (appContext *AppContext) deleteHelmRelease(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	auditValues := make(map[string]string)
-	auditValues["environment"] = environment.Name
-	auditValues["purge"] = strconv.FormatBool(purge)
-	auditValues["name"] = releasesName[0]
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "deleteHelmRelease",
+		EnvironmentID: environment.ID,
+		ResourceType:  "release",
+		ResourceID:    releasesName[0],
+		After:         "purge=" + strconv.FormatBool(purge),
+	})
 
-	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, principal.Email, "deleteHelmRelease", auditValues)
+	appContext.triggerWebHooks(envID, model.HookReleaseDeleted, model.WebHookReleasePayload{
+		Event:         model.HookReleaseDeleted,
+		Environment:   environment.Name,
+		EnvironmentID: environment.ID,
+		Release:       releasesName[0],
+		Purge:         purge,
+		User:          principal.Email,
+	})
 
 	w.WriteHeader(http.StatusOK)
 
@@ -130,6 +143,10 @@ func (appContext *AppContext) rollback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !inTokenScope(w, r, payload.EnvironmentID) {
+		return
+	}
+
 	//Locate Environment
 	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.EnvironmentID)
 	if err != nil {
@@ -137,7 +154,11 @@ func (appContext *AppContext) rollback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	err = appContext.HelmServiceAPI.RollbackRelease(kubeConfig, payload.ReleaseName, payload.Revision)
 	if err != nil {
@@ -145,6 +166,15 @@ func (appContext *AppContext) rollback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	appContext.triggerWebHooks(payload.EnvironmentID, model.HookRollback, model.WebHookReleasePayload{
+		Event:         model.HookRollback,
+		Environment:   environment.Name,
+		EnvironmentID: environment.ID,
+		Release:       payload.ReleaseName,
+		Revision:      payload.Revision,
+		User:          util.GetPrincipal(r).Email,
+	})
+
 	w.WriteHeader(http.StatusOK)
 
 }
@@ -160,6 +190,10 @@ func (appContext *AppContext) revision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !inTokenScope(w, r, payload.EnvironmentID) {
+		return
+	}
+
 	//Locate Environment
 	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.EnvironmentID)
 	if err != nil {
@@ -167,7 +201,11 @@ func (appContext *AppContext) revision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	yaml, err := appContext.HelmServiceAPI.Get(kubeConfig, payload.ReleaseName, payload.Revision)
 
@@ -188,6 +226,10 @@ func (appContext *AppContext) listReleaseHistory(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if !inTokenScope(w, r, payload.EnvironmentID) {
+		return
+	}
+
 	//Locate Environment
 	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.EnvironmentID)
 	if err != nil {
@@ -195,7 +237,11 @@ func (appContext *AppContext) listReleaseHistory(w http.ResponseWriter, r *http.
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	history, err := appContext.HelmServiceAPI.GetHelmReleaseHistory(kubeConfig, payload.ReleaseName)
 
@@ -214,6 +260,10 @@ func (appContext *AppContext) listHelmDeploymentsByEnvironment(w http.ResponseWr
 		return
 	}
 
+	if !inTokenScope(w, r, id) {
+		return
+	}
+
 	//Locate Environment
 	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(id)
 	if err != nil {
@@ -223,7 +273,11 @@ func (appContext *AppContext) listHelmDeploymentsByEnvironment(w http.ResponseWr
 
 	w.Header().Set(global.ContentType, global.JSONContentType)
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	result, err := appContext.HelmServiceAPI.ListHelmDeployments(kubeConfig, environment.Namespace)
 
@@ -316,7 +370,7 @@ func (appContext *AppContext) getHelmCommand(w http.ResponseWriter, r *http.Requ
 
 		command, errX := appContext.simpleInstall(environment, element, out, false, true, "", -1)
 		if errX != nil {
-			http.Error(w, err.Error(), 501)
+			http.Error(w, errX.Error(), 501)
 			return
 		}
 
@@ -420,6 +474,17 @@ func (appContext *AppContext) multipleInstall(w http.ResponseWriter, r *http.Req
 		environments = append(environments, environment)
 	}
 
+	for _, environment := range environments {
+		deployables, err := appContext.loadConfigMap(payload.Deployables, int(environment.ID))
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if !appContext.checkDeployGate(w, r, principal, environment, deployables, overrideValidation(r)) {
+			return
+		}
+	}
+
 	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
@@ -450,16 +515,11 @@ func (appContext *AppContext) multipleInstall(w http.ResponseWriter, r *http.Req
 
 			_, err = appContext.simpleInstall(environment, element, out, false, false, principal.Email, requestDeploymentID)
 			if err != nil {
-				http.Error(w, err.Error(), 501)
+				http.Error(w, err.Error(), installErrorStatus(err, 501))
 				return
 			}
 
-			auditValues := make(map[string]string)
-			auditValues["environment"] = environment.Name
-			auditValues["chartName"] = element.Chart
-			auditValues["name"] = element.Name
-
-			appContext.Auditing.DoAudit(r.Context(), appContext.Elk, principal.Email, "deploy", auditValues)
+			appContext.Auditing.DoAudit(r.Context(), appContext.Elk, deployAuditEvent(principal, environment, element))
 		}
 		if payload.ProductVersionID > 0 {
 			pv, err := appContext.Repositories.ProductDAO.ListProductVersionsByID(payload.ProductVersionID)
@@ -486,7 +546,7 @@ func (appContext *AppContext) triggerProductDeploymentWebhook(
 	var err error
 	var webHooks []model.WebHook
 	webHooks, err = appContext.Repositories.WebHookDAO.
-		ListWebHooksByEnvAndType(environmentID, "HOOK_DEPLOY_PRODUCT")
+		ListWebHooksByEnvAndType(environmentID, model.HookDeployProduct)
 	if err != nil {
 		log.Println("Error trying to find webhooks", err)
 		return
@@ -504,10 +564,8 @@ func (appContext *AppContext) triggerProductDeploymentWebhook(
 		p.ProductName = product.Name
 		p.Release = releaseVersion
 
-		payloadStr, _ := json.Marshal(p)
-		if _, err := http.Post(hook.URL, "application/json", bytes.NewBuffer(payloadStr)); err != nil {
-			log.Println("Error trying to post to webhook: ", hook.URL, err)
-			return
+		if _, err := appContext.WebHookDispatcher.Dispatch(hook, p); err != nil {
+			log.Println("Error trying to dispatch webhook: ", hook.URL, err)
 		}
 	}
 }
@@ -576,6 +634,10 @@ func (appContext *AppContext) install(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !appContext.checkDeployGate(w, r, principal, environment, deployables, overrideValidation(r)) {
+		return
+	}
+
 	user, err := appContext.Repositories.UserDAO.FindByEmail(principal.Email)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
@@ -592,7 +654,7 @@ func (appContext *AppContext) install(w http.ResponseWriter, r *http.Request) {
 		_, err = appContext.simpleInstall(environment, deployable, out, false, false, principal.Email, requestDeploymentID)
 		if err != nil {
 			fmt.Println(out.String())
-			http.Error(w, err.Error(), 501)
+			http.Error(w, err.Error(), installErrorStatus(err, 501))
 			return
 		}
 	}
@@ -623,7 +685,7 @@ func (appContext *AppContext) helmDryRun(w http.ResponseWriter, r *http.Request)
 	_, err = appContext.simpleInstall(environment, payload, out, true, false, "", -1)
 
 	if err != nil {
-		http.Error(w, err.Error(), 501)
+		http.Error(w, err.Error(), installErrorStatus(err, 501))
 		return
 	}
 
@@ -632,71 +694,53 @@ func (appContext *AppContext) helmDryRun(w http.ResponseWriter, r *http.Request)
 
 }
 
-func (appContext *AppContext) getArgsWithHelmDefault(variables []model.Variable, helmVars map[string]interface{}, globalVariables []model.Variable, environment *model.Environment) []string {
+//getInstallArgs builds the helm arguments of a deployable from its layered variables, see variableLayers.
+func (appContext *AppContext) getInstallArgs(environment *model.Environment, installPayload model.InstallPayload) ([]string, []string, map[string]interface{}, error) {
 
-	var args []string
-	var keys []string
-	for i, item := range variables {
-		if item.Secret {
-			byteValues, _ := hex.DecodeString(item.Value)
-			value, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
-			if err == nil {
-				variables[i].Value = string(value)
-			}
-		}
-		if len(item.Name) > 0 && len(item.Value) > 0 {
-			value := replace(item.Value, *environment, globalVariables)
-			if value != "" {
-				keys = append(keys, normalizeVariableName(item.Name))
-			}
-			if value == "T_EMPTY" {
-				value = ""
-			}
-			args = append(args, normalizeVariableName(item.Name)+"="+value)
-		}
+	chartValues, err := appContext.getHelmChartValues(installPayload.Chart, installPayload.ChartVersion)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	dt := time.Now()
-	args = append(args, "app.dateHour="+dt.String())
-	keys = append(keys, "app.dateHour")
+	layers, resolver, err := appContext.getVariableLayers(environment, getVariableScope(installPayload),
+		getAppValues(chartValues), installPayload.Overrides)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	args, stringArgs, err := getLayeredArgs(layers, resolver)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	for key, value := range helmVars {
-		if !util.Contains(keys, normalizeVariableName(key)) {
-			svalue, ok := value.(string)
-			if ok {
-				args = append(args, normalizeVariableName(key)+"="+replace(svalue, *environment, globalVariables))
-			}
-		}
+	//Add Default Gateway
+	if len(environment.Gateway) > 0 {
+		args = append(args, "istio.virtualservices.gateways[0]="+environment.Gateway)
 	}
 
-	return args
+	return args, stringArgs, chartValues, nil
 }
 
 func (appContext *AppContext) simpleInstall(environment *model.Environment, installPayload model.InstallPayload, out *bytes.Buffer, dryRun bool, helmCommandOnly bool, userID string, requestDeploymentID int) (string, error) {
 
 	//WARNING - VERIFY IF CONFIG FILE EXISTS !!! This is the cause of  u.client.ReleaseHistory fail sometimes.
 
-	searchTerm := installPayload.Chart
-	if strings.Index(installPayload.Name, "gcm") > -1 {
-		searchTerm = installPayload.Name
-	}
-	variables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), searchTerm)
-	globalVariables := appContext.getGlobalVariables(int(environment.ID))
-
-	helmVars, err := appContext.getHelmChartAppVars(installPayload.Chart, installPayload.ChartVersion)
+	args, stringArgs, chartValues, err := appContext.getInstallArgs(environment, installPayload)
 	if err != nil {
 		return "", err
 	}
-	args := appContext.getArgsWithHelmDefault(variables, helmVars, globalVariables, environment)
 
-	//Add Default Gateway
-	if len(environment.Gateway) > 0 {
-		args = append(args, "istio.virtualservices.gateways[0]="+environment.Gateway)
+	if !helmCommandOnly {
+		if err := appContext.checkChartSchema(environment, installPayload, chartValues, args, stringArgs); err != nil {
+			return "", err
+		}
 	}
 
 	if err == nil {
 		name := installPayload.Name + "-" + environment.Namespace
-		kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+		kubeConfig, err := appContext.getKubeConfig(environment)
+		if err != nil {
+			return "", err
+		}
 
 		if !helmCommandOnly {
 
@@ -706,6 +750,7 @@ func (appContext *AppContext) simpleInstall(environment *model.Environment, inst
 			upgradeRequest.ChartVersion = installPayload.ChartVersion
 			upgradeRequest.Chart = installPayload.Chart
 			upgradeRequest.Variables = args
+			upgradeRequest.StringVariables = stringArgs
 			upgradeRequest.Dryrun = dryRun
 			upgradeRequest.Release = name
 
@@ -720,15 +765,24 @@ func (appContext *AppContext) simpleInstall(environment *model.Environment, inst
 			deployment.Processed = false
 			deploymentID, _ := appContext.Repositories.DeploymentDAO.CreateDeployment(deployment)
 
+			credentials := *environment
+			appContext.DecryptEnvironmentCredentials(&credentials)
+
+			//Workers reading only Variables still receive the string variables
+			queueRequest := upgradeRequest
+			queueRequest.Variables = append(append([]string{}, args...), stringArgs...)
+
 			queuePayload := rabbitmq.PayloadRabbit{
-				UpgradeRequest: upgradeRequest,
+				Version:        rabbitmq.PayloadVersion,
+				UpgradeRequest: queueRequest,
+				EnvironmentID:  environment.ID,
 				Name:           environment.Name,
-				Token:          environment.Token,
-				Filename:       appContext.K8sConfigPath + environment.Group + "_" + environment.Name,
-				CACertificate:  environment.CACertificate,
+				Token:          encryptCredential(credentials.Token, appContext.getQueuePasskey()),
+				CACertificate:  encryptCredential(credentials.CACertificate, appContext.getQueuePasskey()),
 				ClusterURI:     environment.ClusterURI,
 				Namespace:      environment.Namespace,
 				DeploymentID:   uint(deploymentID),
+				Encrypted:      true,
 			}
 
 			queuePayloadJSON, _ := json.Marshal(queuePayload)
@@ -744,9 +798,13 @@ func (appContext *AppContext) simpleInstall(environment *model.Environment, inst
 					Body:        queuePayloadJSON,
 				},
 			)
+			if err == nil {
+				deployment.ID = uint(deploymentID)
+				appContext.triggerDeploymentWebHook(model.HookDeployStarted, deployment, environment.Name)
+			}
 			return "", err
 		}
-		return getHelmMessage(name, args, environment, installPayload.Chart), nil
+		return getHelmMessage(name, args, stringArgs, environment, installPayload.Chart), nil
 	}
 	return "", nil
 }
@@ -776,7 +834,7 @@ func (appContext *AppContext) doUpgrade(upgradeRequest helmapi.UpgradeRequest, o
 	return "", nil
 }
 
-func getHelmMessage(name string, args []string, environment *model.Environment, chart string) string {
+func getHelmMessage(name string, args []string, stringArgs []string, environment *model.Environment, chart string) string {
 	var message string
 
 	message = "helm upgrade --install " + name + " \\\n"
@@ -784,43 +842,16 @@ func getHelmMessage(name string, args []string, environment *model.Environment,
 	for _, e := range args {
 		message = message + " --set \"" + e + "\" " + " \\\n"
 	}
+	for _, e := range stringArgs {
+		message = message + " --set-string \"" + e + "\" " + " \\\n"
+	}
 	message = message + " " + chart + " --namespace=" + environment.Namespace
 	return message
 }
 
-func replace(value string, environment model.Environment, variables []model.Variable) string {
-	newValue := strings.Replace(value, "${NAMESPACE}", environment.Namespace, -1)
-	keywords := util.GetReplacebleKeyName(newValue)
-	for _, keyword := range keywords {
-		for _, element := range variables {
-			if element.Name == keyword {
-				newValue = strings.Replace(newValue, "${"+element.Name+"}", element.Value, -1)
-				break
-			}
-		}
-	}
-	return newValue
-}
-
 func normalizeVariableName(value string) string {
 	if strings.Index(value, "istio.") > -1 || (strings.Index(value, "image.")) > -1 || (strings.Index(value, "service.")) > -1 {
 		return value
 	}
 	return "app." + value
 }
-
-func (appContext *AppContext) getGlobalVariables(id int) []model.Variable {
-	variables, _ := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(id, "global")
-
-	for i, e := range variables {
-		if e.Secret {
-			byteValues, _ := hex.DecodeString(e.Value)
-			value, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
-			if err == nil {
-				variables[i].Value = string(value)
-			}
-		}
-	}
-
-	return variables
-}
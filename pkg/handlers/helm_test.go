@@ -16,6 +16,7 @@ import (
 	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
 	"github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
 	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	mockWebhook "github.com/softplan/tenkai-api/pkg/webhook/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -62,13 +63,10 @@ func TestDeleteHelmRelease(t *testing.T) {
 	mockHelmSvc := &mockSvc.HelmServiceInterface{}
 	mockHelmSvc.On("DeleteHelmRelease", "./config/foo_bar", "foo", false).Return(nil)
 
-	auditValues := make(map[string]string)
-	auditValues["environment"] = "bar"
-	auditValues["purge"] = "false"
-	auditValues["name"] = "foo"
-	mockAudit := mockDoAudit(&appContext, "deleteHelmRelease", auditValues)
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "deleteHelmRelease", EnvironmentID: 999,
+		ResourceType: "release", ResourceID: "foo", After: "purge=false"})
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.Repositories = Repositories{}
 	appContext.Repositories.EnvironmentDAO = mockEnvDao
@@ -83,7 +81,7 @@ func TestDeleteHelmRelease(t *testing.T) {
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockEnvDao.AssertNumberOfCalls(t, "GetAllEnvironments", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "DeleteHelmRelease", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
@@ -198,7 +196,7 @@ func TestDeleteHelmRelease_DeleteHelmReleaseError(t *testing.T) {
 	auditValues["purge"] = "false"
 	auditValues["name"] = "foo"
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.Repositories = Repositories{}
 	appContext.Repositories.EnvironmentDAO = mockEnvDao
@@ -213,7 +211,7 @@ func TestDeleteHelmRelease_DeleteHelmReleaseError(t *testing.T) {
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockEnvDao.AssertNumberOfCalls(t, "GetAllEnvironments", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "DeleteHelmRelease", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
 }
@@ -230,7 +228,7 @@ func TestRollback(t *testing.T) {
 	mockHelmSvc := &mockSvc.HelmServiceInterface{}
 	mockHelmSvc.On("RollbackRelease", "./config/foo_bar", "foo", 800).Return(nil)
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.HelmServiceAPI = mockHelmSvc
 
@@ -240,7 +238,7 @@ func TestRollback(t *testing.T) {
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "RollbackRelease", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
 }
@@ -281,7 +279,7 @@ func TestRollback_RollbackReleaseError(t *testing.T) {
 	mockHelmSvc := &mockSvc.HelmServiceInterface{}
 	mockHelmSvc.On("RollbackRelease", "./config/foo_bar", "foo", 800).Return(errors.New("some error"))
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.HelmServiceAPI = mockHelmSvc
 
@@ -291,11 +289,37 @@ func TestRollback_RollbackReleaseError(t *testing.T) {
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "RollbackRelease", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
 }
 
+func mockScopedToken(req *http.Request, environments ...int64) {
+	principal, _ := json.Marshal(model.Principal{Email: "beta@alfa.com",
+		Token: &model.TokenScope{ID: 7, Environments: environments, Policies: []string{"ACTION_DEPLOY"}}})
+	req.Header.Set("principal", string(principal))
+}
+
+func TestRollback_TokenOfAnotherEnvironment(t *testing.T) {
+	payloadStr, _ := json.Marshal(getRevision())
+	req, err := http.NewRequest("POST", "/rollback", bytes.NewBuffer(payloadStr))
+	assert.NoError(t, err)
+	mockScopedToken(req, 1000)
+
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.rollback)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 0)
+	mockHelmSvc.AssertNumberOfCalls(t, "RollbackRelease", 0)
+}
+
 func TestRevision(t *testing.T) {
 	payloadStr, _ := json.Marshal(getRevision())
 	req, err := http.NewRequest("POST", "/revision", bytes.NewBuffer(payloadStr))
@@ -309,7 +333,7 @@ func TestRevision(t *testing.T) {
 	yaml := "foo: bar"
 	mockHelmSvc.On("Get", "./config/foo_bar", "foo", 800).Return(yaml, nil)
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.HelmServiceAPI = mockHelmSvc
 
@@ -319,7 +343,7 @@ func TestRevision(t *testing.T) {
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "Get", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
 	assert.Equal(t, "\"foo: bar\"", string(rr.Body.Bytes()), "Response is not correct.")
@@ -361,7 +385,7 @@ func TestListReleaseHistory(t *testing.T) {
 
 	appContext := AppContext{}
 	mockEnvDao := mockGetByID(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	var info helmapi.ReleaseInfo
 	info.Revision = 987
@@ -383,7 +407,7 @@ func TestListReleaseHistory(t *testing.T) {
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "GetHelmReleaseHistory", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
 	assert.Equal(t, getExpecHistory(), string(rr.Body.Bytes()), "Response is not correct.")
@@ -424,7 +448,7 @@ func TestListHelmDeploymentsByEnvironment(t *testing.T) {
 
 	appContext := AppContext{}
 	mockEnvDao := mockGetByID(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockListHelmDeployments(&appContext)
 
 	rr := httptest.NewRecorder()
@@ -434,13 +458,31 @@ func TestListHelmDeploymentsByEnvironment(t *testing.T) {
 
 	mockHelmSvc.AssertNumberOfCalls(t, "ListHelmDeployments", 1)
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
 	j, _ := json.Marshal(mockHelmListResult())
 	assert.Equal(t, string(j), string(rr.Body.Bytes()), "Response is not correct.")
 }
 
+func TestListHelmDeploymentsByEnvironment_TokenOfAnotherEnvironment(t *testing.T) {
+
+	req, err := http.NewRequest("GET", "/listHelmDeploymentsByEnvironment/999", bytes.NewBuffer(nil))
+	assert.NoError(t, err)
+	mockScopedToken(req, 1000)
+
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/listHelmDeploymentsByEnvironment/{id}", appContext.listHelmDeploymentsByEnvironment).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 0)
+}
+
 func TestListHelmDeploymentsByEnvironment_StringConvError(t *testing.T) {
 
 	req, err := http.NewRequest("GET", "/listHelmDeploymentsByEnvironment/qwert", bytes.NewBuffer(nil))
@@ -484,7 +526,7 @@ func TestListHelmDeploymentsByEnvironment_ListHelmDeploymentsError(t *testing.T)
 
 	appContext := AppContext{}
 	mockEnvDao := mockGetByID(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockListHelmDeploymentsError(&appContext)
 
 	rr := httptest.NewRecorder()
@@ -494,7 +536,7 @@ func TestListHelmDeploymentsByEnvironment_ListHelmDeploymentsError(t *testing.T)
 
 	mockHelmSvc.AssertNumberOfCalls(t, "ListHelmDeployments", 1)
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
 }
@@ -579,9 +621,10 @@ func TestGetHelmCommand(t *testing.T) {
 	assert.NotNil(t, req)
 
 	appContext := AppContext{}
+	mockGroupVariables(&appContext)
 	mockEnvDao := mockGetByID(&appContext)
 	mockVariableDAO := mockGetAllVariablesByEnvironmentAndScope(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	chartValue := `{"app":{"myvar":"myvalue"}}`
 	mockHelmSvc := &mocks.HelmServiceInterface{}
@@ -595,7 +638,7 @@ func TestGetHelmCommand(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
@@ -607,6 +650,37 @@ func TestGetHelmCommand(t *testing.T) {
 	assert.Contains(t, response, "repo/my-chart - 0.1.0 --namespace=dev")
 }
 
+func TestGetHelmCommand_UndefinedReference(t *testing.T) {
+	req, err := http.NewRequest("POST", "/getHelmCommand", getMultipleInstallPayload())
+	assert.NoError(t, err)
+	assert.NotNil(t, req)
+
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockGetByID(&appContext)
+	mockKubeConfigProvider(&appContext)
+
+	variable := mockVariable()
+	variable.Value = "${undefined}"
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{mockGlobalVariable()}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, mock.Anything).Return([]model.Variable{variable}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(`{"app":{"myvar":"myvalue"}}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getHelmCommand)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, 501, rr.Code, "Response should be 501.")
+	assert.Contains(t, rr.Body.String(), "variable password: undefined variable reference ${undefined}")
+}
+
 func TestGetHelmCommand_UnmarshalPayloadError(t *testing.T) {
 	appContext := AppContext{}
 	rr := testUnmarshalPayloadErrorWithPrincipal(t, "/getHelmCommand", appContext.getHelmCommand, "tenkai-helm-upgrade")
@@ -642,6 +716,8 @@ func TestMultipleInstall(t *testing.T) {
 	charts := getCharts()
 
 	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockConfiguration(&appContext)
 
 	mockRequestDeploymentDAO := &mockRepo.RequestDeploymentDAOInterface{}
 	mockRequestDeploymentDAO.On("CreateRequestDeployment", mock.Anything).Return(1, nil)
@@ -659,7 +735,7 @@ func TestMultipleInstall(t *testing.T) {
 
 	mockEnvDao := mockGetByID(&appContext)
 	mockVariableDAO := mockGetAllVariablesByEnvironmentAndScope(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockUpgrade(&appContext)
 	mockHelmSvc.On("SearchCharts", mock.Anything, false).Return(charts)
 
@@ -704,11 +780,8 @@ func TestMultipleInstall(t *testing.T) {
 	appContext.Repositories.RequestDeploymentDAO = mockRequestDeploymentDAO
 	appContext.HelmServiceAPI = mockHelmSvc
 
-	auditValues := make(map[string]string)
-	auditValues["environment"] = "bar"
-	auditValues["chartName"] = "repo/my-chart - 0.1.0"
-	auditValues["name"] = "my-chart"
-	mockAudit := mockDoAudit(&appContext, "deploy", auditValues)
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "deploy", EnvironmentID: 999,
+		ResourceType: "release", ResourceID: "my-chart", After: "chart=repo/my-chart - 0.1.0 version=0.1.0"})
 
 	mockEnvDao.On("EditEnvironment", mock.Anything).Return(nil)
 
@@ -717,7 +790,12 @@ func TestMultipleInstall(t *testing.T) {
 	mockWebHookDAO := &mockRepo.WebHookDAOInterface{}
 	mockWebHookDAO.On("ListWebHooksByEnvAndType", 999, "HOOK_DEPLOY_PRODUCT").
 		Return(webHooks, nil)
+	mockWebHookDAO.On("ListWebHooksByEnvAndType", 999, model.HookDeployStarted).
+		Return([]model.WebHook{}, nil)
 	appContext.Repositories.WebHookDAO = mockWebHookDAO
+	mockDispatcher := &mockWebhook.DispatcherInterface{}
+	mockDispatcher.On("Dispatch", webHooks[0], model.WebHookPostPayload{Environment: "dev", ProductName: "My Product", Release: "19.0.1-0"}).Return(1, nil)
+	appContext.WebHookDispatcher = mockDispatcher
 
 	var product model.Product
 	product.ID = 999
@@ -733,15 +811,16 @@ func TestMultipleInstall(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
 	mockHelmSvc.AssertNumberOfCalls(t, "Upgrade", 0)
 	mockAudit.AssertNumberOfCalls(t, "DoAudit", 1)
 
 	mockProductDAO.AssertNumberOfCalls(t, "ListProductsVersionServices", 1)
 	mockVariableDAO.AssertNumberOfCalls(t, "GetVarImageTagByEnvAndScope", 1)
-	mockWebHookDAO.AssertNumberOfCalls(t, "ListWebHooksByEnvAndType", 1)
+	mockWebHookDAO.AssertNumberOfCalls(t, "ListWebHooksByEnvAndType", 2)
 	mockProductDAO.AssertNumberOfCalls(t, "FindProductByID", 1)
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
 }
@@ -771,6 +850,8 @@ func TestInstall(t *testing.T) {
 	charts := getCharts()
 
 	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockConfiguration(&appContext)
 
 	mockConfigDAO := &mockRepo.ConfigDAOInterface{}
 
@@ -788,7 +869,7 @@ func TestInstall(t *testing.T) {
 
 	mockEnvDao := mockGetByID(&appContext)
 	mockVariableDAO := mockGetAllVariablesByEnvironmentAndScope(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockUpgrade(&appContext)
 	mockHelmSvc.On("SearchCharts", mock.Anything, false).Return(charts)
 
@@ -817,7 +898,7 @@ func TestInstall(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
 	mockHelmSvc.AssertNumberOfCalls(t, "Upgrade", 0)
 
@@ -830,9 +911,10 @@ func TestDryRun(t *testing.T) {
 	assert.NotNil(t, req)
 
 	appContext := AppContext{}
+	mockGroupVariables(&appContext)
 	mockEnvDao := mockGetByID(&appContext)
 	mockVariableDAO := mockGetAllVariablesByEnvironmentAndScope(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockUpgrade(&appContext)
 	mockHelmSvc.On("SearchCharts", mock.Anything, false).Return(getCharts())
 
@@ -855,7 +937,7 @@ func TestDryRun(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
 	mockHelmSvc.AssertNumberOfCalls(t, "Upgrade", 1)
 
@@ -27,7 +27,11 @@ func (appContext *AppContext) services(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	services, err := appContext.HelmServiceAPI.GetServices(kubeConfig, environment.Namespace)
 	if err != nil {
@@ -60,7 +64,11 @@ func (appContext *AppContext) pods(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	pods, err := appContext.HelmServiceAPI.GetPods(kubeConfig, environment.Namespace)
 	if err != nil {
@@ -101,7 +109,7 @@ func (appContext *AppContext) deletePod(w http.ResponseWriter, r *http.Request)
 	//Locate Environment
 	envID, _ := strconv.Atoi(environmentIDs[0])
 
-	has, err := appContext.hasAccess(principal.Email, envID)
+	has, err := appContext.hasAccess(principal, envID)
 	if err != nil || !has {
 		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
 		return
@@ -122,7 +130,11 @@ func (appContext *AppContext) deletePod(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	err = appContext.HelmServiceAPI.DeletePod(kubeConfig, podName[0], environment.Namespace)
 	if err != nil {
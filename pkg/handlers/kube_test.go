@@ -14,7 +14,7 @@ func TestServices(t *testing.T) {
 	appContext := AppContext{}
 
 	mockEnvDao := mockEnvDaoWithLotOfThings(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockHelmSvcWithLotOfThings(&appContext)
 
 	appContext.Repositories = Repositories{}
@@ -31,7 +31,7 @@ func TestServices(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 }
 
@@ -40,7 +40,7 @@ func TestPods(t *testing.T) {
 	appContext := AppContext{}
 
 	mockEnvDao := mockEnvDaoWithLotOfThings(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockHelmSvcWithLotOfThings(&appContext)
 
 	appContext.Repositories = Repositories{}
@@ -57,7 +57,7 @@ func TestPods(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 }
 
@@ -66,7 +66,7 @@ func TestDeletePods(t *testing.T) {
 	appContext := AppContext{}
 
 	mockEnvDao := mockEnvDaoWithLotOfThings(&appContext)
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockHelmSvcWithLotOfThings(&appContext)
 
 	appContext.Repositories = Repositories{}
@@ -85,6 +85,6 @@ func TestDeletePods(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 }
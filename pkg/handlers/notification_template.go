@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/softplan/tenkai-api/pkg/webhook"
+)
+
+//listNotificationTemplates lists the message templates of every event of the chat channels,
+//the stored ones replacing the defaults
+func (appContext *AppContext) listNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	stored, err := appContext.Repositories.NotificationTemplateDAO.ListNotificationTemplates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	custom := make(map[string]model.NotificationTemplate)
+	for _, t := range stored {
+		t.Custom = true
+		custom[t.Channel+"/"+t.Event] = t
+	}
+
+	events := webhook.Events()
+	sort.Strings(events)
+
+	result := &model.NotificationTemplateResponse{List: make([]model.NotificationTemplate, 0)}
+	for _, channel := range []string{model.ChannelSlack, model.ChannelTeams} {
+		for _, event := range events {
+			t, ok := custom[channel+"/"+event]
+			if !ok {
+				t = model.NotificationTemplate{Channel: channel, Event: event, Template: webhook.DefaultTemplate(event)}
+			}
+			result.List = append(result.List, t)
+		}
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) saveNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.NotificationTemplate
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !util.Contains(webhook.Events(), payload.Event) {
+		http.Error(w, "unknown event "+payload.Event, http.StatusBadRequest)
+		return
+	}
+	if err := webhook.ValidateTemplate(payload.Channel, payload.Template); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := appContext.Repositories.NotificationTemplateDAO.CreateOrUpdateNotificationTemplate(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (appContext *AppContext) deleteNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := appContext.Repositories.NotificationTemplateDAO.DeleteNotificationTemplate(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockNotificationTemplate() model.NotificationTemplate {
+	var item model.NotificationTemplate
+	item.Channel = model.ChannelSlack
+	item.Event = model.HookDeployFinished
+	item.Template = `{{bold .environment}} done`
+	return item
+}
+
+func TestListNotificationTemplates(t *testing.T) {
+	appContext := AppContext{}
+
+	stored := mockNotificationTemplate()
+	stored.ID = 1
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	mockTemplateDAO.On("ListNotificationTemplates").Return([]model.NotificationTemplate{stored}, nil)
+	appContext.Repositories.NotificationTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("GET", "/notificationTemplates", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listNotificationTemplates)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+
+	var response model.NotificationTemplateResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.List, 2*len(webhook.Events()))
+
+	custom := 0
+	for _, item := range response.List {
+		if item.Custom {
+			custom++
+			assert.Equal(t, stored.Template, item.Template)
+			assert.Equal(t, model.ChannelSlack, item.Channel)
+		} else {
+			assert.Equal(t, webhook.DefaultTemplate(item.Event), item.Template)
+		}
+	}
+	assert.Equal(t, 1, custom)
+}
+
+func TestListNotificationTemplates_Error(t *testing.T) {
+	appContext := AppContext{}
+
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	mockTemplateDAO.On("ListNotificationTemplates").Return(nil, errors.New("some error"))
+	appContext.Repositories.NotificationTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("GET", "/notificationTemplates", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listNotificationTemplates)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
+}
+
+func TestSaveNotificationTemplate(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockNotificationTemplate()
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	mockTemplateDAO.On("CreateOrUpdateNotificationTemplate", p).Return(nil)
+	appContext.Repositories.NotificationTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("POST", "/notificationTemplates", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveNotificationTemplate)
+	handler.ServeHTTP(rr, req)
+
+	mockTemplateDAO.AssertNumberOfCalls(t, "CreateOrUpdateNotificationTemplate", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+}
+
+func TestSaveNotificationTemplate_InvalidTemplate(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockNotificationTemplate()
+	p.Template = `{{.environment`
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	appContext.Repositories.NotificationTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("POST", "/notificationTemplates", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveNotificationTemplate)
+	handler.ServeHTTP(rr, req)
+
+	mockTemplateDAO.AssertNumberOfCalls(t, "CreateOrUpdateNotificationTemplate", 0)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestSaveNotificationTemplate_UnknownEvent(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockNotificationTemplate()
+	p.Event = "HOOK_UNKNOWN"
+
+	req, err := http.NewRequest("POST", "/notificationTemplates", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveNotificationTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestSaveNotificationTemplate_AccessDenied(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("POST", "/notificationTemplates", payload(mockNotificationTemplate()))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveNotificationTemplate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestDeleteNotificationTemplate(t *testing.T) {
+	appContext := AppContext{}
+
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	mockTemplateDAO.On("DeleteNotificationTemplate", 999).Return(nil)
+	appContext.Repositories.NotificationTemplateDAO = mockTemplateDAO
+
+	req, err := http.NewRequest("DELETE", "/notificationTemplates/999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/notificationTemplates/{id}", appContext.deleteNotificationTemplate).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	mockTemplateDAO.AssertNumberOfCalls(t, "DeleteNotificationTemplate", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+}
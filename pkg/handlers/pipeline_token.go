@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//pipelineTokenPrefix tells the pipeline tokens apart from the JWTs
+const pipelineTokenPrefix = "tkp_"
+
+//generateToken returns a random token with the given prefix
+func generateToken(prefix string) (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(data), nil
+}
+
+//hashToken returns the hash stored in place of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+//bearerToken returns the token of the Authorization header
+func bearerToken(r *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+func (appContext *AppContext) listPipelineTokens(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	result := &model.PipelineTokenResponse{}
+	var err error
+	if result.List, err = appContext.Repositories.PipelineTokenDAO.ListPipelineTokens(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (appContext *AppContext) newPipelineToken(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.PipelineToken
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(payload.Name) == 0 {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range payload.Scopes {
+		if scope != model.PipelineScopeDeploy && scope != model.PipelineScopeRegistry {
+			http.Error(w, "invalid scope "+scope, http.StatusBadRequest)
+			return
+		}
+	}
+	if pipelineHasScope(payload, model.PipelineScopeDeploy) && len(payload.Environments) == 0 {
+		http.Error(w, "environments are required to deploy", http.StatusBadRequest)
+		return
+	}
+
+	token, err := generateToken(pipelineTokenPrefix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payload.TokenHash = hashToken(token)
+	payload.CreatedBy = principal.Email
+
+	id, err := appContext.Repositories.PipelineTokenDAO.CreatePipelineToken(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(model.NewPipelineTokenResponse{ID: id, Token: token})
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (appContext *AppContext) deletePipelineToken(w http.ResponseWriter, r *http.Request) {
+
+	principal := util.GetPrincipal(r)
+	if !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := appContext.Repositories.PipelineTokenDAO.DeletePipelineToken(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewPipelineToken(t *testing.T) {
+	appContext := AppContext{}
+
+	var stored model.PipelineToken
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("CreatePipelineToken", mock.Anything).Return(1, nil).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(model.PipelineToken)
+	})
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+
+	p := model.PipelineToken{Name: "my-pipeline", Environments: []int64{999}}
+	req, err := http.NewRequest("POST", "/pipelineTokens", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newPipelineToken)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be Created.")
+
+	var response model.NewPipelineTokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.ID)
+	assert.True(t, strings.HasPrefix(response.Token, pipelineTokenPrefix))
+	assert.Equal(t, hashToken(response.Token), stored.TokenHash)
+	assert.Equal(t, "beta@alfa.com", stored.CreatedBy)
+}
+
+func TestNewPipelineToken_MissingEnvironments(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("POST", "/pipelineTokens", payload(model.PipelineToken{Name: "my-pipeline"}))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newPipelineToken)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
+
+func TestListPipelineTokens(t *testing.T) {
+	appContext := AppContext{}
+
+	token := model.PipelineToken{Name: "my-pipeline", TokenHash: "secret-hash"}
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("ListPipelineTokens").Return([]model.PipelineToken{token}, nil)
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+
+	req, err := http.NewRequest("GET", "/pipelineTokens", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listPipelineTokens)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), "my-pipeline")
+	assert.NotContains(t, rr.Body.String(), "secret-hash")
+}
+
+func TestListPipelineTokens_AccessDenied(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("GET", "/pipelineTokens", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listPipelineTokens)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestDeletePipelineToken(t *testing.T) {
+	appContext := AppContext{}
+
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("DeletePipelineToken", 999).Return(nil)
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+
+	req, err := http.NewRequest("DELETE", "/pipelineTokens/999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/pipelineTokens/{id}", appContext.deletePipelineToken).Methods("DELETE")
+	r.ServeHTTP(rr, req)
+
+	mockPipelineTokenDAO.AssertNumberOfCalls(t, "DeletePipelineToken", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+}
+
+func TestNewPipelineToken_RegistryScope(t *testing.T) {
+	appContext := AppContext{}
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("CreatePipelineToken", mock.Anything).Return(1, nil)
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+
+	p := model.PipelineToken{Name: "my-registry", Scopes: []string{model.PipelineScopeRegistry}}
+	req, err := http.NewRequest("POST", "/pipelineTokens", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newPipelineToken)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Response should be Created.")
+}
+
+func TestNewPipelineToken_InvalidScope(t *testing.T) {
+	appContext := AppContext{}
+
+	p := model.PipelineToken{Name: "my-pipeline", Environments: []int64{999}, Scopes: []string{"admin"}}
+	req, err := http.NewRequest("POST", "/pipelineTokens", payload(p))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newPipelineToken)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -121,7 +120,7 @@ func (appContext *AppContext) triggerNewReleaseWebhook(productID int, release st
 	var err error
 	var webHooks []model.WebHook
 	webHooks, err = appContext.Repositories.WebHookDAO.
-		ListWebHooksByEnvAndType(-1, "HOOK_NEW_RELEASE")
+		ListWebHooksByEnvAndType(-1, model.HookNewRelease)
 	if err != nil {
 		log.Println("Error trying to find webhooks", err)
 		return
@@ -150,9 +149,8 @@ func (appContext *AppContext) triggerNewReleaseWebhook(productID int, release st
 		p.Release = release
 		p.AdditionalData = hook.AdditionalData
 		p.Services = services
-		payloadStr, _ := json.Marshal(p)
-		if _, err := http.Post(hook.URL, "application/json", bytes.NewBuffer(payloadStr)); err != nil {
-			log.Println("Error trying to post to webhook: ", hook.URL, err)
+		if _, err := appContext.WebHookDispatcher.Dispatch(hook, p); err != nil {
+			log.Println("Error trying to dispatch webhook: ", hook.URL, err)
 		}
 	}
 
@@ -366,9 +364,29 @@ func (appContext *AppContext) lockProductVersion(w http.ResponseWriter, r *http.
 		return
 	}
 
+	appContext.triggerProductVersionLockedWebHook(pv, util.GetPrincipal(r).Email)
+
 	w.WriteHeader(http.StatusOK)
 }
 
+func (appContext *AppContext) triggerProductVersionLockedWebHook(pv *model.ProductVersion, user string) {
+	if appContext.WebHookDispatcher == nil {
+		return
+	}
+
+	var productName string
+	if product, err := appContext.Repositories.ProductDAO.FindProductByID(pv.ProductID); err == nil {
+		productName = product.Name
+	}
+	appContext.triggerWebHooks(-1, model.HookProductVersionLocked, model.WebHookProductVersionPayload{
+		Event:            model.HookProductVersionLocked,
+		ProductName:      productName,
+		Release:          pv.Version,
+		ProductVersionID: pv.ID,
+		User:             user,
+	})
+}
+
 func (appContext *AppContext) unlockProductVersion(w http.ResponseWriter, r *http.Request) {
 	pv, httpCode, err := appContext.lockUnlockCommon(w, r)
 	if err != nil {
@@ -421,6 +439,7 @@ func (appContext *AppContext) listProductVersionServices(w http.ResponseWriter,
 
 			var serviceName = e.ServiceName
 			var tag = e.DockerImageTag
+			var availableTag = e.AvailableTag
 			var helmRepo = splitChartRepo(serviceName)
 			index := i
 
@@ -429,14 +448,18 @@ func (appContext *AppContext) listProductVersionServices(w http.ResponseWriter,
 			}
 
 			wg.Add(1)
-			go func(wg *sync.WaitGroup, serviceName string, tag string, index int,
+			go func(wg *sync.WaitGroup, serviceName string, tag string, availableTag string, index int,
 				searchResult []model.SearchResult, productVersion string, hotFix bool) {
 
 				defer wg.Done()
-				version, _ := appContext.verifyNewVersion(splitSrvNameIfNeeded(serviceName), tag, productVersion, hotFix)
+				//A tag notified by the registry spares polling it
+				version := availableTag
+				if version == "" || version == tag {
+					version, _ = appContext.verifyNewVersion(splitSrvNameIfNeeded(serviceName), tag, productVersion, hotFix)
+				}
 				result.List[index].LatestVersion = version
 				result.List[index].ChartLatestVersion = appContext.getChartLatestVersion(serviceName, searchResult)
-			}(wg, serviceName, tag, index, helmCharts[helmRepo], pv.Version, pv.HotFix)
+			}(wg, serviceName, tag, availableTag, index, helmCharts[helmRepo], pv.Version, pv.HotFix)
 		}
 	}
 
@@ -12,6 +12,7 @@ import (
 	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
 	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
 	"github.com/softplan/tenkai-api/pkg/service/docker/mocks"
+	mockWebhook "github.com/softplan/tenkai-api/pkg/webhook/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -207,7 +208,7 @@ func TestListProducts(t *testing.T) {
 	response := string(rr.Body.Bytes())
 	assert.Contains(t, response, `{"list":[{"ID":999,`)
 	assert.Contains(t, response, `"name":"my-product",`)
-	assert.Contains(t, response, `"validateReleases":true}]}`)
+	assert.Contains(t, response, `"validateReleases":true,"autoUpdateTags":false}]}`)
 }
 
 func TestListProducts_Error(t *testing.T) {
@@ -245,6 +246,9 @@ func TestNewProductVersion(t *testing.T) {
 	mockWebHookDAO.On("ListWebHooksByEnvAndType", -1, "HOOK_NEW_RELEASE").
 		Return(webHooks, nil)
 	appContext.Repositories.WebHookDAO = mockWebHookDAO
+	mockDispatcher := &mockWebhook.DispatcherInterface{}
+	mockDispatcher.On("Dispatch", webHooks[0], mock.Anything).Return(1, nil)
+	appContext.WebHookDispatcher = mockDispatcher
 
 	var product model.Product
 	product.ID = 999
@@ -67,14 +67,14 @@ func (appContext *AppContext) retrieveSrcAndTargetEnv(w http.ResponseWriter, pri
 		return nil, nil, err
 	}
 
-	has, err := appContext.hasAccess(principal.Email, int(srcEnvironment.ID))
+	has, err := appContext.hasAccess(principal, int(srcEnvironment.ID))
 	if err != nil || !has {
 		newErr := errors.New("Access Denied in environment " + srcEnvironment.Namespace)
 		http.Error(w, newErr.Error(), http.StatusUnauthorized)
 		return nil, nil, newErr
 	}
 
-	has, err = appContext.hasAccess(principal.Email, int(targetEnvironment.ID))
+	has, err = appContext.hasAccess(principal, int(targetEnvironment.ID))
 	if err != nil || !has {
 		newErr := errors.New("Access Denied in environment " + targetEnvironment.Namespace)
 		http.Error(w, newErr.Error(), http.StatusUnauthorized)
@@ -106,7 +106,11 @@ func (appContext *AppContext) promote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(srcEnvironment.Group, srcEnvironment.Name)
+	kubeConfig, err := appContext.getKubeConfig(srcEnvironment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	if mode == "full" {
 
@@ -144,14 +148,40 @@ func (appContext *AppContext) promote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	appContext.doIt(kubeConfig, targetEnvironment, toPurge, toDeploy, principal)
+	if targetEnvironment.BlockOnInvalidVariables {
+		repository, err := appContext.getModelRepositoryDefault(principal)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var deployables []model.InstallPayload
+		for _, e := range toDeploy {
+			deployables = append(deployables, model.InstallPayload{Chart: addRepoPrefix(e.Chart, repository), Name: e.Name})
+		}
+		if !appContext.checkDeployGate(w, r, principal, targetEnvironment, deployables, overrideValidation(r)) {
+			return
+		}
+	}
 
-	auditValues := make(map[string]string)
-	auditValues["sourceEnvironment"] = srcEnvironment.Name
-	auditValues["targetEnvironment"] = targetEnvironment.Name
-	auditValues["mode"] = mode
+	if err := appContext.doIt(kubeConfig, targetEnvironment, toPurge, toDeploy, principal); err == nil {
+		appContext.triggerWebHooks(int(targetEnvironment.ID), model.HookPromotionCompleted, model.WebHookPromotionPayload{
+			Event:             model.HookPromotionCompleted,
+			SourceEnvironment: srcEnvironment.Name,
+			Environment:       targetEnvironment.Name,
+			EnvironmentID:     targetEnvironment.ID,
+			Mode:              mode,
+			User:              principal.Email,
+		})
+	}
 
-	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, principal.Email, "promote", auditValues)
+	appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+		Actor:         principal.Email,
+		Action:        "promote",
+		EnvironmentID: targetEnvironment.ID,
+		ResourceType:  "environment",
+		ResourceID:    targetEnvironment.Name,
+		After:         "source=" + srcEnvironment.Name + " mode=" + mode,
+	})
 
 	w.WriteHeader(http.StatusOK)
 
@@ -17,9 +17,10 @@ import (
 func doTest(t *testing.T, mode string) {
 
 	appContext := AppContext{}
+	mockConfiguration(&appContext)
 
 	mockEnvDao := mockEnvDaoWithLotOfThings(&appContext)
-	mockConventionInterface(&appContext)
+	mockKubeConfigProvider(&appContext)
 
 	mockVariableDAO := mockVariableDAOWithLotOfThings(&appContext)
 
@@ -30,7 +31,7 @@ func doTest(t *testing.T, mode string) {
 	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(chartValue), nil)
 
 	auditSvc := &mockAudit.AuditingInterface{}
-	auditSvc.On("DoAudit", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	auditSvc.On("DoAudit", mock.Anything, mock.Anything, mock.Anything)
 
 	configDAO := mocks.ConfigDAOInterface{}
 	var configMap model.ConfigMap
@@ -49,6 +50,7 @@ func doTest(t *testing.T, mode string) {
 	appContext.Repositories.DeploymentDAO = mockDeploymentDAO
 	appContext.Repositories.EnvironmentDAO = mockEnvDao
 	appContext.Repositories.VariableDAO = mockVariableDAO
+	mockGroupVariables(&appContext)
 	appContext.HelmServiceAPI = mockHelmSvc
 	appContext.Auditing = auditSvc
 	appContext.RabbitImpl = getMockRabbitMQ()
@@ -99,6 +101,7 @@ func TestPromote_Unauthorized(t *testing.T) {
 func doTestParamsError(t *testing.T, url string) {
 
 	appContext := AppContext{}
+	mockGroupVariables(&appContext)
 
 	req, err := http.NewRequest("GET", url, nil)
 	assert.NoError(t, err)
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+const registryPushAction = "push"
+
+//Harbor 2.x sends PUSH_ARTIFACT, Harbor 1.x sends pushImage
+var harborPushTypes = []string{"PUSH_ARTIFACT", "pushImage"}
+
+//pushedTags returns the tags pushed in a Docker Registry v2 or Harbor notification
+func pushedTags(payload model.RegistryHookPayload) []model.PushedTag {
+	result := make([]model.PushedTag, 0)
+	for _, e := range payload.Events {
+		//Blob pushes and pushes by digest have no tag
+		if e.Action == registryPushAction && len(e.Target.Tag) > 0 {
+			result = append(result, model.PushedTag{Repository: e.Target.Repository, Tag: e.Target.Tag})
+		}
+	}
+	if payload.EventData != nil && util.Contains(harborPushTypes, payload.Type) {
+		for _, e := range payload.EventData.Resources {
+			if len(e.Tag) > 0 {
+				result = append(result, model.PushedTag{Repository: payload.EventData.Repository.RepoFullName, Tag: e.Tag})
+			}
+		}
+	}
+	return result
+}
+
+//imageMatches compares the image of a chart, which may include the registry host, with a pushed repository
+func imageMatches(image string, repository string) bool {
+	return len(repository) > 0 && (image == repository || strings.HasSuffix(image, "/"+repository))
+}
+
+//tagAfter tells whether a tag sorts after another, comparing their dot or dash separated parts as numbers
+func tagAfter(tag string, other string) bool {
+	a := strings.Split(normalize(tag), ".")
+	b := strings.Split(normalize(other), ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		if errX == nil && errY == nil {
+			return x > y
+		}
+		return a[i] > b[i]
+	}
+	return len(a) > len(b)
+}
+
+//pushedChartTags returns the tags pushed to the image of a chart. The image is resolved by getImageName,
+//which only asks helm when the chart image cache misses, so a push is matched on any replica, even one
+//that just started.
+func (appContext *AppContext) pushedChartTags(chart string, tags []model.PushedTag) []model.PushedTag {
+	image, err := appContext.getImageName(chart)
+	if err != nil || len(image) == 0 {
+		return nil
+	}
+	var result []model.PushedTag
+	for _, t := range tags {
+		if imageMatches(image, t.Repository) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+//registryPush records the tags pushed to a registry as the latest available tag of the matching services
+//of unlocked product versions, bumping their tag when the product updates tags automatically
+func (appContext *AppContext) registryPush(w http.ResponseWriter, r *http.Request) {
+
+	pipeline, err := appContext.authenticatePipeline(r, model.PipelineScopeRegistry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pipeline == nil {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload model.RegistryHookPayload
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := &model.ProductVersionServiceRequestReponse{List: make([]model.ProductVersionService, 0)}
+
+	if tags := pushedTags(payload); len(tags) > 0 {
+		products, err := appContext.Repositories.ProductDAO.ListProducts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, p := range products {
+			updated, err := appContext.updateProductTags(p, tags)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result.List = append(result.List, updated...)
+		}
+	}
+
+	data, _ := json.Marshal(result)
+	w.Header().Set(global.ContentType, global.JSONContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+//updateProductTags records the newest pushed tag of the product version that sorts after the current
+//and available tags of a service, so an older build never lowers them
+func (appContext *AppContext) updateProductTags(product model.Product,
+	pushed []model.PushedTag) ([]model.ProductVersionService, error) {
+
+	result := make([]model.ProductVersionService, 0)
+
+	versions, err := appContext.Repositories.ProductDAO.ListProductsVersions(int(product.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pv := range versions {
+		if pv.Locked {
+			continue
+		}
+
+		services, err := appContext.Repositories.ProductDAO.ListProductsVersionServices(int(pv.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range services {
+			tags := appContext.pushedChartTags(splitSrvNameIfNeeded(s.ServiceName), pushed)
+			if len(tags) == 0 {
+				continue
+			}
+
+			latest := s.DockerImageTag
+			if tagAfter(s.AvailableTag, latest) {
+				latest = s.AvailableTag
+			}
+			changed := false
+			for _, t := range tags {
+				if !appContext.validateVersion(pv.Version, t.Tag) || !tagAfter(t.Tag, latest) {
+					continue
+				}
+				latest = t.Tag
+				changed = true
+			}
+
+			if changed {
+				s.AvailableTag = latest
+				if product.AutoUpdateTags {
+					s.DockerImageTag = latest
+				}
+				if err := appContext.Repositories.ProductDAO.UpdateProductVersionServiceTags(s.ID,
+					s.AvailableTag, s.DockerImageTag); err != nil {
+					return nil, err
+				}
+				s.ProductVersionID = int(pv.ID)
+				result = append(result, s)
+			}
+		}
+	}
+	return result, nil
+}
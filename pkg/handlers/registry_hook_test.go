@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testRegistryToken = "tkp_9876543210"
+
+func mockRegistryToken(appContext *AppContext) *mockRepo.PipelineTokenDAOInterface {
+	deploy := model.PipelineToken{Name: "my-pipeline", Environments: []int64{999}}
+	registry := model.PipelineToken{Name: "my-registry", Scopes: []string{model.PipelineScopeRegistry}}
+	mockPipelineTokenDAO := &mockRepo.PipelineTokenDAOInterface{}
+	mockPipelineTokenDAO.On("FindPipelineTokenByHash", hashToken(testPipelineToken)).Return(&deploy, nil)
+	mockPipelineTokenDAO.On("FindPipelineTokenByHash", hashToken(testRegistryToken)).Return(&registry, nil)
+	mockPipelineTokenDAO.On("FindPipelineTokenByHash", mock.Anything).Return(nil, nil)
+	appContext.Repositories.PipelineTokenDAO = mockPipelineTokenDAO
+	return mockPipelineTokenDAO
+}
+
+func mockRegistryProducts(appContext *AppContext, autoUpdateTags bool) *mockRepo.ProductDAOInterface {
+	product := model.Product{Name: "my-product", AutoUpdateTags: autoUpdateTags}
+	product.ID = 1
+
+	unlocked := model.ProductVersion{ProductID: 1, Version: "19.3.0-0"}
+	unlocked.ID = 10
+	locked := model.ProductVersion{ProductID: 1, Version: "19.3.0-0", Locked: true}
+	locked.ID = 11
+
+	service := model.ProductVersionService{ServiceName: "repo/my-chart - 0.1.0", DockerImageTag: "19.3.0-0"}
+	service.ID = 100
+	other := model.ProductVersionService{ServiceName: "repo/other-chart - 0.1.0", DockerImageTag: "19.3.0-0"}
+	other.ID = 101
+
+	appContext.ChartImageCache.Store("repo/my-chart", "myrepo.com/saj6/my-chart")
+	appContext.ChartImageCache.Store("repo/other-chart", "myrepo.com/saj6/other-chart")
+
+	mockProductDAO := &mockRepo.ProductDAOInterface{}
+	mockProductDAO.On("ListProducts").Return([]model.Product{product}, nil)
+	mockProductDAO.On("ListProductsVersions", 1).Return([]model.ProductVersion{unlocked, locked}, nil)
+	mockProductDAO.On("ListProductsVersionServices", 10).Return([]model.ProductVersionService{service, other}, nil)
+	appContext.Repositories.ProductDAO = mockProductDAO
+	return mockProductDAO
+}
+
+func getRegistryPushPayload(tags ...string) *bytes.Buffer {
+	payload := model.RegistryHookPayload{}
+	for _, tag := range tags {
+		payload.Events = append(payload.Events, model.RegistryEvent{
+			Action: "push",
+			Target: model.RegistryEventTarget{Repository: "saj6/my-chart", Tag: tag},
+		})
+	}
+	//Layers are pushed without a tag
+	payload.Events = append(payload.Events, model.RegistryEvent{
+		Action: "push",
+		Target: model.RegistryEventTarget{Repository: "saj6/my-chart"},
+	})
+	data, _ := json.Marshal(payload)
+	return bytes.NewBuffer(data)
+}
+
+func TestRegistryPush(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-1", "20.1.0-0"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testRegistryToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := mockRegistryProducts(&appContext, false)
+	mockProductDAO.On("UpdateProductVersionServiceTags", uint(100), "19.3.0-1", "19.3.0-0").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockProductDAO.AssertNumberOfCalls(t, "UpdateProductVersionServiceTags", 1)
+	mockProductDAO.AssertNotCalled(t, "ListProductsVersionServices", 11)
+
+	var response model.ProductVersionServiceRequestReponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.Equal(t, 1, len(response.List))
+	assert.Equal(t, 10, response.List[0].ProductVersionID)
+	assert.Equal(t, "19.3.0-1", response.List[0].AvailableTag)
+}
+
+func TestRegistryPush_AutoUpdateTags(t *testing.T) {
+	payload := model.RegistryHookPayload{
+		Type: "PUSH_ARTIFACT",
+		EventData: &model.HarborEventData{
+			Resources:  []model.HarborResource{{Tag: "19.3.0-2", ResourceURL: "myrepo.com/saj6/my-chart:19.3.0-2"}},
+			Repository: model.HarborRepository{Name: "my-chart", Namespace: "saj6", RepoFullName: "saj6/my-chart"},
+		},
+	}
+	data, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", "/hooks/registry", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testRegistryToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := mockRegistryProducts(&appContext, true)
+	mockProductDAO.On("UpdateProductVersionServiceTags", uint(100), "19.3.0-2", "19.3.0-2").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockProductDAO.AssertNumberOfCalls(t, "UpdateProductVersionServiceTags", 1)
+}
+
+func TestRegistryPush_Unauthorized(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-1"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer tkp_unknown")
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := &mockRepo.ProductDAOInterface{}
+	appContext.Repositories.ProductDAO = mockProductDAO
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	mockProductDAO.AssertNotCalled(t, "ListProducts")
+}
+
+func TestRegistryPush_DeployTokenNotAllowed(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-1"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testPipelineToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := &mockRepo.ProductDAOInterface{}
+	appContext.Repositories.ProductDAO = mockProductDAO
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	mockProductDAO.AssertNotCalled(t, "ListProducts")
+}
+
+func TestRegistryPush_KeepsNewestTag(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-3", "19.3.0-1", "19.3.0-2"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testRegistryToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := mockRegistryProducts(&appContext, true)
+	mockProductDAO.On("UpdateProductVersionServiceTags", uint(100), "19.3.0-3", "19.3.0-3").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockProductDAO.AssertNumberOfCalls(t, "UpdateProductVersionServiceTags", 1)
+}
+
+func TestRegistryPush_IgnoresOlderTag(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-0", "19.3.0"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testRegistryToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := mockRegistryProducts(&appContext, true)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockProductDAO.AssertNotCalled(t, "UpdateProductVersionServiceTags", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRegistryPush_EmptyImageCache(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hooks/registry", getRegistryPushPayload("19.3.0-1"))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testRegistryToken)
+
+	appContext := AppContext{}
+	mockRegistryToken(&appContext)
+	mockProductDAO := mockRegistryProducts(&appContext, false)
+	appContext.ChartImageCache.Delete("repo/my-chart")
+	appContext.ChartImageCache.Delete("repo/other-chart")
+	mockProductDAO.On("UpdateProductVersionServiceTags", uint(100), "19.3.0-1", "19.3.0-0").Return(nil)
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetValues", "repo/my-chart", "0").Return([]byte(`{"image":{"repository":"myrepo.com/saj6/my-chart"}}`), nil)
+	mockHelmSvc.On("GetValues", "repo/other-chart", "0").Return([]byte(`{"image":{"repository":"myrepo.com/saj6/other-chart"}}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.registryPush)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockProductDAO.AssertNumberOfCalls(t, "UpdateProductVersionServiceTags", 1)
+	image, _ := appContext.ChartImageCache.Load("repo/my-chart")
+	assert.Equal(t, "myrepo.com/saj6/my-chart", image)
+}
+
+func TestTagAfter(t *testing.T) {
+	assert.True(t, tagAfter("19.3.0-10", "19.3.0-9"))
+	assert.True(t, tagAfter("19.3.0-1", "19.3.0"))
+	assert.True(t, tagAfter("19.3.0-1", ""))
+	assert.False(t, tagAfter("19.3.0-1", "19.3.0-1"))
+	assert.False(t, tagAfter("19.3.0-0", "19.3.0-1"))
+	assert.True(t, tagAfter("19.3.0-RC-2", "19.3.0-RC-1"))
+}
+
+func TestImageMatches(t *testing.T) {
+	assert.True(t, imageMatches("myrepo.com/saj6/my-chart", "saj6/my-chart"))
+	assert.True(t, imageMatches("saj6/my-chart", "saj6/my-chart"))
+	assert.False(t, imageMatches("myrepo.com/saj6/my-chart-api", "saj6/my-chart"))
+	assert.False(t, imageMatches("myrepo.com/saj6/my-chart", ""))
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	mockAud "github.com/softplan/tenkai-api/pkg/audit/mocks"
+	"github.com/softplan/tenkai-api/pkg/configs"
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
 	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
@@ -27,6 +28,15 @@ func mockPrincipal(req *http.Request) {
 	req.Header.Set("principal", string(pSe))
 }
 
+//mockConfiguration sets a configuration with a passkey to be used only for testing.
+func mockConfiguration(appContext *AppContext) {
+	appContext.Configuration = &configs.Configuration{
+		App: configs.App{
+			Passkey: "qwert",
+		},
+	}
+}
+
 //mockGetByID mocks a call to GetByID function to be used only for testing.
 func mockGetByID(appContext *AppContext) *mockRepo.EnvironmentDAOInterface {
 	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
@@ -94,9 +104,10 @@ func mockVariable() model.Variable {
 }
 
 //mockDoAudit mocks a call to DoAudit function to be used only for testing.
-func mockDoAudit(appContext *AppContext, operation string, auditValues map[string]string) *mockAud.AuditingInterface {
+func mockDoAudit(appContext *AppContext, event model.AuditEvent) *mockAud.AuditingInterface {
 	mockAudit := &mockAud.AuditingInterface{}
-	mockAudit.On("DoAudit", mock.Anything, mock.Anything, "beta@alfa.com", operation, auditValues)
+	event.Actor = "beta@alfa.com"
+	mockAudit.On("DoAudit", mock.Anything, mock.Anything, event)
 	appContext.Auditing = mockAudit
 
 	return mockAudit
@@ -225,11 +236,21 @@ func mockGetAllEnvironmentsError(appContext *AppContext) *mockRepo.EnvironmentDA
 	return mockEnvDao
 }
 
-func mockConventionInterface(appContext *AppContext) *mocks.ConventionInterface {
-	mockConvention := &mocks.ConventionInterface{}
-	mockConvention.On("GetKubeConfigFileName", "foo", "bar").Return("./config/foo_bar")
-	appContext.ConventionInterface = mockConvention
-	return mockConvention
+func mockGroupVariables(appContext *AppContext) *mockRepo.GroupDAOInterface {
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("ListGroupVariablesByName", mock.Anything).Return([]model.GroupVariable{}, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+	return mockGroupDAO
+}
+
+func mockKubeConfigProvider(appContext *AppContext) *mocks.KubeConfigProviderInterface {
+	mockKubeConfig := &mocks.KubeConfigProviderInterface{}
+	mockKubeConfig.On("GetKubeConfig", mock.MatchedBy(func(env *model.Environment) bool {
+		return env.Group == "foo" && env.Name == "bar"
+	})).Return("./config/foo_bar", nil)
+	mockKubeConfig.On("Invalidate", mock.Anything).Return()
+	appContext.KubeConfigProvider = mockKubeConfig
+	return mockKubeConfig
 }
 
 func mockHelmListResult() *helmapi.HelmListResult {
@@ -40,7 +40,11 @@ func (appContext *AppContext) deployTrafficRule(w http.ResponseWriter, r *http.R
 	name := "canary-" + serviceName
 	out := &bytes.Buffer{}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	variables := make([]string, 1)
 	variables = append(variables, "istio.virtualservices.hosts[0]="+domain)
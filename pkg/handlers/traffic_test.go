@@ -26,7 +26,7 @@ func TestDeployTrafficRule(t *testing.T) {
 
 	appContext := AppContext{}
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockHelmSvcWithLotOfThings(&appContext)
 	mockHelmSvc.On("SearchCharts", mock.Anything, false).Return(getCharts())
 
@@ -51,7 +51,7 @@ func TestDeployTrafficRule(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	envDAO.AssertNumberOfCalls(t, "GetByID", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "Upgrade", 1)
 
@@ -71,6 +71,10 @@ func (appContext *AppContext) listUsers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	for _, user := range result.Users {
+		maskEnvironmentCredentials(user.Environments)
+	}
+
 	data, _ := json.Marshal(result)
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -22,6 +25,7 @@ func (appContext *AppContext) validateVariables(w http.ResponseWriter, r *http.R
 	type Payload struct {
 		EnvironmentID int    `json:"environmentId"`
 		Scope         string `json:"scope"`
+		ChartVersion  string `json:"chartVersion"`
 	}
 
 	var payload Payload
@@ -43,17 +47,44 @@ func (appContext *AppContext) validateVariables(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if vrs, err = appContext.filterVariableRules(payload.EnvironmentID, vrs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	ivr, err := appContext.validate(vars, vrs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if len(payload.ChartVersion) > 0 && payload.Scope != globalScope {
+		if ivr.SchemaViolations, err = appContext.validateScopeSchema(payload.EnvironmentID, payload.Scope, payload.ChartVersion); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	data, _ := json.Marshal(ivr)
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
 
+//validateScopeSchema validates the values rendered for a chart against its values.schema.json.
+func (appContext *AppContext) validateScopeSchema(environmentID int, scope string, chartVersion string) ([]model.SchemaViolation, error) {
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	installPayload := model.InstallPayload{Chart: scope, Name: scope, ChartVersion: chartVersion}
+	args, stringArgs, chartValues, err := appContext.getInstallArgs(environment, installPayload)
+	if err != nil {
+		return nil, err
+	}
+	return appContext.validateChartSchema(scope, chartVersion, chartValues, args, stringArgs)
+}
+
 func (appContext *AppContext) validateEnvironmentVariables(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set(global.ContentType, global.JSONContentType)
@@ -74,6 +105,11 @@ func (appContext *AppContext) validateEnvironmentVariables(w http.ResponseWriter
 		return
 	}
 
+	if vrs, err = appContext.filterVariableRules(envID, vrs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	ivr, err := appContext.validate(vars, vrs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -93,11 +129,16 @@ func (appContext *AppContext) validate(variables []model.Variable,
 
 	for _, varRule := range varRules {
 		for _, valueRule := range varRule.ValueRules {
+			validator := appContext.validationFn(valueRule.Type)
+			if validator == nil {
+				log.Println("Unknown value rule type", valueRule.Type)
+				continue
+			}
 			for _, variable := range variables {
 
-				if varRuleAppliesToVar(varRule.Name, variable.Name) {
+				if varRuleAppliesToVar(varRule.Name, variable.Name) && varRuleAppliesToScope(varRule.Scope, variable.Scope) {
 
-					if valid := validationFn(valueRule.Type)(varRule, valueRule, variable); !valid {
+					if valid := validator(varRule, valueRule, variable); !valid {
 						invalidVar := createResult(varRule, valueRule, variable)
 						result.InvalidVariables = append(result.InvalidVariables, invalidVar)
 					}
@@ -111,17 +152,61 @@ func (appContext *AppContext) validate(variables []model.Variable,
 	return result, nil
 }
 
-func validationFn(validator string) fn {
+//valueRuleTypes lists the types accepted for a ValueRule
+var valueRuleTypes = []string{"NotEmpty", "StartsWith", "EndsWith", "RegEx", "OneOf", "NumberRange",
+	"IsURL", "IsHostname", "MaxLength", "NotEqualToEnv", "ReferencesExistingVariable"}
+
+func (appContext *AppContext) validationFn(validator string) fn {
 	m := make(map[string]fn)
 
 	m["NotEmpty"] = notEmpty
 	m["StartsWith"] = startsWith
 	m["EndsWith"] = endsWith
 	m["RegEx"] = regEx
+	m["OneOf"] = oneOf
+	m["NumberRange"] = numberRange
+	m["IsURL"] = isURL
+	m["IsHostname"] = isHostname
+	m["MaxLength"] = maxLength
+	m["NotEqualToEnv"] = appContext.notEqualToEnv
+	m["ReferencesExistingVariable"] = appContext.referencesExistingVariable
 
 	return m[validator]
 }
 
+//validateValueRule verifies the type, value and severity of a value rule before it is saved.
+func validateValueRule(vlr model.ValueRule) error {
+	if !util.Contains(valueRuleTypes, vlr.Type) {
+		return fmt.Errorf("unknown value rule type %q", vlr.Type)
+	}
+	if vlr.Severity != "" && vlr.Severity != model.SeverityError && vlr.Severity != model.SeverityWarning {
+		return fmt.Errorf("invalid severity %q, use %s or %s", vlr.Severity, model.SeverityError, model.SeverityWarning)
+	}
+
+	var err error
+	switch vlr.Type {
+	case "RegEx":
+		_, err = regexp.Compile(vlr.Value)
+	case "OneOf":
+		if len(parseOneOf(vlr.Value)) == 0 {
+			err = errors.New("OneOf requires a comma separated list of values")
+		}
+	case "NumberRange":
+		_, _, err = parseNumberRange(vlr.Value)
+	case "MaxLength":
+		var length int
+		if length, err = strconv.Atoi(strings.TrimSpace(vlr.Value)); err == nil && length < 0 {
+			err = errors.New("MaxLength must not be negative")
+		}
+	case "NotEqualToEnv":
+		_, err = strconv.Atoi(strings.TrimSpace(vlr.Value))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for rule %s: %s", vlr.Value, vlr.Type, err.Error())
+	}
+	return nil
+}
+
 type fn func(model.VariableRule, *model.ValueRule, model.Variable) bool
 
 func notEmpty(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
@@ -154,6 +239,129 @@ func regEx(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool
 	return result
 }
 
+//oneOf accepts values found in the comma separated list of the rule.
+func oneOf(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	result := util.Contains(parseOneOf(vlr.Value), v.Value)
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+func parseOneOf(value string) []string {
+	var options []string
+	for _, e := range strings.Split(value, ",") {
+		if option := strings.TrimSpace(e); len(option) > 0 {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+//numberRange accepts numbers inside the rule range, written as min..max where any bound may be omitted.
+func numberRange(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	min, max, err := parseNumberRange(vlr.Value)
+	if err != nil {
+		log.Println("Error parsing number range", err)
+		return false
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(v.Value), 64)
+	result := err == nil && (min == nil || number >= *min) && (max == nil || number <= *max)
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+func parseNumberRange(value string) (*float64, *float64, error) {
+	bounds := strings.Split(value, "..")
+	if len(bounds) != 2 {
+		return nil, nil, errors.New("number range must be written as min..max")
+	}
+	var result [2]*float64
+	for i, e := range bounds {
+		if e = strings.TrimSpace(e); len(e) == 0 {
+			continue
+		}
+		number, err := strconv.ParseFloat(e, 64)
+		if err != nil {
+			return nil, nil, err
+		}
+		result[i] = &number
+	}
+	if result[0] != nil && result[1] != nil && *result[0] > *result[1] {
+		return nil, nil, errors.New("min is greater than max")
+	}
+	return result[0], result[1], nil
+}
+
+func isURL(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	u, err := url.Parse(v.Value)
+	result := err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+var hostnameRegex = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+func isHostname(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	result := len(v.Value) <= 253 && hostnameRegex.MatchString(v.Value)
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+func maxLength(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	length, err := strconv.Atoi(strings.TrimSpace(vlr.Value))
+	result := err == nil && len([]rune(v.Value)) <= length
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+//notEqualToEnv rejects values equal to the same variable in the environment whose id is the rule value.
+func (appContext *AppContext) notEqualToEnv(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	envID, err := strconv.Atoi(strings.TrimSpace(vlr.Value))
+	if err != nil {
+		log.Println("Error parsing environment id", err)
+		return false
+	}
+	if envID == v.EnvironmentID {
+		return true
+	}
+
+	others, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(envID, v.Scope)
+	if err != nil {
+		log.Println("Error retrieving variables of environment", envID, err)
+		return false
+	}
+
+	value := appContext.decryptVariables([]model.Variable{v})[0].Value
+	result := true
+	for _, e := range appContext.decryptVariables(others) {
+		if e.Name == v.Name && e.Value == value {
+			result = false
+			break
+		}
+	}
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
+//referencesExistingVariable requires the value to reference other variables, all of them defined.
+func (appContext *AppContext) referencesExistingVariable(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) bool {
+	value := appContext.decryptVariables([]model.Variable{v})[0].Value
+	if !strings.Contains(value, "${") {
+		logMsg(vrr, vlr, v, false)
+		return false
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(v.EnvironmentID)
+	if err != nil {
+		log.Println("Error retrieving environment", v.EnvironmentID, err)
+		return false
+	}
+
+	_, err = appContext.newEnvironmentResolver(environment).resolveVariable(v.Scope, v.Name, value)
+	result := err == nil
+	logMsg(vrr, vlr, v, result)
+	return result
+}
+
 //varRuleAppliesToVar Validates only variables whose name matches the variableRule value.
 func varRuleAppliesToVar(regex string, varName string) bool {
 	result, err := regexp.MatchString(regex, varName)
@@ -164,6 +372,46 @@ func varRuleAppliesToVar(regex string, varName string) bool {
 	return result
 }
 
+//varRuleAppliesToScope Validates only variables whose scope matches the optional variableRule scope.
+func varRuleAppliesToScope(regex string, scope string) bool {
+	return len(regex) == 0 || varRuleAppliesToVar(regex, scope)
+}
+
+//filterVariableRules keeps the rules whose environment and group selectors match the environment.
+func (appContext *AppContext) filterVariableRules(environmentID int, varRules []model.VariableRule) ([]model.VariableRule, error) {
+	var environment *model.Environment
+	result := make([]model.VariableRule, 0, len(varRules))
+	for _, varRule := range varRules {
+		if varRule.EnvironmentID > 0 && int(varRule.EnvironmentID) != environmentID {
+			continue
+		}
+		if len(varRule.Group) > 0 {
+			if environment == nil {
+				var err error
+				if environment, err = appContext.Repositories.EnvironmentDAO.GetByID(environmentID); err != nil {
+					return nil, err
+				}
+			}
+			if varRule.Group != environment.Group {
+				continue
+			}
+		}
+		result = append(result, varRule)
+	}
+	return result, nil
+}
+
+//validateVariableRule verifies the name and scope expressions of a variable rule before it is saved.
+func validateVariableRule(vrr model.VariableRule) error {
+	if _, err := regexp.Compile(vrr.Name); err != nil {
+		return fmt.Errorf("invalid name expression %q: %s", vrr.Name, err.Error())
+	}
+	if _, err := regexp.Compile(vrr.Scope); err != nil {
+		return fmt.Errorf("invalid scope expression %q: %s", vrr.Scope, err.Error())
+	}
+	return nil
+}
+
 func createResult(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable) model.InvalidVariable {
 	var iv model.InvalidVariable
 	iv.Scope = v.Scope
@@ -172,6 +420,10 @@ func createResult(vrr model.VariableRule, vlr *model.ValueRule, v model.Variable
 	iv.VariableRule = vrr.Name
 	iv.RuleType = vlr.Type
 	iv.ValueRule = vlr.Value
+	iv.Severity = vlr.Severity
+	if len(iv.Severity) == 0 {
+		iv.Severity = model.SeverityError
+	}
 	return iv
 }
 
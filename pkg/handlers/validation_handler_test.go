@@ -46,7 +46,7 @@ func TestValidateVariables_NotEmpty(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok.")
 
 	r := string(rr.Body.Bytes())
-	assert.Equal(t, r, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUsername","ruleType":"NotEmpty","valueRule":""}]}`)
+	assert.Equal(t, r, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUsername","ruleType":"NotEmpty","valueRule":"","severity":"error"}]}`)
 }
 
 func TestValidateVariables_VariabeRule_UsingRegex_NotEmpty(t *testing.T) {
@@ -82,7 +82,7 @@ func TestValidateVariables_VariabeRule_UsingRegex_NotEmpty(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok.")
 
 	r := string(rr.Body.Bytes())
-	assert.Equal(t, r, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUser.+","ruleType":"NotEmpty","valueRule":""}]}`)
+	assert.Equal(t, r, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUser.+","ruleType":"NotEmpty","valueRule":"","severity":"error"}]}`)
 }
 
 func TestValidateVariables_VariabeRule_UsingRegex_NotMatch(t *testing.T) {
@@ -232,7 +232,7 @@ func TestValidateEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok.")
 
 	res := string(rr.Body.Bytes())
-	assert.Equal(t, res, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUsername","ruleType":"NotEmpty","valueRule":""}]}`)
+	assert.Equal(t, res, `{"InvalidVariables":[{"scope":"global","name":"dbUsername","value":"","variableRule":"dbUsername","ruleType":"NotEmpty","valueRule":"","severity":"error"}]}`)
 }
 
 func TestValidateEnvironmentVariables_Error1(t *testing.T) {
@@ -327,3 +327,179 @@ func getVarRule(rName string, rType string, rValue string) model.VariableRule {
 	vrr.ValueRules = append(vrr.ValueRules, &vlr)
 	return vrr
 }
+
+func TestValidateNewRuleTypes(t *testing.T) {
+	appContext := AppContext{}
+
+	cases := []struct {
+		rType   string
+		rValue  string
+		valid   string
+		invalid string
+	}{
+		{"OneOf", "debug, info,warn", "info", "trace"},
+		{"NumberRange", "1..10", "10", "11"},
+		{"NumberRange", "..0", "-1.5", "abc"},
+		{"IsURL", "", "https://my-server.com/api", "my-server.com/api"},
+		{"IsHostname", "", "db.dev.svc.cluster.local", "db_host:5432"},
+		{"MaxLength", "5", "abcde", "abcdef"},
+	}
+
+	for _, c := range cases {
+		vars := []model.Variable{getVar("myVar", c.invalid), getVar("myVar", c.valid)}
+		vrs := []model.VariableRule{getVarRule("myVar", c.rType, c.rValue)}
+
+		ivr, err := appContext.validate(vars, vrs)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(ivr.InvalidVariables), c.rType)
+		assert.Equal(t, c.invalid, ivr.InvalidVariables[0].Value, c.rType)
+	}
+}
+
+func TestValidateUnknownRuleType(t *testing.T) {
+	appContext := AppContext{}
+
+	vars := []model.Variable{getVar("myVar", "")}
+	vrs := []model.VariableRule{getVarRule("myVar", "Unknown", "")}
+
+	ivr, err := appContext.validate(vars, vrs)
+	assert.Nil(t, err)
+	assert.Empty(t, ivr.InvalidVariables)
+}
+
+func TestValidateWarningSeverity(t *testing.T) {
+	appContext := AppContext{}
+
+	vars := []model.Variable{getVar("myVar", "")}
+	vrs := []model.VariableRule{getVarRule("myVar", "NotEmpty", "")}
+	vrs[0].ValueRules[0].Severity = model.SeverityWarning
+
+	ivr, err := appContext.validate(vars, vrs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ivr.InvalidVariables))
+	assert.Equal(t, model.SeverityWarning, ivr.InvalidVariables[0].Severity)
+}
+
+func TestValidateNotEqualToEnv(t *testing.T) {
+	appContext := AppContext{}
+
+	dev := getVar("dbUrl", "jdbc://dev")
+	dev.EnvironmentID = 1
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 1, "global").Return([]model.Variable{dev}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	vars := []model.Variable{getVar("dbUrl", "jdbc://dev"), getVar("dbUrl", "jdbc://prod")}
+	vrs := []model.VariableRule{getVarRule("dbUrl", "NotEqualToEnv", "1")}
+
+	ivr, err := appContext.validate(vars, vrs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ivr.InvalidVariables))
+	assert.Equal(t, "jdbc://dev", ivr.InvalidVariables[0].Value)
+}
+
+func TestValidateReferencesExistingVariable(t *testing.T) {
+	appContext := AppContext{}
+	mockGetByID(&appContext)
+	mockGetAllVariablesByEnvironmentAndScope(&appContext)
+	mockGroupVariables(&appContext)
+
+	vars := []model.Variable{
+		getVar("dbUser", "${username}"),
+		getVar("dbUser", "${missing}"),
+		getVar("dbUser", "plain"),
+	}
+	vrs := []model.VariableRule{getVarRule("dbUser", "ReferencesExistingVariable", "")}
+
+	ivr, err := appContext.validate(vars, vrs)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ivr.InvalidVariables))
+	assert.Equal(t, "${missing}", ivr.InvalidVariables[0].Value)
+	assert.Equal(t, "plain", ivr.InvalidVariables[1].Value)
+}
+
+func TestValidateValueRule(t *testing.T) {
+	assert.NoError(t, validateValueRule(getValueRule("NumberRange", "0..")))
+	assert.NoError(t, validateValueRule(getValueRule("OneOf", "a,b")))
+	assert.Error(t, validateValueRule(getValueRule("Unknown", "")))
+	assert.Error(t, validateValueRule(getValueRule("RegEx", "(")))
+	assert.Error(t, validateValueRule(getValueRule("NumberRange", "10..1")))
+	assert.Error(t, validateValueRule(getValueRule("MaxLength", "-1")))
+	assert.Error(t, validateValueRule(getValueRule("NotEqualToEnv", "dev")))
+	assert.Error(t, validateValueRule(getValueRule("OneOf", " , ")))
+
+	rule := getValueRule("NotEmpty", "")
+	rule.Severity = "fatal"
+	assert.Error(t, validateValueRule(rule))
+}
+
+func TestFilterVariableRules(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockGetByID(&appContext)
+
+	all := getVarRule("dbUrl", "NotEmpty", "")
+	env := getVarRule("dbUrl", "NotEmpty", "")
+	env.EnvironmentID = 999
+	otherEnv := getVarRule("dbUrl", "NotEmpty", "")
+	otherEnv.EnvironmentID = 1
+	group := getVarRule("dbUrl", "NotEmpty", "")
+	group.Group = "foo"
+	otherGroup := getVarRule("dbUrl", "NotEmpty", "")
+	otherGroup.Group = "prod"
+
+	result, err := appContext.filterVariableRules(999, []model.VariableRule{all, env, otherEnv, group, otherGroup})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.VariableRule{all, env, group}, result)
+	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestValidateScopedRule(t *testing.T) {
+	appContext := AppContext{}
+
+	chartVar := getVar("dbUrl", "localhost")
+	chartVar.Scope = "repo/my-chart"
+	vars := []model.Variable{getVar("dbUrl", "localhost"), chartVar}
+
+	vrs := []model.VariableRule{getVarRule("dbUrl", "EndsWith", ".svc")}
+	vrs[0].Scope = "^repo/"
+
+	ivr, err := appContext.validate(vars, vrs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ivr.InvalidVariables))
+	assert.Equal(t, "repo/my-chart", ivr.InvalidVariables[0].Scope)
+}
+
+func TestValidateEnvironmentVariables_GroupRule(t *testing.T) {
+	appContext := AppContext{}
+	mockGetByID(&appContext)
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironment", 999).Return([]model.Variable{getVar("dbUsername", "")}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	prodRule := getVarRule("dbUsername", "NotEmpty", "")
+	prodRule.Group = "prod"
+	mockVariableRuleDAO := &mockRepo.VariableRuleDAOInterface{}
+	mockVariableRuleDAO.On("ListVariableRules").Return([]model.VariableRule{prodRule}, nil)
+	appContext.Repositories.VariableRuleDAO = mockVariableRuleDAO
+
+	req, err := http.NewRequest("GET", "/validateEnvVars/999", bytes.NewBuffer(nil))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/validateEnvVars/{envId}", appContext.validateEnvironmentVariables).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok.")
+	assert.Equal(t, `{"InvalidVariables":[]}`, rr.Body.String())
+}
+
+func TestValidateVariableRule(t *testing.T) {
+	rule := getVarRule("dbUrl", "NotEmpty", "")
+	assert.NoError(t, validateVariableRule(rule))
+
+	rule.Scope = "repo/("
+	assert.Error(t, validateVariableRule(rule))
+}
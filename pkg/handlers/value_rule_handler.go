@@ -23,6 +23,11 @@ func (appContext *AppContext) newValueRule(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if err := validateValueRule(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if _, err := appContext.Repositories.ValueRuleDAO.CreateValueRule(payload); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -41,6 +46,11 @@ func (appContext *AppContext) editValueRule(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := validateValueRule(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := appContext.Repositories.ValueRuleDAO.EditValueRule(payload); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -178,7 +178,7 @@ func TestListValueRule(t *testing.T) {
 	response := string(rr.Body.Bytes())
 	assert.Contains(t, response, `{"list":[{"ID":888,`)
 	assert.Contains(t, response, `"type":"StartsWith",`)
-	assert.Contains(t, response, `"value":"http","VariableRuleID":999}]}`)
+	assert.Contains(t, response, `"value":"http","severity":"","VariableRuleID":999}]}`)
 }
 
 func TestListValueRule_ParseError(t *testing.T) {
@@ -213,3 +213,23 @@ func TestListValueRule_Error(t *testing.T) {
 	mockValueRule.AssertNumberOfCalls(t, "ListValueRules", 1)
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500")
 }
+
+func TestNewValueRule_UnknownType(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockValueRule()
+	p.Type = "Unknown"
+
+	mockValueRule := &mockRepo.ValueRuleDAOInterface{}
+	appContext.Repositories.ValueRuleDAO = mockValueRule
+
+	req, err := http.NewRequest("POST", "/valuerules", payload(p))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newValueRule)
+	handler.ServeHTTP(rr, req)
+
+	mockValueRule.AssertNumberOfCalls(t, "CreateValueRule", 0)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400")
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/constraints"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//Layers of the variables of a deployable, from the lowest to the highest precedence
+const (
+	chartLayer    = "chart"
+	groupLayer    = "group"
+	globalLayer   = "global"
+	scopeLayer    = "scope"
+	overrideLayer = "override"
+)
+
+//layeredVariable is the value of a helm key taken from the highest layer defining it
+type layeredVariable struct {
+	key     string
+	scope   string
+	name    string
+	value   string
+	varType string
+	secret  bool
+	source  string
+}
+
+//variableLayers merges the variables of a deployable by precedence:
+//chart defaults < group variables < environment global < environment scope < per-deploy overrides.
+//Group and environment global variables only override the chart defaults they match, as they are
+//shared by every chart of the environment; scope variables and overrides are always set.
+type variableLayers struct {
+	items []*layeredVariable
+	keys  map[string]*layeredVariable
+}
+
+func newVariableLayers() *variableLayers {
+	return &variableLayers{keys: make(map[string]*layeredVariable)}
+}
+
+//set registers the value of a variable in a layer, replacing the value of lower layers
+func (l *variableLayers) set(layer string, scope string, name string, value string, varType string, secret bool) {
+	if len(name) == 0 || len(value) == 0 {
+		return
+	}
+	key := normalizeVariableName(name)
+	item, ok := l.keys[key]
+	if !ok {
+		item = &layeredVariable{key: key}
+		l.keys[key] = item
+		l.items = append(l.items, item)
+	}
+	item.scope = scope
+	item.name = name
+	item.value = value
+	item.source = layer
+	if layer != overrideLayer {
+		item.varType = varType
+		item.secret = secret
+	}
+}
+
+//override replaces the value of a key already defined by a lower layer
+func (l *variableLayers) override(layer string, scope string, name string, value string, varType string, secret bool) {
+	if _, ok := l.keys[normalizeVariableName(name)]; ok {
+		l.set(layer, scope, name, value, varType, secret)
+	}
+}
+
+//resolve resolves the references of a layered value
+func (item *layeredVariable) resolve(resolver *variableResolver) (string, error) {
+	var value string
+	var err error
+	switch item.source {
+	case chartLayer:
+		value, err = resolver.resolveKnown(item.value)
+	case overrideLayer:
+		value, err = resolver.resolve(item.value)
+	default:
+		value, err = resolver.resolveVariable(item.scope, item.name, item.value)
+	}
+	if err != nil {
+		if item.source == chartLayer {
+			return "", fmt.Errorf("chart default %s: %s", item.name, err.Error())
+		}
+		return "", fmt.Errorf("variable %s: %s", item.name, err.Error())
+	}
+	if value == "T_EMPTY" {
+		value = ""
+	}
+	return value, nil
+}
+
+//getVariableLayers loads the layers of variables of a scope and a resolver for their references.
+func (appContext *AppContext) getVariableLayers(environment *model.Environment, scope string,
+	chartValues map[string]interface{}, overrides map[string]string) (*variableLayers, *variableResolver, error) {
+
+	variables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	variables = appContext.decryptVariables(variables)
+
+	globalVariables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), globalScope)
+	if err != nil {
+		return nil, nil, err
+	}
+	globalVariables = appContext.decryptVariables(globalVariables)
+	groupVariables := appContext.getGroupVariables(environment)
+
+	resolver := appContext.newEnvironmentResolver(environment)
+	resolver.addScope(globalScope, mergeGroupVariables(environment, groupVariables, globalVariables))
+	resolver.addScope(scope, variables)
+
+	return buildVariableLayers(scope, chartValues, groupVariables, globalVariables, variables, overrides), resolver, nil
+}
+
+//buildVariableLayers merges the variables of a deployable, already loaded and decrypted, by precedence
+func buildVariableLayers(scope string, chartValues map[string]interface{}, groupVariables []model.GroupVariable,
+	globalVariables []model.Variable, variables []model.Variable, overrides map[string]string) *variableLayers {
+
+	layers := newVariableLayers()
+	for _, key := range sortedKeys(chartValues) {
+		if value, ok := chartValues[key].(string); ok {
+			layers.set(chartLayer, "", key, value, "", false)
+		}
+	}
+	for _, e := range groupVariables {
+		layers.override(groupLayer, globalScope, e.Name, e.Value, e.Type, e.Secret)
+	}
+	for _, e := range globalVariables {
+		layers.override(globalLayer, globalScope, e.Name, e.Value, e.Type, e.Secret)
+	}
+	for _, e := range variables {
+		layers.set(scopeLayer, e.Scope, e.Name, e.Value, e.Type, e.Secret)
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		layers.set(overrideLayer, scope, name, overrides[name], "", false)
+	}
+	return layers
+}
+
+//getLayeredArgs renders the helm arguments of the layered variables of a deployable
+func getLayeredArgs(layers *variableLayers, resolver *variableResolver) ([]string, []string, error) {
+	var args []string
+	var stringArgs []string
+	for _, item := range layers.items {
+		value, err := item.resolve(resolver)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, stringValues, err := renderVariable(item.key, value, item.varType)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, values...)
+		stringArgs = append(stringArgs, stringValues...)
+	}
+
+	dt := time.Now()
+	args = append(args, "app.dateHour="+dt.String())
+
+	return args, stringArgs, nil
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (appContext *AppContext) getEffectiveVariables(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+
+	envID, err := strconv.Atoi(r.URL.Query().Get("envId"))
+	if err != nil {
+		http.Error(w, "envId is required", http.StatusBadRequest)
+		return
+	}
+	scope := r.URL.Query().Get("scope")
+	if len(scope) == 0 {
+		http.Error(w, "scope is required", http.StatusBadRequest)
+		return
+	}
+
+	//Like the export, secret values are only revealed to admins who ask for them
+	revealSecrets, _ := strconv.ParseBool(r.URL.Query().Get("secrets"))
+	if revealSecrets && !util.Contains(principal.Roles, constraints.TenkaiAdmin) {
+		http.Error(w, errors.New(global.AccessDenied).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	has, err := appContext.hasAccess(principal, envID)
+	if err != nil || !has {
+		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
+		return
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(envID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chartValues, err := appContext.getHelmChartValues(scope, r.URL.Query().Get("chartVersion"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	layers, resolver, err := appContext.getVariableLayers(environment, scope, getAppValues(chartValues), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := &model.EffectiveVariablesResult{Variables: make([]model.EffectiveVariable, 0, len(layers.items))}
+	for _, item := range layers.items {
+		variable := model.EffectiveVariable{
+			Key:    item.key,
+			Name:   item.name,
+			Value:  item.value,
+			Type:   item.varType,
+			Secret: item.secret,
+			Source: item.source,
+		}
+		if variable.Resolved, err = item.resolve(resolver); err != nil {
+			variable.Error = err.Error()
+		}
+		if item.secret && !revealSecrets {
+			variable.Value = redactedValue
+			variable.Resolved = redactedValue
+		}
+		result.Variables = append(result.Variables, variable)
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/configs"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockLayeredVariables(appContext *AppContext) {
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{
+		{EnvironmentID: 999, Scope: "global", Name: "domain", Value: "bar.com"},
+		{EnvironmentID: 999, Scope: "global", Name: "username", Value: "user"},
+	}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/my-chart").Return([]model.Variable{
+		{EnvironmentID: 999, Scope: "repo/my-chart", Name: "replicas", Value: "2", Type: model.VariableTypeInt},
+		{EnvironmentID: 999, Scope: "repo/my-chart", Name: "url", Value: "https://${domain}"},
+	}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockGroupDAO := &mockRepo.GroupDAOInterface{}
+	mockGroupDAO.On("ListGroupVariablesByName", "foo").Return([]model.GroupVariable{
+		{GroupID: 1, Name: "domain", Value: "foo.com"},
+		{GroupID: 1, Name: "region", Value: "us-east-1"},
+	}, nil)
+	appContext.Repositories.GroupDAO = mockGroupDAO
+}
+
+func TestGetVariableLayers(t *testing.T) {
+	appContext := AppContext{}
+	mockLayeredVariables(&appContext)
+
+	chartValues := map[string]interface{}{"domain": "default.com", "region": "sa-east-1", "replicas": "1", "logLevel": "info"}
+	overrides := map[string]string{"logLevel": "debug"}
+
+	env := mockGetEnv()
+	layers, resolver, err := appContext.getVariableLayers(&env, "repo/my-chart", chartValues, overrides)
+	assert.NoError(t, err)
+
+	sources := make(map[string]string)
+	values := make(map[string]string)
+	for _, item := range layers.items {
+		sources[item.key] = item.source
+		values[item.key], _ = item.resolve(resolver)
+	}
+
+	assert.Len(t, layers.items, 5)
+	assert.Equal(t, globalLayer, sources["app.domain"])
+	assert.Equal(t, "bar.com", values["app.domain"])
+	assert.Equal(t, groupLayer, sources["app.region"])
+	assert.Equal(t, "us-east-1", values["app.region"])
+	assert.Equal(t, scopeLayer, sources["app.replicas"])
+	assert.Equal(t, overrideLayer, sources["app.logLevel"])
+	assert.Equal(t, "debug", values["app.logLevel"])
+	assert.Equal(t, "https://bar.com", values["app.url"])
+	_, ok := sources["app.username"]
+	assert.False(t, ok, "global variables not declared by the chart should not be set")
+}
+
+func TestGetLayeredArgs_OverrideKeepsType(t *testing.T) {
+	appContext := AppContext{}
+	mockLayeredVariables(&appContext)
+
+	env := mockGetEnv()
+	layers, resolver, err := appContext.getVariableLayers(&env, "repo/my-chart", nil, map[string]string{"replicas": "three"})
+	assert.NoError(t, err)
+
+	_, _, err = getLayeredArgs(layers, resolver)
+	assert.Error(t, err)
+}
+
+func TestGetLayeredArgs_ChartDefaultKeepsUnknownReferences(t *testing.T) {
+	appContext := AppContext{}
+	mockLayeredVariables(&appContext)
+
+	chartValues := map[string]interface{}{"javaOpts": "-Dns=${NAMESPACE} ${JAVA_OPTS}"}
+
+	env := mockGetEnv()
+	layers, resolver, err := appContext.getVariableLayers(&env, "repo/my-chart", chartValues, nil)
+	assert.NoError(t, err)
+
+	args, _, err := getLayeredArgs(layers, resolver)
+	assert.NoError(t, err)
+	assert.Contains(t, args, "app.javaOpts=-Dns=dev ${JAVA_OPTS}")
+}
+
+func TestGetEffectiveVariables(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDaoWithLotOfThings(&appContext)
+	mockLayeredVariables(&appContext)
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, "repo/my-chart", "0.1.0", "values").
+		Return([]byte(`{"app":{"region":"sa-east-1"}}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	req, err := http.NewRequest("GET", "/variables/effective?envId=999&scope=repo/my-chart&chartVersion=0.1.0", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getEffectiveVariables)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+
+	var result model.EffectiveVariablesResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result.Variables, 3)
+	assert.Equal(t, "app.region", result.Variables[0].Key)
+	assert.Equal(t, groupLayer, result.Variables[0].Source)
+	assert.Equal(t, "https://bar.com", result.Variables[2].Resolved)
+}
+
+func getEffectiveSecret(t *testing.T, query string, admin bool) *httptest.ResponseRecorder {
+	appContext := AppContext{}
+	mockEnvDaoWithLotOfThings(&appContext)
+	mockLayeredVariables(&appContext)
+	appContext.Configuration = &configs.Configuration{}
+	appContext.Configuration.App.Passkey = "123456"
+
+	secret := model.Variable{EnvironmentID: 999, Scope: "repo/my-chart", Name: "token", Secret: true,
+		Value: hex.EncodeToString(util.Encrypt([]byte("s3cr3t"), "123456"))}
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "repo/my-chart").Return([]model.Variable{secret}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, "repo/my-chart", "", "values").Return([]byte(`{}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	req, err := http.NewRequest("GET", "/variables/effective?envId=999&scope=repo/my-chart"+query, nil)
+	assert.NoError(t, err)
+	if admin {
+		mockPrincipal(req)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getEffectiveVariables)
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetEffectiveVariables_RedactsSecrets(t *testing.T) {
+	rr := getEffectiveSecret(t, "", true)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+
+	var result model.EffectiveVariablesResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result.Variables, 1)
+	assert.Equal(t, redactedValue, result.Variables[0].Value)
+	assert.Equal(t, redactedValue, result.Variables[0].Resolved)
+	assert.NotContains(t, rr.Body.String(), "s3cr3t")
+
+	rr = getEffectiveSecret(t, "&secrets=true", true)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"resolved":"s3cr3t"`)
+
+	rr = getEffectiveSecret(t, "&secrets=true", false)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestGetEffectiveVariables_ScopeRequired(t *testing.T) {
+	appContext := AppContext{}
+
+	req, err := http.NewRequest("GET", "/variables/effective?envId=999", nil)
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getEffectiveVariables)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
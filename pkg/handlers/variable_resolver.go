@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+const globalScope = "global"
+
+//variableResolver resolves ${...} references found in variable values.
+//Supported references are ${NAMESPACE}, ${ENV.name}, ${ENV.group}, ${ENV.gateway},
+//${ENV.namespace}, ${name} (global scope), ${scope:name} and ${reference:-default}.
+type variableResolver struct {
+	environment model.Environment
+	scopes      map[string]map[string]string
+	loadScope   func(scope string) ([]model.Variable, error)
+}
+
+func newVariableResolver(environment model.Environment, loadScope func(scope string) ([]model.Variable, error)) *variableResolver {
+	return &variableResolver{
+		environment: environment,
+		scopes:      make(map[string]map[string]string),
+		loadScope:   loadScope,
+	}
+}
+
+//newEnvironmentResolver returns a resolver which loads (and decrypts) the variables of an environment on demand.
+func (appContext *AppContext) newEnvironmentResolver(environment *model.Environment) *variableResolver {
+	return newVariableResolver(*environment, func(scope string) ([]model.Variable, error) {
+		variables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(int(environment.ID), scope)
+		if err != nil {
+			return nil, err
+		}
+		if scope == globalScope {
+			return appContext.inheritGroupVariables(environment, appContext.decryptVariables(variables)), nil
+		}
+		return appContext.decryptVariables(variables), nil
+	})
+}
+
+//addScope registers variables already loaded by the caller, avoiding a new lookup.
+func (v *variableResolver) addScope(scope string, variables []model.Variable) {
+	values := make(map[string]string)
+	for _, e := range variables {
+		values[e.Name] = e.Value
+	}
+	v.scopes[scope] = values
+}
+
+//resolve resolves an arbitrary value.
+func (v *variableResolver) resolve(value string) (string, error) {
+	return v.resolveValue(value, nil, false)
+}
+
+//resolveKnown resolves only the references to environment fields and existing variables, leaving
+//any other reference unchanged. Chart defaults may hold ${...} meant for the shell of the pod.
+func (v *variableResolver) resolveKnown(value string) (string, error) {
+	return v.resolveValue(value, nil, true)
+}
+
+//resolveVariable resolves the value of a variable, detecting references back to itself.
+func (v *variableResolver) resolveVariable(scope string, name string, value string) (string, error) {
+	return v.resolveValue(value, []string{scope + ":" + name}, false)
+}
+
+func (v *variableResolver) resolveValue(value string, stack []string, lenient bool) (string, error) {
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		end := findClosingBrace(value, start+2)
+		if end < 0 {
+			break
+		}
+		resolved, err := v.resolveReference(value[start+2:end], stack, lenient)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(value[:start])
+		result.WriteString(resolved)
+		value = value[end+1:]
+	}
+	result.WriteString(value)
+	return result.String(), nil
+}
+
+func (v *variableResolver) resolveReference(reference string, stack []string, lenient bool) (string, error) {
+	expression := reference
+	hasDefault := false
+	defaultValue := ""
+	if i := strings.Index(reference, ":-"); i > -1 {
+		expression = reference[:i]
+		defaultValue = reference[i+2:]
+		hasDefault = true
+	}
+	expression = strings.TrimSpace(expression)
+
+	if value, ok := v.environmentField(expression); ok {
+		if value == "" && hasDefault {
+			return v.resolveValue(defaultValue, stack, lenient)
+		}
+		return value, nil
+	}
+
+	scope := globalScope
+	name := expression
+	if i := strings.LastIndex(expression, ":"); i > -1 {
+		scope = expression[:i]
+		name = expression[i+1:]
+	}
+
+	values, err := v.getScope(scope)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[name]
+	if !ok && lenient {
+		return "${" + reference + "}", nil
+	}
+	if !ok || value == "" {
+		if hasDefault {
+			return v.resolveValue(defaultValue, stack, lenient)
+		}
+		if !ok {
+			return "", fmt.Errorf("undefined variable reference ${%s}", reference)
+		}
+	}
+
+	key := scope + ":" + name
+	for _, e := range stack {
+		if e == key {
+			return "", fmt.Errorf("circular variable reference: %s", strings.Join(append(stack, key), " -> "))
+		}
+	}
+
+	next := make([]string, len(stack), len(stack)+1)
+	copy(next, stack)
+	return v.resolveValue(value, append(next, key), lenient)
+}
+
+func (v *variableResolver) environmentField(expression string) (string, bool) {
+	switch expression {
+	case "NAMESPACE", "ENV.namespace":
+		return v.environment.Namespace, true
+	case "ENV.name":
+		return v.environment.Name, true
+	case "ENV.group":
+		return v.environment.Group, true
+	case "ENV.gateway":
+		return v.environment.Gateway, true
+	}
+	return "", false
+}
+
+func (v *variableResolver) getScope(scope string) (map[string]string, error) {
+	if values, ok := v.scopes[scope]; ok {
+		return values, nil
+	}
+	if v.loadScope == nil {
+		return map[string]string{}, nil
+	}
+	variables, err := v.loadScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	v.addScope(scope, variables)
+	return v.scopes[scope], nil
+}
+
+//findClosingBrace returns the index of the brace closing a reference, honoring nested references.
+func findClosingBrace(value string, from int) int {
+	depth := 1
+	for i := from; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//decryptVariables replaces the value of secret variables by its decrypted content.
+func (appContext *AppContext) decryptVariables(variables []model.Variable) []model.Variable {
+	for i, e := range variables {
+		if e.Secret {
+			byteValues, _ := hex.DecodeString(e.Value)
+			value, err := util.Decrypt(byteValues, appContext.Configuration.App.Passkey)
+			if err == nil {
+				variables[i].Value = string(value)
+			}
+		}
+	}
+	return variables
+}
+
+func (appContext *AppContext) resolveVariables(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	principal := util.GetPrincipal(r)
+
+	var payload model.ResolveVariablesRequest
+	if err := util.UnmarshalPayload(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	has, err := appContext.hasAccess(principal, payload.EnvironmentID)
+	if err != nil || !has {
+		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
+		return
+	}
+
+	environment, err := appContext.Repositories.EnvironmentDAO.GetByID(payload.EnvironmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resolver := appContext.newEnvironmentResolver(environment)
+	result := &model.ResolveVariablesResult{Variables: make([]model.ResolvedVariable, 0)}
+
+	if len(payload.Value) > 0 {
+		item := model.ResolvedVariable{Scope: payload.Scope, Value: payload.Value}
+		if item.Resolved, err = resolver.resolve(payload.Value); err != nil {
+			item.Error = err.Error()
+		}
+		result.Variables = append(result.Variables, item)
+	} else {
+		variables, err := appContext.Repositories.VariableDAO.GetAllVariablesByEnvironmentAndScope(payload.EnvironmentID, payload.Scope)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		variables = appContext.decryptVariables(variables)
+		resolver.addScope(payload.Scope, variables)
+
+		for _, e := range variables {
+			item := model.ResolvedVariable{Scope: e.Scope, Name: e.Name, Value: e.Value}
+			if item.Resolved, err = resolver.resolveVariable(e.Scope, e.Name, e.Value); err != nil {
+				item.Error = err.Error()
+			}
+			result.Variables = append(result.Variables, item)
+		}
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
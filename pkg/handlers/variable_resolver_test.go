@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getTestResolver() *variableResolver {
+	chart := []model.Variable{
+		{Name: "host", Value: "db.${NAMESPACE}.svc"},
+		{Name: "port", Value: "5432"},
+	}
+	resolver := newVariableResolver(mockGetEnv(), func(scope string) ([]model.Variable, error) {
+		if scope == "repo/db" {
+			return chart, nil
+		}
+		return nil, errors.New("unknown scope")
+	})
+	resolver.addScope(globalScope, []model.Variable{
+		{Name: "username", Value: "user"},
+		{Name: "url", Value: "jdbc://${repo/db:host}:${repo/db:port}"},
+		{Name: "empty", Value: ""},
+		{Name: "a", Value: "${b}"},
+		{Name: "b", Value: "${a}"},
+	})
+	return resolver
+}
+
+func TestResolve(t *testing.T) {
+	resolver := getTestResolver()
+
+	cases := map[string]string{
+		"plain":                               "plain",
+		"${NAMESPACE}-${username}":            "dev-user",
+		"${ENV.name}/${ENV.group}":            "bar/foo",
+		"${ENV.gateway}":                      "my-gateway.istio-system.svc.cluster.local",
+		"${url}":                              "jdbc://db.dev.svc:5432",
+		"${missing:-fallback}":                "fallback",
+		"${empty:-fallback}":                  "fallback",
+		"${missing:-${username}}":             "user",
+		"${repo/db:missing:-${repo/db:port}}": "5432",
+		"unterminated ${username":             "unterminated ${username",
+	}
+
+	for value, expected := range cases {
+		result, err := resolver.resolve(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, result, value)
+	}
+}
+
+func TestResolve_UndefinedReference(t *testing.T) {
+	resolver := getTestResolver()
+
+	_, err := resolver.resolve("${missing}")
+	assert.EqualError(t, err, "undefined variable reference ${missing}")
+}
+
+func TestResolveKnown(t *testing.T) {
+	resolver := getTestResolver()
+
+	cases := map[string]string{
+		"${NAMESPACE}-${username}":       "dev-user",
+		"${url}":                         "jdbc://db.dev.svc:5432",
+		"${JAVA_OPTS}":                   "${JAVA_OPTS}",
+		"${HOME:-/tmp}/${username}":      "${HOME:-/tmp}/user",
+		"${empty:-fallback}":             "fallback",
+		"${repo/db:missing} ${ENV.name}": "${repo/db:missing} bar",
+	}
+
+	for value, expected := range cases {
+		result, err := resolver.resolveKnown(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, result, value)
+	}
+}
+
+func TestResolve_CircularReference(t *testing.T) {
+	resolver := getTestResolver()
+
+	_, err := resolver.resolveVariable(globalScope, "a", "${b}")
+	assert.EqualError(t, err, "circular variable reference: global:a -> global:b -> global:a")
+}
+
+func TestResolve_LoadScopeError(t *testing.T) {
+	resolver := getTestResolver()
+
+	_, err := resolver.resolve("${other:name}")
+	assert.EqualError(t, err, "unknown scope")
+}
+
+func getResolveVariablesPayload(value string) *bytes.Buffer {
+	var payload model.ResolveVariablesRequest
+	payload.EnvironmentID = 999
+	payload.Scope = "bar"
+	payload.Value = value
+	pStr, _ := json.Marshal(payload)
+	return bytes.NewBuffer(pStr)
+}
+
+func TestResolveVariables(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := mockEnvDaoWithLotOfThings(&appContext)
+
+	variable := mockVariable()
+	variable.Value = "${username}-${ENV.name}"
+	invalid := mockVariable()
+	invalid.Name = "invalid"
+	invalid.Value = "${nothing}"
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "bar").
+		Return([]model.Variable{variable, invalid}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").
+		Return([]model.Variable{mockGlobalVariable()}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+	mockGroupVariables(&appContext)
+
+	req, err := http.NewRequest("POST", "/variables/resolve", getResolveVariablesPayload(""))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.resolveVariables)
+	handler.ServeHTTP(rr, req)
+
+	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
+	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironmentAndScope", 2)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+
+	var result model.ResolveVariablesResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result.Variables, 2)
+	assert.Equal(t, "user-bar", result.Variables[0].Resolved)
+	assert.Equal(t, "undefined variable reference ${nothing}", result.Variables[1].Error)
+}
+
+func TestResolveVariables_Value(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDaoWithLotOfThings(&appContext)
+	mockGroupVariables(&appContext)
+	mockGetAllVariablesByEnvironmentAndScope(&appContext)
+
+	req, err := http.NewRequest("POST", "/variables/resolve", getResolveVariablesPayload("${username:-x}@${NAMESPACE}"))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.resolveVariables)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	assert.Contains(t, rr.Body.String(), `"resolved":"user@dev"`)
+}
+
+func TestResolveVariables_AccessDenied(t *testing.T) {
+	appContext := AppContext{}
+	mockEnvDao := &mockRepo.EnvironmentDAOInterface{}
+	mockEnvDao.On("GetAllEnvironments", mock.Anything).Return([]model.Environment{}, nil)
+	appContext.Repositories.EnvironmentDAO = mockEnvDao
+
+	req, err := http.NewRequest("POST", "/variables/resolve", getResolveVariablesPayload(""))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.resolveVariables)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Response should be 401.")
+}
+
+func TestResolveVariables_UnmarshalPayloadError(t *testing.T) {
+	appContext := AppContext{}
+	rr := testUnmarshalPayloadError(t, "/variables/resolve", appContext.resolveVariables)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500.")
+}
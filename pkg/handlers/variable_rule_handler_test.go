@@ -183,8 +183,8 @@ func TestListVariableRule(t *testing.T) {
 
 	response := string(rr.Body.Bytes())
 	assert.Contains(t, response, `{"list":[{"ID":999,`)
-	assert.Contains(t, response, `"name":"urlapi.*","ValueRules":[{"ID":888,`)
-	assert.Contains(t, response, `"type":"StartsWith","value":"http","VariableRuleID":999}]}]}`)
+	assert.Contains(t, response, `"name":"urlapi.*","environmentId":0,"group":"","scope":"","ValueRules":[{"ID":888,`)
+	assert.Contains(t, response, `"type":"StartsWith","value":"http","severity":"","VariableRuleID":999}]}]}`)
 }
 
 func TestListVariableRule_Error(t *testing.T) {
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//inferVariableType infers the type of a variable from its default value in the chart values.yaml.
+func inferVariableType(defaultValue interface{}) string {
+	switch value := defaultValue.(type) {
+	case string:
+		return model.VariableTypeString
+	case bool:
+		return model.VariableTypeBool
+	case float64:
+		if value == math.Trunc(value) {
+			return model.VariableTypeInt
+		}
+		return model.VariableTypeJSON
+	case []interface{}:
+		for _, item := range value {
+			switch item.(type) {
+			case string, bool, float64:
+			default:
+				return model.VariableTypeJSON
+			}
+		}
+		return model.VariableTypeList
+	case map[string]interface{}:
+		return model.VariableTypeJSON
+	}
+	return ""
+}
+
+//lookupChartDefault finds the default value of a variable inside the app section of the chart values.
+func lookupChartDefault(appVars map[string]interface{}, name string) (interface{}, bool) {
+	if normalizeVariableName(name) == name {
+		return nil, false
+	}
+	return lookupChartValue(appVars, name)
+}
+
+//lookupChartValue finds a value of the chart values by its dotted name.
+func lookupChartValue(values map[string]interface{}, name string) (interface{}, bool) {
+	var current interface{} = values
+	for _, key := range strings.Split(name, ".") {
+		values, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = values[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+//formatVariableValue formats a chart default value the way it is stored for its type.
+func formatVariableValue(defaultValue interface{}, varType string) string {
+	switch value := defaultValue.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		if varType == model.VariableTypeList {
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, formatVariableValue(item, ""))
+			}
+			return strings.Join(items, ",")
+		}
+		data, _ := json.Marshal(value)
+		return string(data)
+	case map[string]interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return fmt.Sprintf("%v", defaultValue)
+}
+
+//validateVariableValue verifies a value is compatible with the type of its variable.
+//Values containing references are only validated after they are resolved.
+func validateVariableValue(variable model.Variable) error {
+	if strings.Contains(variable.Value, "${") {
+		if !isValidVariableType(variable.Type) {
+			return fmt.Errorf("invalid type %q for variable %s", variable.Type, variable.Name)
+		}
+		return nil
+	}
+	_, _, err := renderVariable(variable.Name, variable.Value, variable.Type)
+	return err
+}
+
+func isValidVariableType(varType string) bool {
+	switch varType {
+	case "", model.VariableTypeString, model.VariableTypeInt, model.VariableTypeBool,
+		model.VariableTypeList, model.VariableTypeJSON:
+		return true
+	}
+	return false
+}
+
+//renderVariable renders a variable as helm arguments, returning values for --set and for --set-string.
+//Untyped variables keep the legacy behavior where helm guesses the type of the value.
+func renderVariable(key string, value string, varType string) ([]string, []string, error) {
+	switch varType {
+	case "":
+		return []string{key + "=" + value}, nil, nil
+	case model.VariableTypeString:
+		return nil, []string{key + "=" + escapeHelmValue(value)}, nil
+	case model.VariableTypeInt:
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("value %q of variable %s is not a valid int", value, key)
+		}
+		return []string{key + "=" + strconv.FormatInt(number, 10)}, nil, nil
+	case model.VariableTypeBool:
+		flag, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, nil, fmt.Errorf("value %q of variable %s is not a valid bool", value, key)
+		}
+		return []string{key + "=" + strconv.FormatBool(flag)}, nil, nil
+	case model.VariableTypeList:
+		items, err := parseListValue(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("value %q of variable %s is not a valid list", value, key)
+		}
+		var values, stringValues []string
+		flattenHelmValue(key, items, &values, &stringValues)
+		return values, stringValues, nil
+	case model.VariableTypeJSON:
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.UseNumber()
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return nil, nil, fmt.Errorf("value of variable %s is not a valid json: %s", key, err.Error())
+		}
+		var values, stringValues []string
+		flattenHelmValue(key, document, &values, &stringValues)
+		return values, stringValues, nil
+	}
+	return nil, nil, fmt.Errorf("invalid type %q for variable %s", varType, key)
+}
+
+//parseListValue accepts a json array of scalars or a comma separated list of strings.
+func parseListValue(value string) ([]interface{}, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") {
+		decoder := json.NewDecoder(bytes.NewBufferString(trimmed))
+		decoder.UseNumber()
+		var items []interface{}
+		if err := decoder.Decode(&items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			switch item.(type) {
+			case string, bool, json.Number:
+			default:
+				return nil, fmt.Errorf("list items must be scalars")
+			}
+		}
+		return items, nil
+	}
+
+	items := make([]interface{}, 0)
+	if len(trimmed) == 0 {
+		return items, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items, nil
+}
+
+func flattenHelmValue(key string, value interface{}, values *[]string, stringValues *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var names []string
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			flattenHelmValue(key+"."+escapeHelmKey(name), v[name], values, stringValues)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			*values = append(*values, key+"={}")
+		}
+		for i, item := range v {
+			flattenHelmValue(key+"["+strconv.Itoa(i)+"]", item, values, stringValues)
+		}
+	case string:
+		*stringValues = append(*stringValues, key+"="+escapeHelmValue(v))
+	case nil:
+		*values = append(*values, key+"=null")
+	default:
+		*values = append(*values, fmt.Sprintf("%s=%v", key, v))
+	}
+}
+
+func escapeHelmValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`).Replace(value)
+}
+
+func escapeHelmKey(key string) string {
+	return strings.NewReplacer(`\`, `\\`, `.`, `\.`, `,`, `\,`, `=`, `\=`, `[`, `\[`).Replace(key)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/rabbitmq"
+	mockRabbit "github.com/softplan/tenkai-api/pkg/rabbitmq/mocks"
+	"github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInferVariableType(t *testing.T) {
+	var values map[string]interface{}
+	json.Unmarshal([]byte(`{"name":"x","replicas":2,"ratio":0.5,"enabled":true,
+		"hosts":["a","b"],"ports":[{"port":80}],"resources":{"cpu":"1"},"empty":null}`), &values)
+
+	assert.Equal(t, model.VariableTypeString, inferVariableType(values["name"]))
+	assert.Equal(t, model.VariableTypeInt, inferVariableType(values["replicas"]))
+	assert.Equal(t, model.VariableTypeJSON, inferVariableType(values["ratio"]))
+	assert.Equal(t, model.VariableTypeBool, inferVariableType(values["enabled"]))
+	assert.Equal(t, model.VariableTypeList, inferVariableType(values["hosts"]))
+	assert.Equal(t, model.VariableTypeJSON, inferVariableType(values["ports"]))
+	assert.Equal(t, model.VariableTypeJSON, inferVariableType(values["resources"]))
+	assert.Equal(t, "", inferVariableType(values["empty"]))
+}
+
+func TestLookupChartDefault(t *testing.T) {
+	var values map[string]interface{}
+	json.Unmarshal([]byte(`{"db":{"port":5432},"name":"x"}`), &values)
+
+	value, ok := lookupChartDefault(values, "db.port")
+	assert.True(t, ok)
+	assert.Equal(t, float64(5432), value)
+
+	_, ok = lookupChartDefault(values, "db.host")
+	assert.False(t, ok)
+
+	_, ok = lookupChartDefault(values, "image.tag")
+	assert.False(t, ok)
+}
+
+func TestFormatVariableValue(t *testing.T) {
+	var values map[string]interface{}
+	json.Unmarshal([]byte(`{"replicas":1000000,"hosts":["a","b"],"resources":{"cpu":"1"}}`), &values)
+
+	assert.Equal(t, "1000000", formatVariableValue(values["replicas"], model.VariableTypeInt))
+	assert.Equal(t, "a,b", formatVariableValue(values["hosts"], model.VariableTypeList))
+	assert.Equal(t, `{"cpu":"1"}`, formatVariableValue(values["resources"], model.VariableTypeJSON))
+}
+
+func TestRenderVariable(t *testing.T) {
+	values, stringValues, err := renderVariable("app.code", "0123", model.VariableTypeString)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	assert.Equal(t, []string{"app.code=0123"}, stringValues)
+
+	_, stringValues, err = renderVariable("app.hosts", "a,b", model.VariableTypeString)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`app.hosts=a\,b`}, stringValues)
+
+	values, _, err = renderVariable("app.replicas", " 3 ", model.VariableTypeInt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.replicas=3"}, values)
+
+	values, _, err = renderVariable("app.enabled", "TRUE", model.VariableTypeBool)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.enabled=true"}, values)
+
+	values, stringValues, err = renderVariable("app.hosts", "a, b", model.VariableTypeList)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	assert.Equal(t, []string{"app.hosts[0]=a", "app.hosts[1]=b"}, stringValues)
+
+	values, stringValues, err = renderVariable("app.ports", "[80, \"http\"]", model.VariableTypeList)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.ports[0]=80"}, values)
+	assert.Equal(t, []string{"app.ports[1]=http"}, stringValues)
+
+	values, _, err = renderVariable("app.hosts", "", model.VariableTypeList)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.hosts={}"}, values)
+
+	values, stringValues, err = renderVariable("app.resources", `{"cpu":"1","replicas":2,"a.b":null}`, model.VariableTypeJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`app.resources.a\.b=null`, "app.resources.replicas=2"}, values)
+	assert.Equal(t, []string{"app.resources.cpu=1"}, stringValues)
+
+	values, _, err = renderVariable("app.other", "abc", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.other=abc"}, values)
+}
+
+func TestRenderVariable_Errors(t *testing.T) {
+	_, _, err := renderVariable("app.replicas", "three", model.VariableTypeInt)
+	assert.EqualError(t, err, `value "three" of variable app.replicas is not a valid int`)
+
+	_, _, err = renderVariable("app.enabled", "yes", model.VariableTypeBool)
+	assert.Error(t, err)
+
+	_, _, err = renderVariable("app.hosts", `[{"a":1}]`, model.VariableTypeList)
+	assert.Error(t, err)
+
+	_, _, err = renderVariable("app.resources", `{"cpu":`, model.VariableTypeJSON)
+	assert.Error(t, err)
+
+	_, _, err = renderVariable("app.x", "1", "float")
+	assert.EqualError(t, err, `invalid type "float" for variable app.x`)
+}
+
+func TestValidateVariableValue(t *testing.T) {
+	variable := model.Variable{Name: "replicas", Value: "${replicas}", Type: model.VariableTypeInt}
+	assert.NoError(t, validateVariableValue(variable))
+
+	variable.Type = "float"
+	assert.Error(t, validateVariableValue(variable))
+
+	variable.Value = "abc"
+	variable.Type = model.VariableTypeInt
+	assert.Error(t, validateVariableValue(variable))
+}
+
+func TestSaveVariableValues_InferTypeAndValidate(t *testing.T) {
+	appContext := AppContext{}
+
+	variable := mockVariable()
+	variable.Name = "replicas"
+	variable.Value = "two"
+	var varData model.VariableData
+	varData.Data = append(varData.Data, variable)
+
+	payloadStr, _ := json.Marshal(varData)
+	req, err := http.NewRequest("POST", "/saveVariableValues", bytes.NewBuffer(payloadStr))
+	assert.NoError(t, err)
+
+	mockPrincipal(req)
+
+	mockEnvDao := mockGetByID(&appContext)
+	mockEnvDao.On("GetAllEnvironments", "beta@alfa.com").Return([]model.Environment{mockGetEnv()}, nil)
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(`{"app":{"replicas":1}}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.saveVariableValues)
+	handler.ServeHTTP(rr, req)
+
+	mockVariableDAO.AssertNumberOfCalls(t, "CreateVariable", 0)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+	assert.Contains(t, rr.Body.String(), `value "two" of variable replicas is not a valid int`)
+}
+
+func TestGetHelmCommand_TypedVariables(t *testing.T) {
+	req, err := http.NewRequest("POST", "/getHelmCommand", getMultipleInstallPayload())
+	assert.NoError(t, err)
+
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockGetByID(&appContext)
+	mockKubeConfigProvider(&appContext)
+
+	code := mockVariable()
+	code.Name = "code"
+	code.Value = "0123"
+	code.Type = model.VariableTypeString
+	enabled := mockVariable()
+	enabled.Name = "enabled"
+	enabled.Value = "True"
+	enabled.Type = model.VariableTypeBool
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{mockGlobalVariable()}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, mock.Anything).Return([]model.Variable{code, enabled}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockHelmSvc := &mocks.HelmServiceInterface{}
+	mockHelmSvc.On("GetTemplate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(`{"app":{}}`), nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	mockPrincipal(req)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.getHelmCommand)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Ok.")
+	response := rr.Body.String()
+	assert.Contains(t, response, `--set-string "app.code=0123"`)
+	assert.Contains(t, response, `--set "app.enabled=true"`)
+}
+
+func TestSimpleInstall_QueuePayloadStringVariables(t *testing.T) {
+	appContext := AppContext{}
+	mockGroupVariables(&appContext)
+	mockConfiguration(&appContext)
+	mockKubeConfigProvider(&appContext)
+	mockChartSchema(&appContext, `{"app":{}}`, "")
+
+	code := mockVariable()
+	code.Name = "code"
+	code.Value = "0123"
+	code.Type = model.VariableTypeString
+
+	mockVariableDAO := &mockRepo.VariableDAOInterface{}
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, "global").Return([]model.Variable{}, nil)
+	mockVariableDAO.On("GetAllVariablesByEnvironmentAndScope", 999, mock.Anything).Return([]model.Variable{code}, nil)
+	appContext.Repositories.VariableDAO = mockVariableDAO
+
+	mockDeploymentDAO := &mockRepo.DeploymentDAOInterface{}
+	mockDeploymentDAO.On("CreateDeployment", mock.Anything).Return(1, nil)
+	appContext.Repositories.DeploymentDAO = mockDeploymentDAO
+
+	var queuePayload rabbitmq.PayloadRabbit
+	mockRabbitMQ := &mockRabbit.RabbitInterface{}
+	mockRabbitMQ.On("Publish", mock.Anything, "", rabbitmq.InstallQueue, false, false, mock.Anything).
+		Run(func(args mock.Arguments) {
+			json.Unmarshal(args.Get(5).(amqp.Publishing).Body, &queuePayload)
+		}).Return(nil)
+	appContext.RabbitImpl = mockRabbitMQ
+
+	environment := mockGetEnv()
+	payload := model.InstallPayload{Chart: "repo/my-chart", ChartVersion: "0.1.0", Name: "my-chart"}
+
+	_, err := appContext.simpleInstall(&environment, payload, &bytes.Buffer{}, false, false, "", 1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, rabbitmq.PayloadVersion, queuePayload.Version)
+	assert.Equal(t, []string{"app.code=0123"}, queuePayload.UpgradeRequest.StringVariables)
+	assert.Contains(t, queuePayload.UpgradeRequest.Variables, "app.code=0123")
+}
@@ -60,6 +60,11 @@ func (appContext *AppContext) editVariable(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	if err := validateVariableValue(payload.Data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if payload.Data.Secret {
 		secret := util.Encrypt([]byte(payload.Data.Value), appContext.Configuration.App.Passkey)
 		payload.Data.Value = hex.EncodeToString(secret)
@@ -85,7 +90,7 @@ func (appContext *AppContext) getVariables(w http.ResponseWriter, r *http.Reques
 	id, _ := strconv.Atoi(sl)
 	variableResult := &model.VariablesResult{}
 
-	has, failed := appContext.hasAccess(principal.Email, id)
+	has, failed := appContext.hasAccess(principal, id)
 	if failed != nil || !has {
 		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
 		return
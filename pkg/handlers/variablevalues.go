@@ -74,7 +74,7 @@ func (appContext *AppContext) saveVariableValues(w http.ResponseWriter, r *http.
 
 		global.Logger.Info(logFields, "Item: "+item.Scope+" => "+item.Name)
 
-		has, err := appContext.hasAccess(principal.Email, int(targetEnvironment.ID))
+		has, err := appContext.hasAccess(principal, int(targetEnvironment.ID))
 		if err != nil || !has {
 			global.Logger.Error(logFields, "Error appContext.hasAccess")
 			http.Error(w, errors.New("Access Denied in environment "+targetEnvironment.Namespace).Error(), http.StatusUnauthorized)
@@ -87,6 +87,18 @@ func (appContext *AppContext) saveVariableValues(w http.ResponseWriter, r *http.
 			return
 		}
 
+		if len(item.Type) == 0 {
+			if defaultValue, ok := lookupChartDefault(cacheVars[item.Scope], item.Name); ok {
+				item.Type = inferVariableType(defaultValue)
+			}
+		}
+
+		if err := validateVariableValue(item); err != nil {
+			global.Logger.Error(logFields, "Error validateVariableValue")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		var updated bool
 		var auditValues map[string]string
 		if auditValues, updated, err = appContext.Repositories.VariableDAO.CreateVariable(item); err != nil {
@@ -137,17 +149,17 @@ func (appContext *AppContext) saveVariablesWithDefaultValue(cacheVars map[string
 
 	for chartName, charts := range cacheVars {
 		for varName, varDefaultValue := range charts {
-			defaultValue := fmt.Sprintf("%v", varDefaultValue)
-
-			if strings.HasPrefix(defaultValue, "[map") || varName == "dateHour" || varName == "version" {
+			if strings.HasPrefix(fmt.Sprintf("%v", varDefaultValue), "[map") || varName == "dateHour" || varName == "version" {
 				continue
 			}
 
+			varType := inferVariableType(varDefaultValue)
 			item := model.Variable{
 				EnvironmentID: int(firstVar.EnvironmentID),
 				Scope:         chartName,
 				Name:          varName,
-				Value:         defaultValue,
+				Value:         formatVariableValue(varDefaultValue, varType),
+				Type:          varType,
 			}
 
 			var err error
@@ -166,20 +178,41 @@ func (appContext *AppContext) audit(updated bool, auditValues map[string]string,
 	targetEnvironment *model.Environment, principal model.Principal, r *http.Request) {
 
 	if updated {
-		auditValues["environment"] = targetEnvironment.Name
-		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, principal.Email, "saveVariable", auditValues)
+		appContext.Auditing.DoAudit(r.Context(), appContext.Elk, model.AuditEvent{
+			Actor:         principal.Email,
+			Action:        "saveVariable",
+			EnvironmentID: targetEnvironment.ID,
+			ResourceType:  "variable",
+			ResourceID:    auditValues["scope"] + ":" + auditValues["variable_name"],
+			Before:        auditValues["variable_old_value"],
+			After:         auditValues["variable_new_value"],
+		})
+		appContext.triggerWebHooks(int(targetEnvironment.ID), model.HookVariableChanged, model.WebHookVariablePayload{
+			Event:         model.HookVariableChanged,
+			Environment:   targetEnvironment.Name,
+			EnvironmentID: targetEnvironment.ID,
+			Scope:         auditValues["scope"],
+			Name:          auditValues["variable_name"],
+			User:          principal.Email,
+		})
 	}
 }
 
 func (appContext *AppContext) getHelmChartAppVars(chart string, chartVersion string) (map[string]interface{}, error) {
 
-	if strings.HasSuffix(chart, "-gcm") {
-		var config model.ConfigMap
-		var err error
-		if config, err = appContext.Repositories.ConfigDAO.GetConfigByName("commonValuesConfigMapChart"); err != nil {
-			return nil, err
-		}
-		chart = config.Value
+	values, err := appContext.getHelmChartValues(chart, chartVersion)
+	if err != nil {
+		return nil, err
+	}
+	return getAppValues(values), nil
+}
+
+//getHelmChartValues retrieves all default values of a chart
+func (appContext *AppContext) getHelmChartValues(chart string, chartVersion string) (map[string]interface{}, error) {
+
+	chart, err := appContext.getValuesChart(chart)
+	if err != nil {
+		return nil, err
 	}
 
 	chartVariables, err := appContext.HelmServiceAPI.GetTemplate(&appContext.Mutex, chart, chartVersion, "values")
@@ -190,9 +223,31 @@ func (appContext *AppContext) getHelmChartAppVars(chart string, chartVersion str
 
 	var result map[string]interface{}
 	json.Unmarshal(chartVariables, &result)
-	app := result["app"].(map[string]interface{})
+	if result == nil {
+		result = make(map[string]interface{})
+	}
+
+	return result, nil
+}
 
-	return app, nil
+//getValuesChart returns the chart holding the values of a deployable, gcm deployables use the common chart
+func (appContext *AppContext) getValuesChart(chart string) (string, error) {
+	if strings.HasSuffix(chart, "-gcm") {
+		config, err := appContext.Repositories.ConfigDAO.GetConfigByName("commonValuesConfigMapChart")
+		if err != nil {
+			return "", err
+		}
+		return config.Value, nil
+	}
+	return chart, nil
+}
+
+func getAppValues(values map[string]interface{}) map[string]interface{} {
+	app, ok := values["app"].(map[string]interface{})
+	if !ok {
+		return make(map[string]interface{})
+	}
+	return app
 }
 
 func (appContext *AppContext) getVariablesByEnvironmentAndScope(w http.ResponseWriter, r *http.Request) {
@@ -213,7 +268,7 @@ func (appContext *AppContext) getVariablesByEnvironmentAndScope(w http.ResponseW
 		return
 	}
 
-	has, failed := appContext.hasAccess(principal.Email, payload.EnvironmentID)
+	has, failed := appContext.hasAccess(principal, payload.EnvironmentID)
 	if failed != nil || !has {
 		http.Error(w, errors.New("Access Denied in this environment").Error(), http.StatusUnauthorized)
 		return
@@ -279,7 +334,11 @@ func (appContext *AppContext) getVariablesNotUsed(w http.ResponseWriter, r *http
 		http.Error(w, err.Error(), 501)
 		return
 	}
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	helmReleases, err := appContext.HelmServiceAPI.ListHelmDeployments(kubeConfig, environment.Namespace)
 
 	result := make([]responseResult, 0)
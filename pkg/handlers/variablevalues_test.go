@@ -49,7 +49,8 @@ func TestSaveVariableValues(t *testing.T) {
 	mockVariableDAO.On("CreateVariableWithDefaultValue", mock.Anything).Return(auditValues, true, nil)
 	mockVariableDAO.On("CreateVariable", mock.Anything).Return(auditValues, true, nil)
 
-	mockAudit := mockDoAudit(&appContext, "saveVariable", auditValues)
+	mockAudit := mockDoAudit(&appContext, model.AuditEvent{Action: "saveVariable", EnvironmentID: 999,
+		ResourceType: "variable", ResourceID: variable.Scope + ":" + variable.Name, After: variable.Value})
 
 	appContext.Repositories.EnvironmentDAO = mockEnvDao
 	appContext.Repositories.VariableDAO = mockVariableDAO
@@ -246,7 +247,7 @@ func TestGetVariablesNotUsed(t *testing.T) {
 	mockEnvDao := mockGetByID(&appContext)
 	appContext.Repositories.VariableDAO = mockVariableDAO
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 	mockHelmSvc := mockListHelmDeployments(&appContext)
 
 	req, err := http.NewRequest("GET", "/getVariablesNotUsed/999", bytes.NewBuffer(nil))
@@ -260,7 +261,7 @@ func TestGetVariablesNotUsed(t *testing.T) {
 
 	mockVariableDAO.AssertNumberOfCalls(t, "GetAllVariablesByEnvironment", 1)
 	mockEnvDao.AssertNumberOfCalls(t, "GetByID", 1)
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 	mockHelmSvc.AssertNumberOfCalls(t, "ListHelmDeployments", 1)
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
@@ -26,12 +26,15 @@ func (appContext *AppContext) handleEnvironment(r *http.Request) (string, string
 		return "", "", err
 	}
 
-	has, err := appContext.hasAccess(principal.Email, envID)
+	has, err := appContext.hasAccess(principal, envID)
 	if err != nil || !has {
 		return "", "", errors.New("Access Denied in this environment")
 	}
 
-	kubeConfig := appContext.ConventionInterface.GetKubeConfigFileName(environment.Group, environment.Name)
+	kubeConfig, err := appContext.getKubeConfig(environment)
+	if err != nil {
+		return "", "", err
+	}
 
 	return kubeConfig, environment.Name, nil
 
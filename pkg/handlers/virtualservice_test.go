@@ -30,7 +30,7 @@ func TestGetVirtualServices(t *testing.T) {
 	result = append(result, "test.com.br")
 	mockHelmSvc.On("GetVirtualServices", mock.Anything, mock.Anything).Return(result, nil)
 
-	mockConvention := mockConventionInterface(&appContext)
+	mockKubeConfig := mockKubeConfigProvider(&appContext)
 
 	appContext.HelmServiceAPI = mockHelmSvc
 
@@ -45,6 +45,6 @@ func TestGetVirtualServices(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rr.Code, "Response should be Created.")
 
-	mockConvention.AssertNumberOfCalls(t, "GetKubeConfigFileName", 1)
+	mockKubeConfig.AssertNumberOfCalls(t, "GetKubeConfig", 1)
 
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//triggerWebHooks dispatches an event to the webhooks of its type subscribed to an environment,
+//environmentID is -1 for the events not bound to an environment. Nothing is sent when webhooks are disabled.
+func (appContext *AppContext) triggerWebHooks(environmentID int, hookType string, payload interface{}) {
+	if appContext.WebHookDispatcher == nil {
+		return
+	}
+
+	webHooks, err := appContext.Repositories.WebHookDAO.ListWebHooksByEnvAndType(environmentID, hookType)
+	if err != nil {
+		log.Println("Error trying to find webhooks", err)
+		return
+	}
+
+	for _, hook := range webHooks {
+		if _, err := appContext.WebHookDispatcher.Dispatch(hook, payload); err != nil {
+			log.Println("Error trying to dispatch webhook: ", hook.URL, err)
+		}
+	}
+}
+
+//triggerDeploymentWebHook dispatches an event of the lifecycle of a single chart deploy
+func (appContext *AppContext) triggerDeploymentWebHook(hookType string, deployment model.Deployment, environment string) {
+	appContext.triggerWebHooks(int(deployment.EnvironmentID), hookType, model.WebHookDeploymentPayload{
+		Event:               hookType,
+		Environment:         environment,
+		EnvironmentID:       deployment.EnvironmentID,
+		Chart:               deployment.Chart,
+		DeploymentID:        deployment.ID,
+		RequestDeploymentID: deployment.RequestDeploymentID,
+		Message:             deployment.Message,
+	})
+}
+
+//triggerDeploymentResultWebHook dispatches the result of a single chart deploy received from the worker
+func (appContext *AppContext) triggerDeploymentResultWebHook(deployment model.Deployment) {
+	if appContext.WebHookDispatcher == nil {
+		return
+	}
+
+	hookType := model.HookDeploySucceeded
+	if !deployment.Success {
+		hookType = model.HookDeployFailed
+	}
+
+	var environment string
+	if env, err := appContext.Repositories.EnvironmentDAO.GetByID(int(deployment.EnvironmentID)); err == nil {
+		environment = env.Name
+	}
+	appContext.triggerDeploymentWebHook(hookType, deployment, environment)
+}
+
+//maxDeploymentsPerRequest is the number of charts of a deploy request listed in HOOK_DEPLOY_FINISHED
+const maxDeploymentsPerRequest = 1000
+
+//notifyDeployFinished reports the result of a deploy request once every chart of it was processed,
+//to the webhooks of the environment and by email to the user who requested it
+func (appContext *AppContext) notifyDeployFinished(rd model.RequestDeployment, environmentID uint) {
+	if appContext.WebHookDispatcher == nil && appContext.Mailer == nil {
+		return
+	}
+
+	payload := model.WebHookDeployFinishedPayload{
+		Event:               model.HookDeployFinished,
+		EnvironmentID:       environmentID,
+		RequestDeploymentID: rd.ID,
+		Success:             rd.Success,
+		Charts:              make([]string, 0),
+		Failures:            make([]string, 0),
+	}
+	if env, err := appContext.Repositories.EnvironmentDAO.GetByID(int(environmentID)); err == nil {
+		payload.Environment = env.Name
+	}
+	if user, err := appContext.Repositories.UserDAO.FindByID(int(rd.UserID)); err == nil {
+		payload.User = user.Email
+	}
+
+	deployments, err := appContext.Repositories.DeploymentDAO.ListDeployments("", strconv.Itoa(int(rd.ID)), 1, maxDeploymentsPerRequest)
+	if err != nil {
+		log.Println("Error trying to list deployments of request", rd.ID, err)
+	}
+	for _, d := range deployments {
+		payload.Charts = append(payload.Charts, d.Chart)
+		if !d.Success {
+			payload.Failures = append(payload.Failures, d.Chart+": "+d.Message)
+		}
+	}
+
+	appContext.triggerWebHooks(int(environmentID), model.HookDeployFinished, payload)
+	//SMTP may be slow, so the email is sent without holding the queue consumer
+	go appContext.emailDeployFinished(rd.UserID, payload, deployments)
+}
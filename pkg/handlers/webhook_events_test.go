@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	mockSvc "github.com/softplan/tenkai-api/pkg/service/_helm/mocks"
+	mockWebhook "github.com/softplan/tenkai-api/pkg/webhook/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockTriggerWebHooks(appContext *AppContext, environmentID int, hookType string) (*mockRepo.WebHookDAOInterface, *mockWebhook.DispatcherInterface) {
+	hook := mockWebHookWithID()
+	hook.Type = hookType
+	mockWebHookDAO := &mockRepo.WebHookDAOInterface{}
+	mockWebHookDAO.On("ListWebHooksByEnvAndType", environmentID, hookType).Return([]model.WebHook{hook}, nil)
+	appContext.Repositories.WebHookDAO = mockWebHookDAO
+
+	mockDispatcher := &mockWebhook.DispatcherInterface{}
+	appContext.WebHookDispatcher = mockDispatcher
+	return mockWebHookDAO, mockDispatcher
+}
+
+func TestTriggerWebHooks(t *testing.T) {
+	appContext := AppContext{}
+	hook := model.WebHook{URL: "http://example.com"}
+	other := model.WebHook{URL: "http://example.org"}
+
+	mockWebHookDAO := &mockRepo.WebHookDAOInterface{}
+	mockWebHookDAO.On("ListWebHooksByEnvAndType", 999, model.HookRollback).Return([]model.WebHook{hook, other}, nil)
+	appContext.Repositories.WebHookDAO = mockWebHookDAO
+	mockDispatcher := &mockWebhook.DispatcherInterface{}
+	mockDispatcher.On("Dispatch", hook, "payload").Return(0, errors.New("some error"))
+	mockDispatcher.On("Dispatch", other, "payload").Return(1, nil)
+	appContext.WebHookDispatcher = mockDispatcher
+
+	appContext.triggerWebHooks(999, model.HookRollback, "payload")
+
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 2)
+}
+
+func TestTriggerWebHooks_Disabled(t *testing.T) {
+	appContext := AppContext{}
+	mockWebHookDAO := &mockRepo.WebHookDAOInterface{}
+	appContext.Repositories.WebHookDAO = mockWebHookDAO
+
+	appContext.triggerWebHooks(999, model.HookRollback, "payload")
+
+	mockWebHookDAO.AssertNumberOfCalls(t, "ListWebHooksByEnvAndType", 0)
+}
+
+func TestTriggerDeploymentResultWebHook(t *testing.T) {
+	appContext := AppContext{}
+	mockGetByID(&appContext)
+	_, mockDispatcher := mockTriggerWebHooks(&appContext, 999, model.HookDeployFailed)
+	mockDispatcher.On("Dispatch", mock.Anything, model.WebHookDeploymentPayload{
+		Event:         model.HookDeployFailed,
+		Environment:   "bar",
+		EnvironmentID: 999,
+		Chart:         "repo/my-chart",
+		Message:       "timeout",
+	}).Return(1, nil)
+
+	deployment := model.Deployment{EnvironmentID: 999, Chart: "repo/my-chart", Success: false, Message: "timeout"}
+	appContext.triggerDeploymentResultWebHook(deployment)
+
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 1)
+}
+
+func TestRollback_TriggersWebHook(t *testing.T) {
+	payloadStr, _ := json.Marshal(getRevision())
+	req, err := http.NewRequest("POST", "/rollback", bytes.NewBuffer(payloadStr))
+	assert.NoError(t, err)
+	mockPrincipal(req)
+
+	appContext := AppContext{}
+	mockGetByID(&appContext)
+	mockKubeConfigProvider(&appContext)
+	mockHelmSvc := &mockSvc.HelmServiceInterface{}
+	mockHelmSvc.On("RollbackRelease", "./config/foo_bar", "foo", 800).Return(nil)
+	appContext.HelmServiceAPI = mockHelmSvc
+
+	_, mockDispatcher := mockTriggerWebHooks(&appContext, 999, model.HookRollback)
+	mockDispatcher.On("Dispatch", mock.Anything, model.WebHookReleasePayload{
+		Event:         model.HookRollback,
+		Environment:   "bar",
+		EnvironmentID: 999,
+		Release:       "foo",
+		Revision:      800,
+		User:          "beta@alfa.com",
+	}).Return(1, nil)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.rollback)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response is not Ok.")
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 1)
+}
+
+func TestTriggerProductVersionLockedWebHook(t *testing.T) {
+	appContext := AppContext{}
+	mockProductDAO := &mockRepo.ProductDAOInterface{}
+	mockProductDAO.On("FindProductByID", 1).Return(model.Product{Name: "My Product"}, nil)
+	appContext.Repositories.ProductDAO = mockProductDAO
+
+	_, mockDispatcher := mockTriggerWebHooks(&appContext, -1, model.HookProductVersionLocked)
+	mockDispatcher.On("Dispatch", mock.Anything, model.WebHookProductVersionPayload{
+		Event:            model.HookProductVersionLocked,
+		ProductName:      "My Product",
+		Release:          "19.0.1",
+		ProductVersionID: 7,
+		User:             "beta@alfa.com",
+	}).Return(1, nil)
+
+	pv := model.ProductVersion{ProductID: 1, Version: "19.0.1"}
+	pv.ID = 7
+	appContext.triggerProductVersionLockedWebHook(&pv, "beta@alfa.com")
+
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 1)
+}
+
+func TestNotifyDeployFinished_WebHook(t *testing.T) {
+	appContext := AppContext{}
+	mockGetByID(&appContext)
+	_, mockDispatcher := mockTriggerWebHooks(&appContext, 999, model.HookDeployFinished)
+
+	mockUserDAO := &mockRepo.UserDAOInterface{}
+	mockUserDAO.On("FindByID", 5).Return(model.User{Email: "beta@alfa.com"}, nil)
+	appContext.Repositories.UserDAO = mockUserDAO
+
+	mockDeploymentDAO := &mockRepo.DeploymentDAOInterface{}
+	mockDeploymentDAO.On("ListDeployments", "", "10", 1, maxDeploymentsPerRequest).Return([]model.Deployments{
+		{Chart: "repo/alfa", Success: true},
+		{Chart: "repo/beta", Success: false, Message: "timeout"},
+	}, nil)
+	appContext.Repositories.DeploymentDAO = mockDeploymentDAO
+
+	mockDispatcher.On("Dispatch", mock.Anything, model.WebHookDeployFinishedPayload{
+		Event:               model.HookDeployFinished,
+		Environment:         "bar",
+		EnvironmentID:       999,
+		RequestDeploymentID: 10,
+		Success:             false,
+		User:                "beta@alfa.com",
+		Charts:              []string{"repo/alfa", "repo/beta"},
+		Failures:            []string{"repo/beta: timeout"},
+	}).Return(1, nil)
+
+	rd := model.RequestDeployment{Success: false, Processed: true, UserID: 5}
+	rd.ID = 10
+	appContext.notifyDeployFinished(rd, 999)
+
+	mockDispatcher.AssertNumberOfCalls(t, "Dispatch", 1)
+}
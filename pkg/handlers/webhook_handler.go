@@ -10,8 +10,17 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/softplan/tenkai-api/pkg/webhook"
 )
 
+//encryptWebHookSecret encrypts the secret used to sign the requests of a webhook, to be stored
+func (appContext *AppContext) encryptWebHookSecret(secret string) string {
+	if len(secret) == 0 {
+		return secret
+	}
+	return encryptCredential(secret, appContext.Configuration.App.Passkey)
+}
+
 func (appContext *AppContext) newWebHook(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set(global.ContentType, global.JSONContentType)
@@ -23,6 +32,13 @@ func (appContext *AppContext) newWebHook(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !webhook.IsValidChannel(payload.Channel) {
+		http.Error(w, "invalid channel "+payload.Channel, http.StatusBadRequest)
+		return
+	}
+
+	payload.Secret = appContext.encryptWebHookSecret(payload.Secret)
+
 	if _, err := appContext.Repositories.WebHookDAO.CreateWebHook(payload); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -41,6 +57,23 @@ func (appContext *AppContext) editWebHook(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !webhook.IsValidChannel(payload.Channel) {
+		http.Error(w, "invalid channel "+payload.Channel, http.StatusBadRequest)
+		return
+	}
+
+	//An edit that omits the secret or sends it back masked keeps the stored secret
+	if len(payload.Secret) == 0 || payload.Secret == MaskedCredential {
+		stored, err := appContext.Repositories.WebHookDAO.GetWebHook(int(payload.ID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		payload.Secret = stored.Secret
+	} else {
+		payload.Secret = appContext.encryptWebHookSecret(payload.Secret)
+	}
+
 	if err := appContext.Repositories.WebHookDAO.EditWebHook(payload); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -73,8 +106,70 @@ func (appContext *AppContext) listWebHooks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	for i := range result.List {
+		if len(result.List[i].Secret) > 0 {
+			result.List[i].Secret = MaskedCredential
+		}
+	}
+
+	data, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+
+}
+
+func (appContext *AppContext) listWebHookDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := &model.WebHookDeliveryResponse{}
+	if result.List, err = appContext.Repositories.WebHookDeliveryDAO.ListWebHookDeliveries(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	data, _ := json.Marshal(result)
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 
 }
+
+func (appContext *AppContext) redeliverWebHook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set(global.ContentType, global.JSONContentType)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := appContext.Repositories.WebHookDeliveryDAO.GetWebHookDelivery(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	hook, err := appContext.Repositories.WebHookDAO.GetWebHook(int(delivery.WebHookID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	deliveryID, err := appContext.WebHookDispatcher.Redeliver(hook, delivery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(map[string]int{"id": deliveryID})
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/softplan/tenkai-api/pkg/dbms/model"
 	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	mockWebhook "github.com/softplan/tenkai-api/pkg/webhook/mocks"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,6 +79,7 @@ func TestEditWebHook(t *testing.T) {
 	p := mockWebHook()
 
 	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("GetWebHook", 0).Return(p, nil)
 	mockWebHook.On("EditWebHook", p).Return(nil)
 
 	appContext.Repositories.WebHookDAO = mockWebHook
@@ -106,6 +108,7 @@ func TestEditWebHook_Error(t *testing.T) {
 	p := mockWebHookWithID()
 
 	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("GetWebHook", 999).Return(p, nil)
 	mockWebHook.On("EditWebHook", p).Return(errors.New("some error"))
 
 	appContext.Repositories.WebHookDAO = mockWebHook
@@ -191,7 +194,8 @@ func TestListWebHook(t *testing.T) {
 	assert.Contains(t, response, `"type":"HOOK_DEPLOY_PRODUCT"`)
 	assert.Contains(t, response, `"url":"http://example.com"`)
 	assert.Contains(t, response, `"environmentId":999`)
-	assert.Contains(t, response, `"additionalData":""}]}`)
+	assert.Contains(t, response, `"additionalData":""`)
+	assert.Contains(t, response, `"secret":""`)
 }
 
 func TestListWebHook_Error(t *testing.T) {
@@ -213,3 +217,141 @@ func TestListWebHook_Error(t *testing.T) {
 	mockWebHook.AssertNumberOfCalls(t, "ListWebHooks", 1)
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Response should be 500")
 }
+
+func TestEditWebHook_KeepsMaskedSecret(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockWebHookWithID()
+	p.Secret = MaskedCredential
+	stored := mockWebHookWithID()
+	stored.Secret = "encrypted"
+
+	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("GetWebHook", 999).Return(stored, nil)
+	mockWebHook.On("EditWebHook", stored).Return(nil)
+	appContext.Repositories.WebHookDAO = mockWebHook
+
+	req, err := http.NewRequest("POST", "/webhooks/edit", payload(p))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.editWebHook)
+	handler.ServeHTTP(rr, req)
+
+	mockWebHook.AssertNumberOfCalls(t, "EditWebHook", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok")
+}
+
+func TestEditWebHook_KeepsOmittedSecret(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockWebHookWithID()
+	stored := mockWebHookWithID()
+	stored.Secret = "encrypted"
+
+	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("GetWebHook", 999).Return(stored, nil)
+	mockWebHook.On("EditWebHook", stored).Return(nil)
+	appContext.Repositories.WebHookDAO = mockWebHook
+
+	req, err := http.NewRequest("POST", "/webhooks/edit", payload(p))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.editWebHook)
+	handler.ServeHTTP(rr, req)
+
+	mockWebHook.AssertNumberOfCalls(t, "EditWebHook", 1)
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok")
+}
+
+func TestListWebHook_MasksSecret(t *testing.T) {
+	appContext := AppContext{}
+
+	hook := mockWebHookWithID()
+	hook.Secret = "encrypted"
+	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("ListWebHooks").Return([]model.WebHook{hook}, nil)
+	appContext.Repositories.WebHookDAO = mockWebHook
+
+	req, err := http.NewRequest("GET", "/webhooks", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.listWebHooks)
+	handler.ServeHTTP(rr, req)
+
+	assert.Contains(t, rr.Body.String(), `"secret":"`+MaskedCredential+`"`)
+}
+
+func TestListWebHookDeliveries(t *testing.T) {
+	appContext := AppContext{}
+
+	delivery := model.WebHookDelivery{WebHookID: 999, Status: model.WebHookDeliveryFailed, StatusCode: 500, ResponseBody: "boom"}
+	mockDeliveryDAO := &mockRepo.WebHookDeliveryDAOInterface{}
+	mockDeliveryDAO.On("ListWebHookDeliveries", 999).Return([]model.WebHookDelivery{delivery}, nil)
+	appContext.Repositories.WebHookDeliveryDAO = mockDeliveryDAO
+
+	req, err := http.NewRequest("GET", "/webhooks/999/deliveries", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/webhooks/{id}/deliveries", appContext.listWebHookDeliveries).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Response should be ok")
+	assert.Contains(t, rr.Body.String(), `"statusCode":500`)
+	assert.Contains(t, rr.Body.String(), `"responseBody":"boom"`)
+}
+
+func TestRedeliverWebHook(t *testing.T) {
+	appContext := AppContext{}
+
+	delivery := model.WebHookDelivery{WebHookID: 999, Payload: "{}"}
+	delivery.ID = 7
+	mockDeliveryDAO := &mockRepo.WebHookDeliveryDAOInterface{}
+	mockDeliveryDAO.On("GetWebHookDelivery", 7).Return(delivery, nil)
+	appContext.Repositories.WebHookDeliveryDAO = mockDeliveryDAO
+
+	hook := mockWebHookWithID()
+	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	mockWebHook.On("GetWebHook", 999).Return(hook, nil)
+	appContext.Repositories.WebHookDAO = mockWebHook
+
+	mockDispatcher := &mockWebhook.DispatcherInterface{}
+	mockDispatcher.On("Redeliver", hook, delivery).Return(8, nil)
+	appContext.WebHookDispatcher = mockDispatcher
+
+	req, err := http.NewRequest("POST", "/webhooks/deliveries/7/redeliver", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/webhooks/deliveries/{id}/redeliver", appContext.redeliverWebHook).Methods("POST")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code, "Response should be 202")
+	assert.Equal(t, `{"id":8}`, rr.Body.String())
+	mockDispatcher.AssertNumberOfCalls(t, "Redeliver", 1)
+}
+
+func TestNewWebHook_InvalidChannel(t *testing.T) {
+	appContext := AppContext{}
+
+	p := mockWebHook()
+	p.Channel = "irc"
+
+	mockWebHook := &mockRepo.WebHookDAOInterface{}
+	appContext.Repositories.WebHookDAO = mockWebHook
+
+	req, err := http.NewRequest("POST", "/webhooks", payload(p))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(appContext.newWebHook)
+	handler.ServeHTTP(rr, req)
+
+	mockWebHook.AssertNumberOfCalls(t, "CreateWebHook", 0)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Response should be 400.")
+}
@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/configs"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//Defaults of the mailer
+const (
+	DefaultPort    = "25"
+	DefaultTimeout = 10 * time.Second
+)
+
+//MailerInterface MailerInterface
+type MailerInterface interface {
+	Send(to []string, subject string, body string) error
+}
+
+//Mailer sends plain text emails through a SMTP server, upgrading the connection
+//with STARTTLS when the server supports it
+type Mailer struct {
+	Config  configs.SMTP
+	Timeout time.Duration
+}
+
+//NewMailer creates a mailer with the default timeout
+func NewMailer(config configs.SMTP) *Mailer {
+	return &Mailer{Config: config, Timeout: DefaultTimeout}
+}
+
+//Send sends an email, the whole conversation with the server must end before the timeout
+func (m *Mailer) Send(to []string, subject string, body string) error {
+	port := m.Config.Port
+	if len(port) == 0 {
+		port = DefaultPort
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.Config.Host, port), m.Timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(m.Timeout))
+
+	client, err := smtp.NewClient(conn, m.Config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Config.Host}); err != nil {
+			return err
+		}
+	}
+	if len(m.Config.Username) > 0 {
+		if err := client.Auth(smtp.PlainAuth("", m.Config.Username, m.Config.Password, m.Config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.Config.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.Config.From, to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMessage(from string, to []string, subject string, body string) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.Replace(strings.Replace(body, "\r\n", "\n", -1), "\n", "\r\n", -1))
+	return message.Bytes()
+}
+
+//DeployFinishedMessage returns the subject and the body of the email sent to the user who
+//requested a deploy, listing the charts that succeeded and failed with the messages of the workers
+func DeployFinishedMessage(summary model.WebHookDeployFinishedPayload, deployments []model.Deployments) (string, string) {
+	result := "succeeded"
+	if !summary.Success {
+		result = "failed"
+	}
+	subject := fmt.Sprintf("[Tenkai] Deploy %s in %s", result, summary.Environment)
+
+	var succeeded, failed []model.Deployments
+	for _, d := range deployments {
+		if d.Success {
+			succeeded = append(succeeded, d)
+		} else {
+			failed = append(failed, d)
+		}
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "Your deploy request #%d in %s %s.\n", summary.RequestDeploymentID, summary.Environment, result)
+	writeDeployments(&body, "Succeeded", succeeded)
+	writeDeployments(&body, "Failed", failed)
+	return subject, body.String()
+}
+
+func writeDeployments(body *bytes.Buffer, title string, deployments []model.Deployments) {
+	if len(deployments) == 0 {
+		return
+	}
+	fmt.Fprintf(body, "\n%s:\n", title)
+	for _, d := range deployments {
+		if len(d.Message) > 0 {
+			fmt.Fprintf(body, "  - %s: %s\n", d.Chart, d.Message)
+		} else {
+			fmt.Fprintf(body, "  - %s\n", d.Chart)
+		}
+	}
+}
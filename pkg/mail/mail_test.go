@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/configs"
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+//received is what the fake SMTP server got from the client
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+//startFakeSMTPServer starts a SMTP stand-in accepting a single message
+func startFakeSMTPServer(t *testing.T) (string, string, chan received) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	result := make(chan received, 1)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var message received
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				message.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				message.to = append(message.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				tp.PrintfLine("250 OK")
+			case command == "DATA":
+				tp.PrintfLine("354 Go ahead")
+				data, _ := tp.ReadDotBytes()
+				message.data = string(data)
+				tp.PrintfLine("250 OK")
+			case command == "QUIT":
+				tp.PrintfLine("221 Bye")
+				result <- message
+				return
+			default:
+				tp.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, result
+}
+
+func TestSend(t *testing.T) {
+	host, port, result := startFakeSMTPServer(t)
+
+	mailer := NewMailer(configs.SMTP{Host: host, Port: port, From: "tenkai@localhost"})
+	err := mailer.Send([]string{"beta@alfa.com"}, "Deploy finished", "line 1\nline 2")
+	assert.NoError(t, err)
+
+	select {
+	case message := <-result:
+		assert.Equal(t, "tenkai@localhost", message.from)
+		assert.Equal(t, []string{"beta@alfa.com"}, message.to)
+		assert.Contains(t, message.data, "Subject: Deploy finished\n")
+		assert.Contains(t, message.data, "To: beta@alfa.com\n")
+		assert.True(t, strings.HasSuffix(message.data, "\nline 1\nline 2\n"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the message was not received")
+	}
+}
+
+func TestSend_ConnectionRefused(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	mailer := NewMailer(configs.SMTP{Host: host, Port: port, From: "tenkai@localhost"})
+	assert.Error(t, mailer.Send([]string{"beta@alfa.com"}, "subject", "body"))
+}
+
+func TestSend_Timeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	mailer := NewMailer(configs.SMTP{Host: host, Port: port})
+	mailer.Timeout = 100 * time.Millisecond
+	assert.Error(t, mailer.Send([]string{"beta@alfa.com"}, "subject", "body"))
+}
+
+func TestDeployFinishedMessage(t *testing.T) {
+	summary := model.WebHookDeployFinishedPayload{Environment: "dev", RequestDeploymentID: 10, Success: false}
+	deployments := []model.Deployments{
+		{Chart: "repo/alfa", Success: true, Message: "installed"},
+		{Chart: "repo/beta", Success: false, Message: "timeout"},
+	}
+
+	subject, body := DeployFinishedMessage(summary, deployments)
+
+	assert.Equal(t, "[Tenkai] Deploy failed in dev", subject)
+	assert.Equal(t, "Your deploy request #10 in dev failed.\n"+
+		"\nSucceeded:\n  - repo/alfa: installed\n"+
+		"\nFailed:\n  - repo/beta: timeout\n", body)
+}
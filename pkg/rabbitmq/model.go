@@ -4,16 +4,27 @@ import (
 	helmapi "github.com/softplan/tenkai-api/pkg/service/_helm"
 )
 
-//PayloadRabbit consumer
+//PayloadVersion is the version of the install queue contract sent in PayloadRabbit.Version.
+//Version 1 (no version field) only had UpgradeRequest.Variables, rendered with --set.
+//Version 2 adds UpgradeRequest.StringVariables, rendered with --set-string. Until every worker
+//reads them, the string variables are sent in Variables as well; a version 2 worker applies
+//StringVariables after Variables, so the string values win as they do in helm.
+//The filename of the kubeconfig is no longer sent, as tenkai-api does not write kubeconfig files anymore:
+//workers build it from ClusterURI, Token and CACertificate.
+const PayloadVersion = 2
+
+//PayloadRabbit consumer - Token and CACertificate are encrypted with the key shared with the worker
 type PayloadRabbit struct {
+	Version        int                    `json:"version"`
 	UpgradeRequest helmapi.UpgradeRequest `json:"upgradeRequest"`
+	EnvironmentID  uint                   `json:"environment_id"`
 	Name           string                 `json:"name"`
 	Token          string                 `json:"token"`
-	Filename       string                 `json:"filename"`
 	CACertificate  string                 `json:"ca_certificate"`
 	ClusterURI     string                 `json:"cluster_uri"`
 	Namespace      string                 `json:"namespace"`
 	DeploymentID   uint                   `json:"deployment_id"`
+	Encrypted      bool                   `json:"encrypted"`
 }
 
 //RabbitPayloadConsumer consumer
@@ -31,6 +31,7 @@ type HelmServiceInterface interface {
 	GetTemplate(mutex *sync.Mutex, chartName string, version string, kind string) ([]byte, error)
 	GetDeployment(chartName string, version string) ([]byte, error)
 	GetValues(chartName string, version string) ([]byte, error)
+	GetSchema(chartName string, version string) ([]byte, error)
 	ListHelmDeployments(kubeconfig string, namespace string) (*HelmListResult, error)
 	RepoUpdate() error
 	RollbackRelease(kubeconfig string, releaseName string, revision int) error
@@ -39,6 +40,9 @@ type HelmServiceInterface interface {
 	GetHelmConnection() HelmConnection
 	HelmCommandExecutor(fn HelmExecutorFunc) HelmExecutorFunc
 	GetVirtualServices(kubeconfig string, namespace string) ([]string, error)
+	CheckConnectivity(kubeconfig string, namespace string) []model.ConnectivityCheck
+	CreateNamespace(kubeconfig string, namespace string, labels map[string]string, quota map[string]string) error
+	DeleteNamespace(kubeconfig string, namespace string) error
 }
 
 //HelmServiceImpl - Concrete type
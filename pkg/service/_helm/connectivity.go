@@ -0,0 +1,97 @@
+package helmapi
+
+import (
+	"strings"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+//Connectivity check names
+const (
+	CheckAPI       = "api"
+	CheckNamespace = "namespace"
+	CheckTiller    = "tiller"
+	CheckRBAC      = "rbac"
+)
+
+//requiredPermissions lists the operations tenkai runs against a cluster
+func requiredPermissions(namespace string) []authorizationv1.ResourceAttributes {
+	return []authorizationv1.ResourceAttributes{
+		{Namespace: namespace, Verb: "list", Resource: "pods"},
+		{Namespace: namespace, Verb: "delete", Resource: "pods"},
+		{Namespace: namespace, Verb: "list", Resource: "services"},
+		{Namespace: namespace, Verb: "list", Group: "networking.istio.io", Resource: "virtualservices"},
+		{Namespace: tillerNamespace, Verb: "list", Resource: "pods"},
+		{Namespace: tillerNamespace, Verb: "create", Resource: "pods", Subresource: "portforward"},
+	}
+}
+
+//CheckConnectivity - Verify API reachability, namespace existence, permissions and tiller reachability of a cluster
+func (svc HelmServiceImpl) CheckConnectivity(kubeconfig string, namespace string) []model.ConnectivityCheck {
+	checks := make([]model.ConnectivityCheck, 0)
+
+	_, client, err := svc.GetHelmConnection().GetKubeClient("", kubeconfig)
+	if err == nil {
+		_, err = client.Discovery().ServerVersion()
+	}
+	checks = append(checks, newConnectivityCheck(CheckAPI, err))
+	if err != nil {
+		return checks
+	}
+
+	_, err = client.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	checks = append(checks, newConnectivityCheck(CheckNamespace, err))
+
+	for _, attributes := range requiredPermissions(namespace) {
+		checks = append(checks, checkPermission(client, attributes))
+	}
+
+	checks = append(checks, svc.checkTiller(kubeconfig))
+	return checks
+}
+
+func checkPermission(client kubernetes.Interface, attributes authorizationv1.ResourceAttributes) model.ConnectivityCheck {
+	resource := attributes.Resource
+	if len(attributes.Subresource) > 0 {
+		resource = resource + "/" + attributes.Subresource
+	}
+	if len(attributes.Group) > 0 {
+		resource = resource + "." + attributes.Group
+	}
+	name := strings.Join([]string{CheckRBAC, attributes.Verb, resource, attributes.Namespace}, " ")
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+	}
+	result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+	if err != nil {
+		return newConnectivityCheck(name, err)
+	}
+	if !result.Status.Allowed {
+		message := "permission denied"
+		if len(result.Status.Reason) > 0 {
+			message = message + ": " + result.Status.Reason
+		}
+		return model.ConnectivityCheck{Name: name, Success: false, Message: message}
+	}
+	return model.ConnectivityCheck{Name: name, Success: true}
+}
+
+func (svc HelmServiceImpl) checkTiller(kubeconfig string) model.ConnectivityCheck {
+	tillerHost, tunnel, err := svc.GetHelmConnection().SetupConnection(kubeconfig)
+	defer svc.GetHelmConnection().Teardown(tunnel)
+	if err == nil {
+		_, err = svc.GetHelmConnection().NewClient(tillerHost).GetVersion()
+	}
+	return newConnectivityCheck(CheckTiller, err)
+}
+
+func newConnectivityCheck(name string, err error) model.ConnectivityCheck {
+	if err != nil {
+		return model.ConnectivityCheck{Name: name, Success: false, Message: err.Error()}
+	}
+	return model.ConnectivityCheck{Name: name, Success: true}
+}
@@ -44,6 +44,8 @@ func (svc HelmServiceImpl) GetTemplate(mutex *sync.Mutex, chartName string, vers
 	} else {
 		if kind == "deployment" {
 			result, err = svc.GetDeployment(chartName, version)
+		} else if kind == "schema" {
+			result, err = svc.GetSchema(chartName, version)
 		}
 	}
 	mutex.Unlock()
@@ -82,6 +84,32 @@ func (svc HelmServiceImpl) GetDeployment(chartName string, version string) ([]by
 
 }
 
+//GetSchema - Retrieve the values.schema.json of a chart, or nil if the chart does not have one
+func (svc HelmServiceImpl) GetSchema(chartName string, version string) ([]byte, error) {
+
+	logFields := global.AppFields{global.Function: "GetSchema"}
+
+	insp := &inspectCmd{
+		out: os.Stdout,
+	}
+
+	if len(version) > 0 {
+		insp.version = version
+	}
+
+	if err := insp.prepare(chartName); err != nil {
+		global.Logger.Error(logFields, "Error insp.prepare(): "+err.Error()+" on chart: "+chartName+" - "+version)
+		return nil, err
+	}
+
+	schema, err := insp.runGetSchema()
+	if err != nil {
+		global.Logger.Error(logFields, "Error insp.runGetSchema(): "+err.Error()+" on chart: "+chartName+" - "+version)
+		return nil, err
+	}
+	return schema, nil
+}
+
 //GetValues Method
 func (svc HelmServiceImpl) GetValues(chartName string, version string) ([]byte, error) {
 
@@ -153,6 +181,19 @@ func (i *inspectCmd) runGetDeployment() (*chart.Template, error) {
 	return result, nil
 }
 
+func (i *inspectCmd) runGetSchema() ([]byte, error) {
+	chrt, err := chartutil.Load(i.chartpath)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range chrt.Files {
+		if e.TypeUrl == "values.schema.json" {
+			return e.Value, nil
+		}
+	}
+	return nil, nil
+}
+
 func (i *inspectCmd) run() (*chart.Config, error) {
 	chrt, err := chartutil.Load(i.chartpath)
 	if err != nil {
@@ -27,6 +27,36 @@ func (_m *HelmServiceInterface) AddRepository(repo model.Repository) error {
 	return r0
 }
 
+// CheckConnectivity provides a mock function with given fields: kubeconfig, namespace
+func (_m *HelmServiceInterface) CheckConnectivity(kubeconfig string, namespace string) []model.ConnectivityCheck {
+	ret := _m.Called(kubeconfig, namespace)
+
+	var r0 []model.ConnectivityCheck
+	if rf, ok := ret.Get(0).(func(string, string) []model.ConnectivityCheck); ok {
+		r0 = rf(kubeconfig, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ConnectivityCheck)
+		}
+	}
+
+	return r0
+}
+
+// CreateNamespace provides a mock function with given fields: kubeconfig, namespace, labels, quota
+func (_m *HelmServiceInterface) CreateNamespace(kubeconfig string, namespace string, labels map[string]string, quota map[string]string) error {
+	ret := _m.Called(kubeconfig, namespace, labels, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, map[string]string, map[string]string) error); ok {
+		r0 = rf(kubeconfig, namespace, labels, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteHelmRelease provides a mock function with given fields: kubeconfig, releaseName, purge
 func (_m *HelmServiceInterface) DeleteHelmRelease(kubeconfig string, releaseName string, purge bool) error {
 	ret := _m.Called(kubeconfig, releaseName, purge)
@@ -41,6 +71,20 @@ func (_m *HelmServiceInterface) DeleteHelmRelease(kubeconfig string, releaseName
 	return r0
 }
 
+// DeleteNamespace provides a mock function with given fields: kubeconfig, namespace
+func (_m *HelmServiceInterface) DeleteNamespace(kubeconfig string, namespace string) error {
+	ret := _m.Called(kubeconfig, namespace)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(kubeconfig, namespace)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeletePod provides a mock function with given fields: kubeconfig, podName, namespace
 func (_m *HelmServiceInterface) DeletePod(kubeconfig string, podName string, namespace string) error {
 	ret := _m.Called(kubeconfig, podName, namespace)
@@ -205,6 +249,29 @@ func (_m *HelmServiceInterface) GetRepositories() ([]model.Repository, error) {
 	return r0, r1
 }
 
+// GetSchema provides a mock function with given fields: chartName, version
+func (_m *HelmServiceInterface) GetSchema(chartName string, version string) ([]byte, error) {
+	ret := _m.Called(chartName, version)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string, string) []byte); ok {
+		r0 = rf(chartName, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(chartName, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetServices provides a mock function with given fields: kubeconfig, namespace
 func (_m *HelmServiceInterface) GetServices(kubeconfig string, namespace string) ([]model.Service, error) {
 	ret := _m.Called(kubeconfig, namespace)
@@ -0,0 +1,94 @@
+package helmapi
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+//NamespaceQuotaName name of the resource quota tenkai manages in a namespace
+const NamespaceQuotaName = "tenkai-quota"
+
+//CreateNamespace - Create a namespace with labels and a resource quota, updating them when the namespace exists
+func (svc HelmServiceImpl) CreateNamespace(kubeconfig string, namespace string, labels map[string]string, quota map[string]string) error {
+	_, client, err := svc.GetHelmConnection().GetKubeClient("", kubeconfig)
+	if err != nil {
+		return err
+	}
+	return createNamespace(client, namespace, labels, quota)
+}
+
+//DeleteNamespace - Delete a namespace and everything inside it
+func (svc HelmServiceImpl) DeleteNamespace(kubeconfig string, namespace string) error {
+	_, client, err := svc.GetHelmConnection().GetKubeClient("", kubeconfig)
+	if err != nil {
+		return err
+	}
+	return deleteNamespace(client, namespace)
+}
+
+func createNamespace(client kubernetes.Interface, namespace string, labels map[string]string, quota map[string]string) error {
+	hard, err := getResourceList(quota)
+	if err != nil {
+		return err
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels}}
+	if _, err := client.CoreV1().Namespaces().Create(ns); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		if ns, err = client.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{}); err != nil {
+			return err
+		}
+		if ns.Labels == nil {
+			ns.Labels = make(map[string]string)
+		}
+		for k, v := range labels {
+			ns.Labels[k] = v
+		}
+		if _, err := client.CoreV1().Namespaces().Update(ns); err != nil {
+			return err
+		}
+	}
+
+	if len(hard) == 0 {
+		return nil
+	}
+
+	resourceQuota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: NamespaceQuotaName, Namespace: namespace},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+	}
+	if _, err := client.CoreV1().ResourceQuotas(namespace).Create(resourceQuota); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		_, err = client.CoreV1().ResourceQuotas(namespace).Update(resourceQuota)
+		return err
+	}
+	return nil
+}
+
+func deleteNamespace(client kubernetes.Interface, namespace string) error {
+	err := client.CoreV1().Namespaces().Delete(namespace, &metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+//getResourceList parses a quota like {"pods": "20", "limits.memory": "8Gi"}
+func getResourceList(quota map[string]string) (corev1.ResourceList, error) {
+	hard := make(corev1.ResourceList)
+	for name, value := range quota {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, err
+		}
+		hard[corev1.ResourceName(name)] = quantity
+	}
+	return hard, nil
+}
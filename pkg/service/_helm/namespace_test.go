@@ -0,0 +1,65 @@
+package helmapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	labels := map[string]string{"team": "alfa"}
+	quota := map[string]string{"pods": "20", "limits.memory": "8Gi"}
+	assert.NoError(t, createNamespace(client, "dev", labels, quota))
+
+	ns, err := client.CoreV1().Namespaces().Get("dev", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "alfa", ns.Labels["team"])
+
+	resourceQuota, err := client.CoreV1().ResourceQuotas("dev").Get(NamespaceQuotaName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	pods := resourceQuota.Spec.Hard["pods"]
+	memory := resourceQuota.Spec.Hard["limits.memory"]
+	assert.Equal(t, int64(20), pods.Value())
+	assert.Equal(t, 0, memory.Cmp(resource.MustParse("8Gi")))
+}
+
+func TestCreateNamespace_AlreadyExists(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	assert.NoError(t, createNamespace(client, "dev", map[string]string{"team": "alfa"}, map[string]string{"pods": "10"}))
+
+	assert.NoError(t, createNamespace(client, "dev", map[string]string{"stage": "qa"}, map[string]string{"pods": "30"}))
+
+	ns, err := client.CoreV1().Namespaces().Get("dev", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "alfa", ns.Labels["team"])
+	assert.Equal(t, "qa", ns.Labels["stage"])
+
+	resourceQuota, err := client.CoreV1().ResourceQuotas("dev").Get(NamespaceQuotaName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	pods := resourceQuota.Spec.Hard["pods"]
+	assert.Equal(t, int64(30), pods.Value())
+}
+
+func TestCreateNamespace_InvalidQuota(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	assert.Error(t, createNamespace(client, "dev", nil, map[string]string{"pods": "many"}))
+
+	_, err := client.CoreV1().Namespaces().Get("dev", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	assert.NoError(t, createNamespace(client, "dev", nil, nil))
+
+	assert.NoError(t, deleteNamespace(client, "dev"))
+	_, err := client.CoreV1().Namespaces().Get("dev", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	assert.NoError(t, deleteNamespace(client, "dev"))
+}
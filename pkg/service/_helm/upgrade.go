@@ -55,13 +55,14 @@ type upgradeCmd struct {
 
 //UpgradeRequest UpgradeRequest
 type UpgradeRequest struct {
-	Kubeconfig   string
-	Release      string
-	Chart        string
-	ChartVersion string
-	Namespace    string
-	Variables    []string
-	Dryrun       bool
+	Kubeconfig      string
+	Release         string
+	Chart           string
+	ChartVersion    string
+	Namespace       string
+	Variables       []string
+	StringVariables []string
+	Dryrun          bool
 }
 
 //Upgrade Method
@@ -98,6 +99,7 @@ func (svc HelmServiceImpl) Upgrade(upgradeRequest UpgradeRequest, out *bytes.Buf
 	upgrade.release = upgradeRequest.Release
 	upgrade.chart = upgradeRequest.Chart
 	upgrade.values = upgradeRequest.Variables
+	upgrade.stringValues = upgradeRequest.StringVariables
 	upgrade.wait = upgrade.wait || upgrade.atomic
 	upgrade.namespace = upgradeRequest.Namespace
 
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//KubeConfigProviderInterface builds the kubeconfig of an environment on demand
+type KubeConfigProviderInterface interface {
+	GetKubeConfig(env *model.Environment) (string, error)
+	Invalidate(envID uint)
+}
+
+type kubeConfigEntry struct {
+	fileName    string
+	fingerprint string
+}
+
+//KubeConfigProviderImpl writes temporary kubeconfig files built from the environments.
+//Files are cached per environment and rewritten when the environment changes or the file is gone,
+//so no kubeconfig has to exist before a request arrives.
+type KubeConfigProviderImpl struct {
+	BasePath string
+	mutex    sync.Mutex
+	cache    map[uint]kubeConfigEntry
+}
+
+//NewKubeConfigProvider creates a provider writing its kubeconfig files into basePath
+func NewKubeConfigProvider(basePath string) *KubeConfigProviderImpl {
+	return &KubeConfigProviderImpl{BasePath: basePath, cache: make(map[uint]kubeConfigEntry)}
+}
+
+//GetKubeConfig - Returns the kubeconfig file of an environment, whose credentials must be decrypted
+func (p *KubeConfigProviderImpl) GetKubeConfig(env *model.Environment) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fingerprint := getKubeConfigFingerprint(env)
+	if entry, ok := p.cache[env.ID]; ok && entry.fingerprint == fingerprint {
+		if _, err := os.Stat(entry.fileName); err == nil {
+			return entry.fileName, nil
+		}
+	}
+
+	if err := os.MkdirAll(p.BasePath, 0700); err != nil {
+		return "", err
+	}
+
+	fileName := filepath.Join(p.BasePath, strconv.Itoa(int(env.ID))+".kubeconfig")
+	if err := writeKubeConfig(fileName, buildKubeConfig(env)); err != nil {
+		return "", err
+	}
+
+	p.cache[env.ID] = kubeConfigEntry{fileName: fileName, fingerprint: fingerprint}
+	return fileName, nil
+}
+
+//Invalidate - Removes the cached kubeconfig of an environment
+func (p *KubeConfigProviderImpl) Invalidate(envID uint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if entry, ok := p.cache[envID]; ok {
+		os.Remove(entry.fileName)
+		delete(p.cache, envID)
+	}
+}
+
+func getKubeConfigFingerprint(env *model.Environment) string {
+	hash := sha256.New()
+	for _, field := range []string{env.Name, env.Namespace, env.ClusterURI, env.CACertificate, env.Token} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//writeKubeConfig replaces the file atomically, so a concurrent reader never sees it half written
+func writeKubeConfig(fileName string, content string) error {
+	file, err := ioutil.TempFile(filepath.Dir(fileName), ".kubeconfig-")
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), fileName)
+}
+
+func buildKubeConfig(env *model.Environment) string {
+	ca := strings.TrimSuffix(env.CACertificate, "\n")
+	caBase64 := base64.StdEncoding.EncodeToString([]byte(ca))
+
+	clusterUser := "xpto"
+	startIndex := strings.Index(env.Token, "kubeconfig-")
+	if startIndex > 0 {
+		startIndex = startIndex + 11
+		endIndex := strings.Index(env.Token, ":")
+		clusterUser = env.Token[startIndex:endIndex]
+	}
+
+	var kubeConfig strings.Builder
+	kubeConfig.WriteString("apiVersion: v1\n")
+	kubeConfig.WriteString("clusters:\n")
+	kubeConfig.WriteString("- cluster:\n")
+	kubeConfig.WriteString("    certificate-authority-data: " + caBase64 + "\n")
+	kubeConfig.WriteString("    server: " + env.ClusterURI + "\n")
+	kubeConfig.WriteString("  name: " + env.Name + "\n")
+	kubeConfig.WriteString("contexts:\n")
+	kubeConfig.WriteString("- context:\n")
+	kubeConfig.WriteString("    cluster: " + env.Name + "\n")
+	kubeConfig.WriteString("    namespace: " + env.Namespace + "\n")
+	kubeConfig.WriteString("    user: " + clusterUser + "\n")
+	kubeConfig.WriteString("  name: " + env.Name + "\n")
+	kubeConfig.WriteString("current-context: " + env.Name + "\n")
+	kubeConfig.WriteString("kind: Config\n")
+	kubeConfig.WriteString("preferences: {}\n")
+	kubeConfig.WriteString("users:\n")
+	kubeConfig.WriteString("- name: " + clusterUser + "\n")
+	kubeConfig.WriteString("  user:\n")
+	kubeConfig.WriteString("    token: " + env.Token + "\n")
+	return kubeConfig.String()
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func getEnvironment() *model.Environment {
+	env := &model.Environment{}
+	env.ID = 999
+	env.Group = "foo"
+	env.Name = "bar"
+	env.ClusterURI = "https://rancher-k8s-my-domain.com/k8s/clusters/c-kbfxr"
+	env.CACertificate = "my-certificate"
+	env.Token = "kubeconfig-user-ph111:abbkdd57t68tq2lppg6lwb65sb69282jhsmh3ndwn4vhjtt8blmhh2"
+	env.Namespace = "dev"
+	return env
+}
+
+func TestGetKubeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	provider := NewKubeConfigProvider(dir)
+	env := getEnvironment()
+
+	fileName, err := provider.GetKubeConfig(env)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadFile(fileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "    server: https://rancher-k8s-my-domain.com/k8s/clusters/c-kbfxr\n")
+	assert.Contains(t, string(content), "    token: "+env.Token+"\n")
+
+	env.Token = "kubeconfig-user-ph222:other"
+	sameFile, err := provider.GetKubeConfig(env)
+	assert.NoError(t, err)
+	assert.Equal(t, fileName, sameFile)
+	content, _ = ioutil.ReadFile(fileName)
+	assert.Contains(t, string(content), "    token: kubeconfig-user-ph222:other\n")
+}
+
+func TestGetKubeConfig_FileRemoved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	provider := NewKubeConfigProvider(dir)
+	env := getEnvironment()
+
+	fileName, err := provider.GetKubeConfig(env)
+	assert.NoError(t, err)
+	assert.NoError(t, os.RemoveAll(dir))
+
+	fileName, err = provider.GetKubeConfig(env)
+	assert.NoError(t, err)
+	_, err = os.Stat(fileName)
+	assert.NoError(t, err)
+}
+
+func TestInvalidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	provider := NewKubeConfigProvider(dir)
+	fileName, err := provider.GetKubeConfig(getEnvironment())
+	assert.NoError(t, err)
+
+	provider.Invalidate(999)
+	_, err = os.Stat(fileName)
+	assert.True(t, os.IsNotExist(err))
+	provider.Invalidate(999)
+}
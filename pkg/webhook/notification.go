@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+)
+
+//defaultTemplates are the messages of the events posted to the chat channels without a stored template
+var defaultTemplates = map[string]string{
+	model.HookDeployProduct:   `{{bold "Product deployed"}}: {{.productName}} {{.release}} in {{.environment}}`,
+	model.HookNewRelease:      `{{bold "New release"}}: {{.productName}} {{.release}}{{if .services}} ({{join .services ", "}}){{end}}`,
+	model.HookDeployStarted:   `{{bold "Deploy started"}}: {{.chart}} in {{.environment}}`,
+	model.HookDeploySucceeded: `{{bold "Deploy succeeded"}}: {{.chart}} in {{.environment}}`,
+	model.HookDeployFailed:    `{{bold "Deploy failed"}}: {{.chart}} in {{.environment}}: {{.message}}`,
+	model.HookDeployFinished: `{{bold "Deploy finished"}} in {{.environment}} by {{.user}}: ` +
+		`{{if .success}}succeeded{{else}}failed{{end}}{{if .charts}}` + "\n" + `Charts: {{join .charts ", "}}{{end}}` +
+		`{{if .failures}}` + "\n" + `Failures: {{join .failures ", "}}{{end}}`,
+	model.HookRollback:             `{{bold "Rollback"}}: {{.release}} to revision {{.revision}} in {{.environment}} by {{.user}}`,
+	model.HookReleaseDeleted:       `{{bold "Release deleted"}}: {{.release}} in {{.environment}} by {{.user}}`,
+	model.HookPromotionCompleted:   `{{bold "Promotion completed"}}: {{.sourceEnvironment}} to {{.environment}} ({{.mode}}) by {{.user}}`,
+	model.HookProductVersionLocked: `{{bold "Product version locked"}}: {{.productName}} {{.release}} by {{.user}}`,
+	model.HookVariableChanged:      `{{bold "Variable changed"}}: {{.scope}}/{{.name}} in {{.environment}} by {{.user}}`,
+}
+
+//IsChatChannel tells whether a channel posts messages rendered from templates
+func IsChatChannel(channel string) bool {
+	return channel == model.ChannelSlack || channel == model.ChannelTeams
+}
+
+//IsValidChannel tells whether a webhook channel is supported, an empty channel is a generic webhook
+func IsValidChannel(channel string) bool {
+	return len(channel) == 0 || channel == model.ChannelWebHook || IsChatChannel(channel)
+}
+
+//DefaultTemplate returns the default message template of an event
+func DefaultTemplate(event string) string {
+	if value, ok := defaultTemplates[event]; ok {
+		return value
+	}
+	return `{{bold .event}}`
+}
+
+//Events returns the events having a default message template
+func Events() []string {
+	events := make([]string, 0, len(defaultTemplates))
+	for event := range defaultTemplates {
+		events = append(events, event)
+	}
+	return events
+}
+
+func parseTemplate(channel string, text string) (*template.Template, error) {
+	marker := "*"
+	if channel == model.ChannelTeams {
+		marker = "**"
+	}
+	funcs := template.FuncMap{
+		"bold": func(value interface{}) string {
+			return marker + strings.TrimSpace(toString(value)) + marker
+		},
+		"join": func(values []interface{}, sep string) string {
+			items := make([]string, 0, len(values))
+			for _, v := range values {
+				items = append(items, toString(v))
+			}
+			return strings.Join(items, sep)
+		},
+	}
+	return template.New(channel).Funcs(funcs).Parse(text)
+}
+
+func toString(value interface{}) string {
+	if value, ok := value.(string); ok {
+		return value
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+//ValidateTemplate checks the syntax of a message template
+func ValidateTemplate(channel string, text string) error {
+	if !IsChatChannel(channel) {
+		return errors.New("templates are only supported by the slack and teams channels")
+	}
+	_, err := parseTemplate(channel, text)
+	return err
+}
+
+//RenderMessage renders the message of an event and wraps it in the body expected by the channel
+func RenderMessage(channel string, text string, event string, payload interface{}) ([]byte, error) {
+	tmpl, err := parseTemplate(channel, text)
+	if err != nil {
+		return nil, err
+	}
+
+	//Templates refer to the fields by their JSON names
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if _, ok := values["event"]; !ok {
+		values["event"] = event
+	}
+
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, values); err != nil {
+		return nil, err
+	}
+
+	if channel == model.ChannelTeams {
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  event,
+			"text":     message.String(),
+		})
+	}
+	return json.Marshal(map[string]string{"text": message.String()})
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getDeployFinishedPayload() model.WebHookDeployFinishedPayload {
+	return model.WebHookDeployFinishedPayload{
+		Event:       model.HookDeployFinished,
+		Environment: "dev",
+		User:        "beta@alfa.com",
+		Success:     false,
+		Charts:      []string{"repo/alfa", "repo/beta"},
+		Failures:    []string{"repo/beta: timeout"},
+	}
+}
+
+func TestRenderMessage_Slack(t *testing.T) {
+	data, err := RenderMessage(model.ChannelSlack, DefaultTemplate(model.HookDeployFinished),
+		model.HookDeployFinished, getDeployFinishedPayload())
+	assert.NoError(t, err)
+
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, "*Deploy finished* in dev by beta@alfa.com: failed\nCharts: repo/alfa, repo/beta\nFailures: repo/beta: timeout",
+		body["text"])
+}
+
+func TestRenderMessage_Teams(t *testing.T) {
+	data, err := RenderMessage(model.ChannelTeams, `{{bold .event}} {{.environment}}`,
+		model.HookDeployFinished, getDeployFinishedPayload())
+	assert.NoError(t, err)
+
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, "MessageCard", body["@type"])
+	assert.Equal(t, model.HookDeployFinished, body["summary"])
+	assert.Equal(t, "**HOOK_DEPLOY_FINISHED** dev", body["text"])
+}
+
+func TestValidateTemplate(t *testing.T) {
+	assert.NoError(t, ValidateTemplate(model.ChannelSlack, `{{.environment}}`))
+	assert.Error(t, ValidateTemplate(model.ChannelSlack, `{{.environment`))
+	assert.Error(t, ValidateTemplate(model.ChannelWebHook, `{{.environment}}`))
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	for _, event := range Events() {
+		assert.NoError(t, ValidateTemplate(model.ChannelSlack, DefaultTemplate(event)), event)
+	}
+	assert.Equal(t, `{{bold .event}}`, DefaultTemplate("HOOK_UNKNOWN"))
+}
+
+func TestDispatch_ChatChannelUsesStoredTemplate(t *testing.T) {
+	mockTemplateDAO := &mockRepo.NotificationTemplateDAOInterface{}
+	mockTemplateDAO.On("GetNotificationTemplate", model.ChannelSlack, model.HookDeployFinished).
+		Return(&model.NotificationTemplate{Template: `Done in {{.environment}}`}, nil)
+
+	mockDeliveryDAO := &mockRepo.WebHookDeliveryDAOInterface{}
+	mockDeliveryDAO.On("CreateWebHookDelivery", mock.MatchedBy(func(item model.WebHookDelivery) bool {
+		return item.Payload == `{"text":"Done in dev"}`
+	})).Return(1, nil)
+	mockDeliveryDAO.On("EditWebHookDelivery", mock.Anything).Return(nil)
+
+	dispatcher := NewDispatcher(mockDeliveryDAO, mockTemplateDAO, "passkey")
+	dispatcher.MaxAttempts = 1
+
+	hook := model.WebHook{Type: model.HookDeployFinished, Channel: model.ChannelSlack, URL: "http://127.0.0.1:0"}
+	id, err := dispatcher.Dispatch(hook, getDeployFinishedPayload())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	mockTemplateDAO.AssertExpectations(t)
+}
@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	"github.com/softplan/tenkai-api/pkg/dbms/repository"
+	"github.com/softplan/tenkai-api/pkg/global"
+	"github.com/softplan/tenkai-api/pkg/util"
+)
+
+//Headers sent with every webhook request
+const (
+	SignatureHeader = "X-Tenkai-Signature"
+	EventHeader     = "X-Tenkai-Event"
+	DeliveryHeader  = "X-Tenkai-Delivery"
+)
+
+//Defaults of the dispatcher
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 2 * time.Second
+)
+
+//responseExcerptSize is the size of the response body kept in the delivery log
+const responseExcerptSize = 1024
+
+//DispatcherInterface DispatcherInterface
+type DispatcherInterface interface {
+	Dispatch(hook model.WebHook, payload interface{}) (int, error)
+	Redeliver(hook model.WebHook, delivery model.WebHookDelivery) (int, error)
+}
+
+//Dispatcher delivers the webhook events in background, logging every delivery and
+//retrying the failed attempts with an exponential backoff. Events sent to the chat
+//channels are rendered as messages with the templates of the events.
+type Dispatcher struct {
+	DeliveryDAO repository.WebHookDeliveryDAOInterface
+	TemplateDAO repository.NotificationTemplateDAOInterface
+	Passkey     string
+	MaxAttempts int
+	Backoff     time.Duration
+	Client      *http.Client
+}
+
+//NewDispatcher creates a dispatcher with the default attempts and backoff
+func NewDispatcher(deliveryDAO repository.WebHookDeliveryDAOInterface,
+	templateDAO repository.NotificationTemplateDAOInterface, passkey string) *Dispatcher {
+	return &Dispatcher{
+		DeliveryDAO: deliveryDAO,
+		TemplateDAO: templateDAO,
+		Passkey:     passkey,
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Client:      &http.Client{},
+	}
+}
+
+//Dispatch logs a new delivery of the payload to the hook and delivers it in background.
+//It returns the id of the delivery.
+func (d *Dispatcher) Dispatch(hook model.WebHook, payload interface{}) (int, error) {
+	data, err := d.render(hook, payload)
+	if err != nil {
+		return -1, err
+	}
+	return d.start(hook, model.WebHookDelivery{
+		WebHookID: hook.ID,
+		Event:     hook.Type,
+		URL:       hook.URL,
+		Payload:   string(data),
+		Status:    model.WebHookDeliveryPending,
+	})
+}
+
+//Redeliver delivers again the payload of a previous delivery, logged as a new delivery
+func (d *Dispatcher) Redeliver(hook model.WebHook, delivery model.WebHookDelivery) (int, error) {
+	return d.start(hook, model.WebHookDelivery{
+		WebHookID:    hook.ID,
+		Event:        delivery.Event,
+		URL:          hook.URL,
+		Payload:      delivery.Payload,
+		Status:       model.WebHookDeliveryPending,
+		RedeliveryOf: delivery.ID,
+	})
+}
+
+//render returns the body posted to a hook, the JSON of the payload or the message of a chat channel
+func (d *Dispatcher) render(hook model.WebHook, payload interface{}) ([]byte, error) {
+	if !IsChatChannel(hook.Channel) {
+		return json.Marshal(payload)
+	}
+
+	text := DefaultTemplate(hook.Type)
+	if d.TemplateDAO != nil {
+		stored, err := d.TemplateDAO.GetNotificationTemplate(hook.Channel, hook.Type)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			text = stored.Template
+		}
+	}
+	return RenderMessage(hook.Channel, text, hook.Type, payload)
+}
+
+func (d *Dispatcher) start(hook model.WebHook, delivery model.WebHookDelivery) (int, error) {
+	id, err := d.DeliveryDAO.CreateWebHookDelivery(delivery)
+	if err != nil {
+		return -1, err
+	}
+	delivery.ID = uint(id)
+	go d.deliver(hook, delivery)
+	return id, nil
+}
+
+//deliver posts the payload until the hook accepts it, rejects it or the attempts run out
+func (d *Dispatcher) deliver(hook model.WebHook, delivery model.WebHookDelivery) model.WebHookDelivery {
+	secret := d.decryptSecret(hook.Secret)
+	timeout := DefaultTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+
+	for {
+		delivery.Attempts++
+		retry := d.attempt(&delivery, secret, timeout)
+		if !retry || delivery.Attempts >= d.MaxAttempts {
+			break
+		}
+		d.save(delivery)
+		time.Sleep(d.Backoff << uint(delivery.Attempts-1))
+	}
+	if delivery.Status != model.WebHookDeliveryDelivered {
+		delivery.Status = model.WebHookDeliveryFailed
+	}
+	d.save(delivery)
+	return delivery
+}
+
+//attempt posts the payload once and records the response, it returns whether the attempt can be retried
+func (d *Dispatcher) attempt(delivery *model.WebHookDelivery, secret string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(global.ContentType, global.JSONContentType)
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(int(delivery.ID)))
+	if len(secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, []byte(delivery.Payload)))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		delivery.StatusCode = 0
+		delivery.ResponseBody = ""
+		delivery.Error = err.Error()
+		return true
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, responseExcerptSize))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(body)
+	delivery.Error = ""
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Status = model.WebHookDeliveryDelivered
+		return false
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+func (d *Dispatcher) save(delivery model.WebHookDelivery) {
+	if err := d.DeliveryDAO.EditWebHookDelivery(delivery); err != nil {
+		global.Logger.Error(global.AppFields{global.Function: "deliver", "url": delivery.URL},
+			"Error saving webhook delivery: "+err.Error())
+	}
+}
+
+//decryptSecret returns the plain secret of a hook, or the value itself when it is not encrypted
+func (d *Dispatcher) decryptSecret(secret string) string {
+	byteValues, err := hex.DecodeString(secret)
+	if err != nil {
+		return secret
+	}
+	plain, err := util.Decrypt(byteValues, d.Passkey)
+	if err != nil {
+		return secret
+	}
+	return string(plain)
+}
+
+//Sign computes the HMAC-SHA256 of a payload, hex encoded, as sent in the X-Tenkai-Signature header
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/softplan/tenkai-api/pkg/dbms/model"
+	mockRepo "github.com/softplan/tenkai-api/pkg/dbms/repository/mocks"
+	"github.com/softplan/tenkai-api/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDispatcher() (*Dispatcher, *mockRepo.WebHookDeliveryDAOInterface) {
+	mockDeliveryDAO := &mockRepo.WebHookDeliveryDAOInterface{}
+	mockDeliveryDAO.On("EditWebHookDelivery", mock.Anything).Return(nil)
+	dispatcher := NewDispatcher(mockDeliveryDAO, nil, "passkey")
+	dispatcher.Backoff = time.Millisecond
+	dispatcher.MaxAttempts = 3
+	return dispatcher, mockDeliveryDAO
+}
+
+func TestDeliver_Signed(t *testing.T) {
+	var signature, event, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		signature = r.Header.Get(SignatureHeader)
+		event = r.Header.Get(EventHeader)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher()
+	secret := hex.EncodeToString(util.Encrypt([]byte("my-secret"), "passkey"))
+	hook := model.WebHook{Type: "HOOK_DEPLOY_PRODUCT", URL: server.URL, Secret: secret}
+	delivery := model.WebHookDelivery{Event: hook.Type, URL: hook.URL, Payload: `{"release":"1.0"}`}
+
+	result := dispatcher.deliver(hook, delivery)
+
+	assert.Equal(t, model.WebHookDeliveryDelivered, result.Status)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "ok", result.ResponseBody)
+	assert.Equal(t, `{"release":"1.0"}`, body)
+	assert.Equal(t, "HOOK_DEPLOY_PRODUCT", event)
+	assert.Equal(t, "sha256="+Sign("my-secret", []byte(body)), signature)
+}
+
+func TestDeliver_Retries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher, mockDeliveryDAO := newTestDispatcher()
+	result := dispatcher.deliver(model.WebHook{URL: server.URL}, model.WebHookDelivery{URL: server.URL, Payload: "{}"})
+
+	assert.Equal(t, model.WebHookDeliveryDelivered, result.Status)
+	assert.Equal(t, 3, result.Attempts)
+	mockDeliveryDAO.AssertNumberOfCalls(t, "EditWebHookDelivery", 3)
+}
+
+func TestDeliver_GivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher()
+	result := dispatcher.deliver(model.WebHook{URL: server.URL}, model.WebHookDelivery{URL: server.URL, Payload: "{}"})
+
+	assert.Equal(t, model.WebHookDeliveryFailed, result.Status)
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, "boom", result.ResponseBody)
+}
+
+func TestDeliver_RejectedIsNotRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher()
+	result := dispatcher.deliver(model.WebHook{URL: server.URL}, model.WebHookDelivery{URL: server.URL, Payload: "{}"})
+
+	assert.Equal(t, model.WebHookDeliveryFailed, result.Status)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+}
+
+func TestDeliver_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher()
+	dispatcher.MaxAttempts = 1
+	result := dispatcher.deliver(model.WebHook{URL: server.URL, TimeoutSeconds: 1}, model.WebHookDelivery{URL: server.URL, Payload: "{}"})
+
+	assert.Equal(t, model.WebHookDeliveryFailed, result.Status)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestRedeliver(t *testing.T) {
+	dispatcher, mockDeliveryDAO := newTestDispatcher()
+	mockDeliveryDAO.On("CreateWebHookDelivery", mock.MatchedBy(func(item model.WebHookDelivery) bool {
+		return item.RedeliveryOf == 7 && item.Payload == "{}" && item.Status == model.WebHookDeliveryPending
+	})).Return(8, nil)
+	dispatcher.MaxAttempts = 1
+
+	delivery := model.WebHookDelivery{Payload: "{}"}
+	delivery.ID = 7
+	id, err := dispatcher.Redeliver(model.WebHook{URL: "http://127.0.0.1:0"}, delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, id)
+}